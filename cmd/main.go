@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,7 +12,9 @@ import (
 	"github.com/NathanSanchezDev/go-insight/internal/api"
 	"github.com/NathanSanchezDev/go-insight/internal/config"
 	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
 	"github.com/NathanSanchezDev/go-insight/internal/middleware"
+	"github.com/NathanSanchezDev/go-insight/internal/notify"
 )
 
 func main() {
@@ -21,6 +24,22 @@ func main() {
 	}
 
 	db.InitDB(cfg)
+	startJobs(context.Background(), cfg)
+	middleware.SetAPIKeyStore(api.APIKeyStore{})
+	notify.AllowPrivateNetworks = os.Getenv("NOTIFY_ALLOW_PRIVATE_NETWORKS") == "true"
+
+	if err := middleware.CheckJWTSettings(); err != nil {
+		log.Fatal("Invalid JWT settings:", err)
+	}
+
+	loginEnabled, err := api.SetupLogin()
+	if err != nil {
+		log.Fatal("Invalid login settings:", err)
+	}
+	if loginEnabled {
+		middleware.SetSessionStore(api.SessionStore{})
+	}
+
 	router := api.SetupRoutes()
 
 	// Apply middleware to main router, but auth will check if path needs it
@@ -34,9 +53,24 @@ func main() {
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), router))
 }
 
+func startJobs(ctx context.Context, cfg *config.Config) {
+	jobs.Start(ctx, api.DependencyGraphJob(jobs.ParseInterval(cfg.Jobs.DependencyGraphInterval, time.Minute)))
+	jobs.Start(ctx, api.SpanMetricsJob(jobs.ParseInterval(cfg.Jobs.SpanMetricsInterval, time.Minute)))
+	jobs.Start(ctx, api.LogPatternsJob(jobs.ParseInterval(cfg.Jobs.LogPatternsInterval, time.Minute)))
+	jobs.Start(ctx, api.LiveMetricsJob())
+	jobs.Start(ctx, api.AlertRulesJob(jobs.ParseInterval(cfg.Jobs.AlertRulesInterval, 10*time.Second)))
+	jobs.Start(ctx, api.NotificationsJob(jobs.ParseInterval(cfg.Jobs.NotificationsInterval, 10*time.Second)))
+	jobs.Start(ctx, api.SLOsJob(jobs.ParseInterval(cfg.Jobs.SLOInterval, time.Minute)))
+	jobs.Start(ctx, api.AnomaliesJob(jobs.ParseInterval(cfg.Jobs.AnomaliesInterval, 5*time.Minute)))
+}
+
 func conditionalAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !middleware.RequiresAuth(r.URL.Path) {
+			if middleware.RequiresSession(r.URL.Path) {
+				middleware.SessionMiddleware(next).ServeHTTP(w, r)
+				return
+			}
 			log.Printf("📖 Public endpoint accessed: %s %s", r.Method, r.RequestURI)
 			next.ServeHTTP(w, r)
 			return
@@ -6,6 +6,7 @@ toolchain go1.23.6
 
 require (
 	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.5.3
 	github.com/jackc/pgx/v5 v5.7.2
 	github.com/joho/godotenv v1.5.1
 	gopkg.in/yaml.v3 v3.0.1
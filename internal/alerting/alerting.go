@@ -0,0 +1,82 @@
+// Package alerting holds the storage independent parts of the alert
+// engine: threshold comparisons, the pending/firing/resolved state machine,
+// label fingerprints, and the noise control applied to notifications:
+// silences, inhibition and grouping.
+package alerting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+// Comparisons accepted by alert rules.
+var comparisons = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// ValidComparison reports whether op is a supported comparison operator.
+func ValidComparison(op string) bool {
+	_, ok := comparisons[op]
+	return ok
+}
+
+// Breached reports whether value op threshold holds. Unknown operators
+// never breach.
+func Breached(op string, value, threshold float64) bool {
+	compare, ok := comparisons[op]
+	return ok && compare(value, threshold)
+}
+
+// Next returns the state and active-since time of an alert after an
+// evaluation at now, given its current state and whether the condition
+// held. A condition holding for forDuration or longer fires the alert.
+func Next(state string, activeSince time.Time, breached bool, now time.Time, forDuration time.Duration) (string, time.Time) {
+	if !breached {
+		switch state {
+		case models.AlertStateFiring:
+			return models.AlertStateResolved, activeSince
+		case models.AlertStatePending:
+			return models.AlertStateInactive, time.Time{}
+		default:
+			return state, activeSince
+		}
+	}
+
+	if state != models.AlertStatePending && state != models.AlertStateFiring {
+		state, activeSince = models.AlertStatePending, now
+	}
+	if state == models.AlertStatePending && now.Sub(activeSince) >= forDuration {
+		state = models.AlertStateFiring
+	}
+	return state, activeSince
+}
+
+// Fingerprint identifies a label set independently of key order.
+func Fingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteByte(0)
+		b.WriteString(labels[key])
+		b.WriteByte(0)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
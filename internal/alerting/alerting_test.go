@@ -0,0 +1,71 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestBreached(t *testing.T) {
+	cases := []struct {
+		op   string
+		want bool
+	}{
+		{">", true}, {">=", true}, {"<", false}, {"<=", false}, {"==", false}, {"!=", true}, {"~", false},
+	}
+	for _, tc := range cases {
+		if got := Breached(tc.op, 5, 3); got != tc.want {
+			t.Errorf("5 %s 3: expected %v, got %v", tc.op, tc.want, got)
+		}
+	}
+}
+
+func TestNextLifecycle(t *testing.T) {
+	start := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	forDuration := 2 * time.Minute
+
+	steps := []struct {
+		offset   time.Duration
+		breached bool
+		want     string
+	}{
+		{0, true, models.AlertStatePending},
+		{time.Minute, true, models.AlertStatePending},
+		{2 * time.Minute, true, models.AlertStateFiring},
+		{3 * time.Minute, true, models.AlertStateFiring},
+		{4 * time.Minute, false, models.AlertStateResolved},
+		{5 * time.Minute, false, models.AlertStateResolved},
+		{6 * time.Minute, true, models.AlertStatePending},
+		{7 * time.Minute, false, models.AlertStateInactive},
+	}
+
+	state, since := models.AlertStateInactive, time.Time{}
+	for _, step := range steps {
+		state, since = Next(state, since, step.breached, start.Add(step.offset), forDuration)
+		if state != step.want {
+			t.Fatalf("at +%s: expected %s, got %s", step.offset, step.want, state)
+		}
+	}
+}
+
+func TestNextFiresImmediatelyWithoutForDuration(t *testing.T) {
+	now := time.Now()
+	state, since := Next(models.AlertStateInactive, time.Time{}, true, now, 0)
+	if state != models.AlertStateFiring || !since.Equal(now) {
+		t.Errorf("expected firing since now, got %s since %v", state, since)
+	}
+}
+
+func TestFingerprintIgnoresKeyOrder(t *testing.T) {
+	a := Fingerprint(map[string]string{"alertname": "HighLatency", "service": "api"})
+	b := Fingerprint(map[string]string{"service": "api", "alertname": "HighLatency"})
+	c := Fingerprint(map[string]string{"alertname": "HighLatency", "service": "web"})
+
+	if a != b {
+		t.Errorf("expected equal fingerprints, got %s and %s", a, b)
+	}
+	if a == c {
+		t.Error("expected different label sets to differ")
+	}
+}
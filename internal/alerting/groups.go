@@ -0,0 +1,47 @@
+package alerting
+
+import (
+	"slices"
+	"time"
+)
+
+// GroupLabels returns the labels among groupBy that alerts are grouped by.
+// Missing labels group as empty values.
+func GroupLabels(groupBy []string, labels map[string]string) map[string]string {
+	group := make(map[string]string, len(groupBy))
+	for _, name := range groupBy {
+		group[name] = labels[name]
+	}
+	return group
+}
+
+// GroupTiming controls when a notification group is announced: GroupWait
+// after it appears, GroupInterval after a change to its firing alerts, and
+// RepeatInterval after the last notification while nothing changes.
+type GroupTiming struct {
+	GroupWait      time.Duration
+	GroupInterval  time.Duration
+	RepeatInterval time.Duration
+}
+
+// GroupState is what was last sent for a notification group.
+type GroupState struct {
+	// Fingerprints are the sorted firing alerts of the last notification.
+	Fingerprints   []string
+	CreatedAt      time.Time
+	LastNotifiedAt *time.Time
+}
+
+// ShouldNotify reports whether a group whose firing alerts are now the
+// sorted fingerprints firing is due a notification at now.
+func ShouldNotify(state GroupState, firing []string, timing GroupTiming, now time.Time) bool {
+	if state.LastNotifiedAt == nil {
+		return len(firing) > 0 && now.Sub(state.CreatedAt) >= timing.GroupWait
+	}
+
+	since := now.Sub(*state.LastNotifiedAt)
+	if !slices.Equal(state.Fingerprints, firing) {
+		return since >= timing.GroupInterval
+	}
+	return len(firing) > 0 && since >= timing.RepeatInterval
+}
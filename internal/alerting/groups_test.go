@@ -0,0 +1,51 @@
+package alerting
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGroupLabels(t *testing.T) {
+	labels := map[string]string{"alertname": "HighLatency", "service": "api", "path": "/users"}
+
+	got := GroupLabels([]string{"alertname", "service", "env"}, labels)
+	want := map[string]string{"alertname": "HighLatency", "service": "api", "env": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestShouldNotify(t *testing.T) {
+	start := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	timing := GroupTiming{GroupWait: 30 * time.Second, GroupInterval: 5 * time.Minute, RepeatInterval: 4 * time.Hour}
+	notified := start.Add(time.Minute)
+
+	cases := []struct {
+		name   string
+		state  GroupState
+		firing []string
+		now    time.Time
+		want   bool
+	}{
+		{"new group waits", GroupState{CreatedAt: start}, []string{"a"}, start.Add(10 * time.Second), false},
+		{"new group after wait", GroupState{CreatedAt: start}, []string{"a"}, start.Add(30 * time.Second), true},
+		{"new group without firing alerts", GroupState{CreatedAt: start}, nil, start.Add(time.Minute), false},
+		{"unchanged before repeat", GroupState{Fingerprints: []string{"a"}, LastNotifiedAt: &notified},
+			[]string{"a"}, notified.Add(time.Hour), false},
+		{"unchanged after repeat", GroupState{Fingerprints: []string{"a"}, LastNotifiedAt: &notified},
+			[]string{"a"}, notified.Add(4 * time.Hour), true},
+		{"changed before interval", GroupState{Fingerprints: []string{"a"}, LastNotifiedAt: &notified},
+			[]string{"a", "b"}, notified.Add(time.Minute), false},
+		{"changed after interval", GroupState{Fingerprints: []string{"a"}, LastNotifiedAt: &notified},
+			[]string{"a", "b"}, notified.Add(5 * time.Minute), true},
+		{"all resolved", GroupState{Fingerprints: []string{"a"}, LastNotifiedAt: &notified},
+			nil, notified.Add(5 * time.Minute), true},
+	}
+
+	for _, tc := range cases {
+		if got := ShouldNotify(tc.state, tc.firing, timing, tc.now); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
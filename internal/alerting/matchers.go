@@ -0,0 +1,181 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+type matcher struct {
+	models.LabelMatcher
+	re *regexp.Regexp
+}
+
+func (m matcher) matches(labels map[string]string) bool {
+	value := labels[m.Name]
+	switch m.Op {
+	case models.MatchEqual:
+		return value == m.Value
+	case models.MatchNotEqual:
+		return value != m.Value
+	case models.MatchRegexp:
+		return m.re.MatchString(value)
+	default:
+		return !m.re.MatchString(value)
+	}
+}
+
+// Matchers is a compiled set of label matchers that all have to match.
+type Matchers []matcher
+
+// CompileMatchers validates matchers and compiles their regular expressions.
+func CompileMatchers(matchers []models.LabelMatcher) (Matchers, error) {
+	compiled := make(Matchers, 0, len(matchers))
+	for _, m := range matchers {
+		if m.Name == "" {
+			return nil, errors.New("matcher name is required")
+		}
+
+		c := matcher{LabelMatcher: m}
+		switch m.Op {
+		case models.MatchEqual, models.MatchNotEqual:
+		case models.MatchRegexp, models.MatchNotRegexp:
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression for %s: %w", m.Name, err)
+			}
+			c.re = re
+		default:
+			return nil, fmt.Errorf("invalid matcher operator: %q", m.Op)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// Matches reports whether labels satisfy every matcher. An empty set matches
+// all labels.
+func (ms Matchers) Matches(labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesEmpty reports whether the matchers accept an alert without labels,
+// which means they would match nearly everything.
+func (ms Matchers) MatchesEmpty() bool {
+	return ms.Matches(map[string]string{})
+}
+
+// SilenceStatus returns whether a silence is pending, active or expired at
+// now.
+func SilenceStatus(silence models.Silence, now time.Time) string {
+	switch {
+	case now.Before(silence.StartsAt):
+		return models.SilencePending
+	case now.Before(silence.EndsAt):
+		return models.SilenceActive
+	default:
+		return models.SilenceExpired
+	}
+}
+
+type compiledSilence struct {
+	id       int
+	matchers Matchers
+}
+
+type compiledInhibitRule struct {
+	source, target Matchers
+	equal          []string
+}
+
+// Suppressor decides which alerts are muted by silences and inhibition
+// rules.
+type Suppressor struct {
+	silences []compiledSilence
+	rules    []compiledInhibitRule
+	firing   []map[string]string
+}
+
+// NewSuppressor prepares the silences active at now and the inhibition
+// rules. firing holds the labels of the firing alerts, which are the
+// possible inhibition sources.
+func NewSuppressor(silences []models.Silence, rules []models.InhibitRule, firing []map[string]string, now time.Time) (*Suppressor, error) {
+	s := &Suppressor{firing: firing}
+
+	for _, silence := range silences {
+		if SilenceStatus(silence, now) != models.SilenceActive {
+			continue
+		}
+		matchers, err := CompileMatchers(silence.Matchers)
+		if err != nil {
+			return nil, fmt.Errorf("silence %d: %w", silence.ID, err)
+		}
+		s.silences = append(s.silences, compiledSilence{id: silence.ID, matchers: matchers})
+	}
+
+	for _, rule := range rules {
+		source, err := CompileMatchers(rule.SourceMatchers)
+		if err != nil {
+			return nil, fmt.Errorf("inhibit rule %d: %w", rule.ID, err)
+		}
+		target, err := CompileMatchers(rule.TargetMatchers)
+		if err != nil {
+			return nil, fmt.Errorf("inhibit rule %d: %w", rule.ID, err)
+		}
+		s.rules = append(s.rules, compiledInhibitRule{source: source, target: target, equal: rule.Equal})
+	}
+
+	return s, nil
+}
+
+// SilencedBy returns the IDs of the active silences matching labels.
+func (s *Suppressor) SilencedBy(labels map[string]string) []int {
+	var ids []int
+	for _, silence := range s.silences {
+		if silence.matchers.Matches(labels) {
+			ids = append(ids, silence.id)
+		}
+	}
+	return ids
+}
+
+// Inhibited reports whether a firing alert other than the one with labels
+// inhibits it.
+func (s *Suppressor) Inhibited(labels map[string]string) bool {
+	fingerprint := Fingerprint(labels)
+
+	for _, rule := range s.rules {
+		if !rule.target.Matches(labels) {
+			continue
+		}
+		for _, source := range s.firing {
+			if rule.source.Matches(source) && equalLabels(rule.equal, source, labels) &&
+				Fingerprint(source) != fingerprint {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Suppressed reports whether notifications for labels are muted.
+func (s *Suppressor) Suppressed(labels map[string]string) bool {
+	return len(s.SilencedBy(labels)) > 0 || s.Inhibited(labels)
+}
+
+func equalLabels(names []string, a, b map[string]string) bool {
+	for _, name := range names {
+		if a[name] != b[name] {
+			return false
+		}
+	}
+	return true
+}
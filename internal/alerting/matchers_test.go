@@ -0,0 +1,127 @@
+package alerting
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestMatchers(t *testing.T) {
+	matchers, err := CompileMatchers([]models.LabelMatcher{
+		{Name: "service", Op: models.MatchEqual, Value: "api"},
+		{Name: "alertname", Op: models.MatchRegexp, Value: "High.*"},
+		{Name: "severity", Op: models.MatchNotEqual, Value: "info"},
+		{Name: "path", Op: models.MatchNotRegexp, Value: "/health|/ready"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{"service": "api", "alertname": "HighLatency", "path": "/users"}, true},
+		{map[string]string{"service": "api", "alertname": "HighLatency", "severity": "info"}, false},
+		{map[string]string{"service": "api", "alertname": "VeryHighLatency"}, false},
+		{map[string]string{"service": "api", "alertname": "HighLatency", "path": "/health"}, false},
+		{map[string]string{"service": "web", "alertname": "HighLatency"}, false},
+	}
+	for _, tc := range cases {
+		if got := matchers.Matches(tc.labels); got != tc.want {
+			t.Errorf("%v: expected %v, got %v", tc.labels, tc.want, got)
+		}
+	}
+
+	if matchers.MatchesEmpty() {
+		t.Error("expected matchers not to match empty labels")
+	}
+
+	for _, invalid := range []models.LabelMatcher{
+		{Name: "", Op: models.MatchEqual},
+		{Name: "service", Op: "=="},
+		{Name: "service", Op: models.MatchRegexp, Value: "("},
+	} {
+		if _, err := CompileMatchers([]models.LabelMatcher{invalid}); err == nil {
+			t.Errorf("%+v: expected error", invalid)
+		}
+	}
+}
+
+func TestSilenceStatus(t *testing.T) {
+	now := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	silence := models.Silence{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+
+	if got := SilenceStatus(silence, now); got != models.SilenceActive {
+		t.Errorf("expected active, got %s", got)
+	}
+	if got := SilenceStatus(silence, now.Add(-2*time.Hour)); got != models.SilencePending {
+		t.Errorf("expected pending, got %s", got)
+	}
+	if got := SilenceStatus(silence, now.Add(time.Hour)); got != models.SilenceExpired {
+		t.Errorf("expected expired, got %s", got)
+	}
+}
+
+func TestSuppressorSilences(t *testing.T) {
+	now := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	silences := []models.Silence{
+		{ID: 1, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour),
+			Matchers: []models.LabelMatcher{{Name: "service", Op: models.MatchEqual, Value: "api"}}},
+		{ID: 2, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour),
+			Matchers: []models.LabelMatcher{{Name: "service", Op: models.MatchRegexp, Value: ".+"}}},
+	}
+
+	s, err := NewSuppressor(silences, nil, nil, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := s.SilencedBy(map[string]string{"service": "api"}); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("expected silence 1, got %v", got)
+	}
+	if s.Suppressed(map[string]string{"service": "web"}) {
+		t.Error("expired silence should not apply")
+	}
+}
+
+func TestSuppressorInhibition(t *testing.T) {
+	rules := []models.InhibitRule{{
+		ID:             1,
+		SourceMatchers: []models.LabelMatcher{{Name: "alertname", Op: models.MatchEqual, Value: "ServiceDown"}},
+		TargetMatchers: []models.LabelMatcher{{Name: "alertname", Op: models.MatchRegexp, Value: ".*Latency"}},
+		Equal:          []string{"service"},
+	}}
+	down := map[string]string{"alertname": "ServiceDown", "service": "api"}
+
+	s, err := NewSuppressor(nil, rules, []map[string]string{down}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{"alertname": "HighLatency", "service": "api"}, true},
+		{map[string]string{"alertname": "HighLatency", "service": "web"}, false},
+		{map[string]string{"alertname": "HighErrorRate", "service": "api"}, false},
+		{down, false},
+	}
+	for _, tc := range cases {
+		if got := s.Inhibited(tc.labels); got != tc.want {
+			t.Errorf("%v: expected %v, got %v", tc.labels, tc.want, got)
+		}
+	}
+
+	selfRule := []models.InhibitRule{{
+		SourceMatchers: []models.LabelMatcher{{Name: "service", Op: models.MatchEqual, Value: "api"}},
+		TargetMatchers: []models.LabelMatcher{{Name: "service", Op: models.MatchEqual, Value: "api"}},
+	}}
+	s, _ = NewSuppressor(nil, selfRule, []map[string]string{down}, time.Now())
+	if s.Inhibited(down) {
+		t.Error("an alert must not inhibit itself")
+	}
+}
@@ -0,0 +1,115 @@
+// Package anomaly flags values that deviate from a robust baseline of
+// comparable past values.
+//
+// The baseline is the median of the history, and its spread is the median
+// absolute deviation (MAD) scaled to estimate a standard deviation. Unlike a
+// mean and standard deviation, both ignore the outliers the detector is
+// looking for. A value is anomalous when it lies more than Threshold spreads
+// from the median.
+package anomaly
+
+import (
+	"math"
+	"slices"
+)
+
+// Anomaly directions.
+const (
+	DirectionHigh = "high"
+	DirectionLow  = "low"
+)
+
+// madScale turns the median absolute deviation of normally distributed
+// values into an estimate of their standard deviation.
+const madScale = 1.4826
+
+// Baseline summarizes the history a value is compared against.
+type Baseline struct {
+	Median float64
+	// Spread estimates the standard deviation of the history. It is never
+	// below the floors of the detector that computed it.
+	Spread  float64
+	Samples int
+}
+
+// Score returns how many spreads value lies above (positive) or below
+// (negative) the median.
+func (b Baseline) Score(value float64) float64 {
+	if b.Spread == 0 {
+		if value == b.Median {
+			return 0
+		}
+		return math.Copysign(math.Inf(1), value-b.Median)
+	}
+	return (value - b.Median) / b.Spread
+}
+
+// Result is the comparison of a value with its baseline.
+type Result struct {
+	Value     float64
+	Baseline  Baseline
+	Score     float64
+	Direction string
+}
+
+// Detector compares values with baselines of their history.
+type Detector struct {
+	// Threshold is the score beyond which a value is anomalous.
+	Threshold float64
+	// MinSamples is the history needed before values are judged.
+	MinSamples int
+	// RelativeSpread is a floor on the spread as a fraction of the median,
+	// so that very steady histories do not turn small changes into
+	// anomalies.
+	RelativeSpread float64
+}
+
+// DefaultDetector flags values beyond 3.5 spreads once there are 12 samples,
+// with a spread of at least 5% of the median.
+var DefaultDetector = Detector{Threshold: 3.5, MinSamples: 12, RelativeSpread: 0.05}
+
+// Baseline computes the baseline of history. minSpread is an absolute floor
+// on the spread in the unit of the values.
+func (d Detector) Baseline(history []float64, minSpread float64) Baseline {
+	b := Baseline{Median: median(history), Samples: len(history)}
+
+	deviations := make([]float64, len(history))
+	for i, v := range history {
+		deviations[i] = math.Abs(v - b.Median)
+	}
+	b.Spread = max(madScale*median(deviations), d.RelativeSpread*math.Abs(b.Median), minSpread)
+
+	return b
+}
+
+// Detect compares value with the baseline of history and reports whether it
+// is anomalous. Without enough history nothing is anomalous.
+func (d Detector) Detect(value float64, history []float64, minSpread float64) (Result, bool) {
+	if len(history) < d.MinSamples || len(history) == 0 {
+		return Result{Value: value}, false
+	}
+
+	b := d.Baseline(history, minSpread)
+	result := Result{Value: value, Baseline: b, Score: b.Score(value), Direction: DirectionHigh}
+	if result.Score < 0 {
+		result.Direction = DirectionLow
+	}
+
+	return result, math.Abs(result.Score) > d.Threshold
+}
+
+// median returns the median of values, 0 when there are none.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
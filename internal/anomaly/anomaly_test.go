@@ -0,0 +1,78 @@
+package anomaly
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestMedian(t *testing.T) {
+	cases := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{nil, 0},
+	}
+	for _, tc := range cases {
+		if got := median(tc.values); got != tc.want {
+			t.Errorf("%v: expected %g, got %g", tc.values, tc.want, got)
+		}
+	}
+}
+
+func TestBaselineIgnoresOutliers(t *testing.T) {
+	history := []float64{10, 11, 9, 10, 12, 8, 10, 1000}
+
+	b := DefaultDetector.Baseline(history, 0)
+	if b.Median != 10 || b.Samples != 8 {
+		t.Errorf("unexpected baseline %+v", b)
+	}
+	// The deviations are 0, 1, 1, 0, 2, 2, 0, 990 with a median of 1.
+	if !approx(b.Spread, madScale) {
+		t.Errorf("expected a spread of %g, got %g", madScale, b.Spread)
+	}
+}
+
+func TestBaselineFloors(t *testing.T) {
+	steady := []float64{100, 100, 100, 100}
+
+	if b := DefaultDetector.Baseline(steady, 0); b.Spread != 5 {
+		t.Errorf("expected the relative floor of 5, got %g", b.Spread)
+	}
+	if b := DefaultDetector.Baseline(steady, 20); b.Spread != 20 {
+		t.Errorf("expected the absolute floor of 20, got %g", b.Spread)
+	}
+	if b := DefaultDetector.Baseline([]float64{0, 0, 0}, 0); b.Score(0) != 0 || !math.IsInf(b.Score(1), 1) {
+		t.Errorf("unexpected scores without spread: %g, %g", b.Score(0), b.Score(1))
+	}
+}
+
+func TestDetect(t *testing.T) {
+	history := make([]float64, 0, 12)
+	for i := range 12 {
+		history = append(history, float64(100+i%3-1))
+	}
+
+	if result, ok := DefaultDetector.Detect(103, history, 0); ok {
+		t.Errorf("expected 103 to be normal, got %+v", result)
+	}
+
+	result, ok := DefaultDetector.Detect(200, history, 0)
+	if !ok || result.Direction != DirectionHigh || !approx(result.Score, 20) {
+		t.Errorf("expected a high anomaly with score 20, got %+v", result)
+	}
+
+	result, ok = DefaultDetector.Detect(10, history, 0)
+	if !ok || result.Direction != DirectionLow {
+		t.Errorf("expected a low anomaly, got %+v", result)
+	}
+
+	if _, ok := DefaultDetector.Detect(200, history[:11], 0); ok {
+		t.Error("expected no anomaly without enough history")
+	}
+}
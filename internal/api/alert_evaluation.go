@@ -0,0 +1,489 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+// alertNameLabel carries the rule name on every alert.
+const alertNameLabel = "alertname"
+
+// alertAggregation is an SQL aggregate over the rows of an alert query's
+// window. perSecond values are divided by the window length.
+type alertAggregation struct {
+	expr      string
+	perSecond bool
+}
+
+// alertSource describes a table alert rules can query.
+type alertSource struct {
+	table      string
+	timeColumn string
+	// columns maps the filter and group_by names of a query to columns.
+	columns      map[string]string
+	aggregations map[string]alertAggregation
+}
+
+func durationAggregations(column string) map[string]alertAggregation {
+	aggregations := map[string]alertAggregation{
+		"avg": {expr: "AVG(" + column + ")"},
+		"max": {expr: "MAX(" + column + ")"},
+	}
+	for _, p := range []int{50, 90, 95, 99} {
+		aggregations[fmt.Sprintf("p%d", p)] = alertAggregation{
+			expr: fmt.Sprintf("percentile_cont(0.%d) WITHIN GROUP (ORDER BY %s)", p, column),
+		}
+	}
+	return aggregations
+}
+
+var alertSources = map[string]alertSource{
+	models.AlertSourceMetrics: {
+		table:      "metrics",
+		timeColumn: "timestamp",
+		columns: map[string]string{
+			"service": "service_name",
+			"path":    "path",
+			"method":  "method",
+		},
+		aggregations: mergeAlertAggregations(durationAggregations("duration"), map[string]alertAggregation{
+			"count":      {expr: "COUNT(*)"},
+			"rate":       {expr: "COUNT(*)", perSecond: true},
+			"error_rate": {expr: "AVG(CASE WHEN status_code >= 500 THEN 1.0 ELSE 0.0 END)"},
+		}),
+	},
+	models.AlertSourceLogs: {
+		table:      "logs",
+		timeColumn: "timestamp",
+		columns: map[string]string{
+			"service": "service_name",
+			"level":   "log_level",
+			"message": "message",
+		},
+		aggregations: map[string]alertAggregation{
+			"count": {expr: "COUNT(*)"},
+			"rate":  {expr: "COUNT(*)", perSecond: true},
+		},
+	},
+	models.AlertSourceSpans: {
+		table:      "spans",
+		timeColumn: "end_time",
+		columns: map[string]string{
+			"service":   "service",
+			"operation": "operation",
+		},
+		aggregations: mergeAlertAggregations(durationAggregations("duration_ms"), map[string]alertAggregation{
+			"count":      {expr: "COUNT(*)"},
+			"rate":       {expr: "COUNT(*)", perSecond: true},
+			"error_rate": {expr: "AVG(CASE WHEN status = 'ERROR' THEN 1.0 ELSE 0.0 END)"},
+		}),
+	},
+	models.AlertSourceAnomalies: {
+		table:      "anomalies",
+		timeColumn: "detected_at",
+		columns: map[string]string{
+			"service": "service_name",
+			"signal":  "signal",
+		},
+		aggregations: map[string]alertAggregation{
+			"count":     {expr: "COUNT(*)"},
+			"max_score": {expr: "MAX(ABS(score))"},
+		},
+	},
+}
+
+func mergeAlertAggregations(sets ...map[string]alertAggregation) map[string]alertAggregation {
+	merged := map[string]alertAggregation{}
+	for _, set := range sets {
+		for name, aggregation := range set {
+			merged[name] = aggregation
+		}
+	}
+	return merged
+}
+
+// alertQueryFilters returns the query's filters keyed by name, including
+// empty ones.
+func alertQueryFilters(q models.AlertQuery) map[string]string {
+	return map[string]string{
+		"service":   q.Service,
+		"path":      q.Path,
+		"method":    q.Method,
+		"level":     q.Level,
+		"message":   q.Message,
+		"operation": q.Operation,
+		"signal":    q.Signal,
+	}
+}
+
+// buildAlertQuery renders a validated query for the window ending at now. It
+// selects the group_by values as text followed by the aggregated value. With
+// includeSilent, a count or rate query also returns the value 0 for groups
+// that had rows in the window before but none in this one.
+func buildAlertQuery(q models.AlertQuery, now time.Time, includeSilent bool) (string, []any) {
+	source := alertSources[q.Source]
+	aggregation := source.aggregations[q.Aggregation]
+	window := time.Duration(q.Window)
+
+	var selects, groups []string
+	for _, label := range q.GroupBy {
+		column := source.columns[label]
+		selects = append(selects, column+"::text")
+		groups = append(groups, column)
+	}
+
+	value := aggregation.expr
+	from := "$1"
+	params := []any{now.Add(-window), now}
+	paramCount := 3
+	if includeSilent {
+		value += fmt.Sprintf(" FILTER (WHERE %s > $1)", source.timeColumn)
+		from = "$3"
+		params = append(params, now.Add(-2*window))
+		paramCount++
+	}
+
+	value += "::float8"
+	if aggregation.perSecond {
+		value = fmt.Sprintf("%s / %g", value, window.Seconds())
+	}
+	selects = append(selects, value)
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s > %s AND %s <= $2",
+		strings.Join(selects, ", "), source.table, source.timeColumn, from, source.timeColumn)
+
+	filters := alertQueryFilters(q)
+	names := make([]string, 0, len(filters))
+	for name, value := range filters {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "message" {
+			query += fmt.Sprintf(" AND %s ILIKE $%d", source.columns[name], paramCount)
+			params = append(params, "%"+filters[name]+"%")
+		} else {
+			query += fmt.Sprintf(" AND %s = $%d", source.columns[name], paramCount)
+			params = append(params, filters[name])
+		}
+		paramCount++
+	}
+
+	if len(groups) > 0 {
+		query += " GROUP BY " + strings.Join(groups, ", ")
+	}
+
+	return query, params
+}
+
+// alertSample is the value of a rule's query for one group.
+type alertSample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// queryAlertSamples runs the rule's query. Groups whose aggregate is NULL,
+// such as an average over no rows, have no sample. When a group going silent
+// breaches the rule, as with "count < 1", groups seen in the previous window
+// are included with the value 0.
+func queryAlertSamples(ctx context.Context, rule models.AlertRule, now time.Time) ([]alertSample, error) {
+	q := rule.Query
+	includeSilent := len(q.GroupBy) > 0 && countsMissingAsZero(q) &&
+		alerting.Breached(rule.Comparison, 0, rule.Threshold)
+	query, params := buildAlertQuery(q, now, includeSilent)
+
+	rows, err := db.DB.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []alertSample
+	for rows.Next() {
+		groupValues := make([]sql.NullString, len(q.GroupBy))
+		var value sql.NullFloat64
+
+		dest := make([]any, 0, len(groupValues)+1)
+		for i := range groupValues {
+			dest = append(dest, &groupValues[i])
+		}
+		dest = append(dest, &value)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if !value.Valid {
+			continue
+		}
+
+		labels := make(map[string]string, len(q.GroupBy))
+		for i, label := range q.GroupBy {
+			labels[label] = groupValues[i].String
+		}
+		samples = append(samples, alertSample{Labels: labels, Value: value.Float64})
+	}
+
+	return samples, rows.Err()
+}
+
+// alertLabels combines the rule's labels with its name, the service it is
+// filtered to and the sample's group labels.
+func alertLabels(rule models.AlertRule, group map[string]string) map[string]string {
+	labels := make(map[string]string, len(rule.Labels)+len(group)+2)
+	for key, value := range rule.Labels {
+		labels[key] = value
+	}
+	if rule.Query.Service != "" {
+		labels["service"] = rule.Query.Service
+	}
+	for key, value := range group {
+		labels[key] = value
+	}
+	labels[alertNameLabel] = rule.Name
+	return labels
+}
+
+// alertUpdate is an alert after an evaluation together with the state it
+// was in before.
+type alertUpdate struct {
+	Alert     models.Alert
+	FromState string
+}
+
+// Changed reports whether the evaluation moved the alert to another state.
+func (u alertUpdate) Changed() bool {
+	return u.FromState != u.Alert.State
+}
+
+// countsMissingAsZero reports whether a group missing from the query result
+// has the value 0, as for counts and rates of rows it has none of.
+func countsMissingAsZero(q models.AlertQuery) bool {
+	return q.Aggregation == "count" || q.Aggregation == "rate"
+}
+
+// evaluateAlerts advances the rule's current alerts with the samples of an
+// evaluation at now. For counts and rates, alerts without a sample are
+// evaluated with the value 0, so that rules such as "count < 1" catch a
+// group going silent. Otherwise active alerts without a sample are treated
+// as no longer breached. Alerts without a sample that stay clear and are not
+// active are left untouched and not returned.
+func evaluateAlerts(rule models.AlertRule, current []models.Alert, samples []alertSample, now time.Time) []alertUpdate {
+	byFingerprint := make(map[string]models.Alert, len(current))
+	for _, alert := range current {
+		byFingerprint[alert.Fingerprint] = alert
+	}
+
+	var updates []alertUpdate
+	seen := map[string]bool{}
+
+	advance := func(alert models.Alert, breached bool) {
+		from := alert.State
+		var activeSince time.Time
+		if alert.ActiveSince != nil {
+			activeSince = *alert.ActiveSince
+		}
+
+		state, since := alerting.Next(from, activeSince, breached, now, time.Duration(rule.For))
+		alert.State = state
+		alert.LastEvaluatedAt = now
+		alert.ActiveSince = nil
+		if !since.IsZero() {
+			alert.ActiveSince = &since
+		}
+
+		if state != from {
+			switch state {
+			case models.AlertStatePending:
+				alert.FiredAt, alert.ResolvedAt = nil, nil
+			case models.AlertStateFiring:
+				firedAt := now
+				alert.FiredAt, alert.ResolvedAt = &firedAt, nil
+			case models.AlertStateResolved:
+				resolvedAt := now
+				alert.ResolvedAt = &resolvedAt
+			}
+		}
+
+		updates = append(updates, alertUpdate{Alert: alert, FromState: from})
+	}
+
+	for _, sample := range samples {
+		labels := alertLabels(rule, sample.Labels)
+		fingerprint := alerting.Fingerprint(labels)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+
+		alert, ok := byFingerprint[fingerprint]
+		if !ok {
+			alert = models.Alert{
+				RuleID:      rule.ID,
+				RuleName:    rule.Name,
+				Fingerprint: fingerprint,
+				State:       models.AlertStateInactive,
+			}
+		}
+		alert.Labels = labels
+		alert.Value = sample.Value
+		advance(alert, alerting.Breached(rule.Comparison, sample.Value, rule.Threshold))
+	}
+
+	for _, alert := range current {
+		if seen[alert.Fingerprint] {
+			continue
+		}
+
+		breached := false
+		if countsMissingAsZero(rule.Query) {
+			alert.Value = 0
+			breached = alerting.Breached(rule.Comparison, 0, rule.Threshold)
+		}
+		if breached || alert.State == models.AlertStatePending || alert.State == models.AlertStateFiring {
+			advance(alert, breached)
+		}
+	}
+
+	return updates
+}
+
+// EvaluateAlertRule runs one evaluation of the rule at now and stores the
+// resulting alert states and transitions.
+func EvaluateAlertRule(ctx context.Context, rule models.AlertRule, now time.Time) ([]alertUpdate, error) {
+	samples, err := queryAlertSamples(ctx, rule, now)
+	if err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+
+	current, err := GetAlerts(AlertFilter{RuleID: rule.ID})
+	if err != nil {
+		return nil, fmt.Errorf("loading alerts: %w", err)
+	}
+
+	updates := evaluateAlerts(rule, current, samples, now)
+	if err := storeAlertUpdates(ctx, rule.ID, updates, now); err != nil {
+		return nil, fmt.Errorf("storing alerts: %w", err)
+	}
+
+	return updates, nil
+}
+
+func storeAlertUpdates(ctx context.Context, ruleID int, updates []alertUpdate, now time.Time) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, update := range updates {
+		alert := update.Alert
+		labels, err := json.Marshal(alert.Labels)
+		if err != nil {
+			return err
+		}
+
+		if alert.State == models.AlertStateInactive {
+			_, err = tx.ExecContext(ctx, "DELETE FROM alerts WHERE rule_id = $1 AND fingerprint = $2",
+				ruleID, alert.Fingerprint)
+		} else {
+			_, err = tx.ExecContext(ctx, `INSERT INTO alerts
+                    (rule_id, fingerprint, labels, state, value, active_since, fired_at, resolved_at, last_evaluated_at)
+                  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+                  ON CONFLICT (rule_id, fingerprint) DO UPDATE SET
+                    labels = EXCLUDED.labels,
+                    state = EXCLUDED.state,
+                    value = EXCLUDED.value,
+                    active_since = EXCLUDED.active_since,
+                    fired_at = EXCLUDED.fired_at,
+                    resolved_at = EXCLUDED.resolved_at,
+                    last_evaluated_at = EXCLUDED.last_evaluated_at`,
+				ruleID, alert.Fingerprint, labels, alert.State, alert.Value,
+				alert.ActiveSince, alert.FiredAt, alert.ResolvedAt, alert.LastEvaluatedAt)
+		}
+		if err != nil {
+			return err
+		}
+
+		if update.Changed() {
+			_, err = tx.ExecContext(ctx, `INSERT INTO alert_history
+                    (rule_id, fingerprint, labels, from_state, to_state, value, created_at)
+                  VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				ruleID, alert.Fingerprint, labels, update.FromState, alert.State, alert.Value, now)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE alert_rules SET last_evaluated_at = $1 WHERE id = $2", now, ruleID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dueAlertRules returns the enabled rules whose interval has elapsed since
+// their last evaluation.
+func dueAlertRules(ctx context.Context, now time.Time) ([]models.AlertRule, error) {
+	query := "SELECT " + alertRuleColumns + ` FROM alert_rules
+              WHERE enabled AND (last_evaluated_at IS NULL
+                OR last_evaluated_at + interval_seconds * INTERVAL '1 second' <= $1)
+              ORDER BY id`
+
+	rows, err := db.DB.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAlertRules(rows)
+}
+
+// AlertRulesJob evaluates the rules that are due. interval is how often due
+// rules are looked for and bounds how precisely rule intervals are kept.
+func AlertRulesJob(interval time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "alert-rules",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			rules, err := dueAlertRules(ctx, now)
+			if err != nil {
+				return err
+			}
+
+			var errs []error
+			for _, rule := range rules {
+				updates, err := EvaluateAlertRule(ctx, rule, now)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("rule %d (%s): %w", rule.ID, rule.Name, err))
+					continue
+				}
+				for _, update := range updates {
+					if update.Changed() {
+						log.Printf("🔔 Alert %s %s -> %s (value %g)",
+							rule.Name, update.FromState, update.Alert.State, update.Alert.Value)
+					}
+				}
+			}
+
+			return errors.Join(errs...)
+		},
+	}
+}
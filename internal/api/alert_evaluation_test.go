@@ -0,0 +1,117 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestEvaluateAlertsLifecycle(t *testing.T) {
+	rule := models.AlertRule{
+		ID:         1,
+		Name:       "SlowPaths",
+		Comparison: ">",
+		Threshold:  500,
+		For:        models.Duration(time.Minute),
+		Labels:     map[string]string{"severity": "warning"},
+		Query:      models.AlertQuery{Service: "api", GroupBy: []string{"path"}},
+	}
+	start := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+
+	var current []models.Alert
+	step := func(offset time.Duration, samples ...alertSample) []alertUpdate {
+		updates := evaluateAlerts(rule, current, samples, start.Add(offset))
+		stored := map[string]models.Alert{}
+		for _, alert := range current {
+			stored[alert.Fingerprint] = alert
+		}
+		for _, update := range updates {
+			stored[update.Alert.Fingerprint] = update.Alert
+		}
+		current = nil
+		for _, alert := range stored {
+			if alert.State != models.AlertStateInactive {
+				current = append(current, alert)
+			}
+		}
+		return updates
+	}
+	sample := func(path string, value float64) alertSample {
+		return alertSample{Labels: map[string]string{"path": path}, Value: value}
+	}
+
+	updates := step(0, sample("/users", 800), sample("/health", 5))
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %+v", updates)
+	}
+	users := updates[0].Alert
+	if users.State != models.AlertStatePending || !updates[0].Changed() {
+		t.Fatalf("expected /users to be pending, got %+v", updates[0])
+	}
+	want := map[string]string{"alertname": "SlowPaths", "severity": "warning", "service": "api", "path": "/users"}
+	for key, value := range want {
+		if users.Labels[key] != value {
+			t.Errorf("label %s: expected %q, got %q", key, value, users.Labels[key])
+		}
+	}
+	if updates[1].Alert.State != models.AlertStateInactive || updates[1].Changed() {
+		t.Errorf("expected /health to stay inactive, got %+v", updates[1])
+	}
+
+	updates = step(time.Minute, sample("/users", 900))
+	if updates[0].Alert.State != models.AlertStateFiring || updates[0].Alert.FiredAt == nil {
+		t.Fatalf("expected /users to fire, got %+v", updates[0].Alert)
+	}
+	if !updates[0].Alert.ActiveSince.Equal(start) {
+		t.Errorf("expected active since %v, got %v", start, updates[0].Alert.ActiveSince)
+	}
+
+	// The group disappearing from the query result resolves the alert.
+	updates = step(2 * time.Minute)
+	if len(updates) != 1 || updates[0].Alert.State != models.AlertStateResolved || updates[0].Alert.ResolvedAt == nil {
+		t.Fatalf("expected /users to resolve, got %+v", updates)
+	}
+
+	if updates = step(3 * time.Minute); len(updates) != 0 {
+		t.Errorf("expected resolved alert without samples to be left alone, got %+v", updates)
+	}
+
+	updates = step(4*time.Minute, sample("/users", 700))
+	if updates[0].FromState != models.AlertStateResolved || updates[0].Alert.State != models.AlertStatePending ||
+		updates[0].Alert.ResolvedAt != nil {
+		t.Errorf("expected resolved alert to become pending again, got %+v", updates[0])
+	}
+}
+
+func TestEvaluateAlertsMissingCountGroups(t *testing.T) {
+	rule := models.AlertRule{
+		ID:         2,
+		Name:       "NoTraffic",
+		Comparison: "<",
+		Threshold:  1,
+		Query:      models.AlertQuery{Aggregation: "count", GroupBy: []string{"service"}},
+	}
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	tracked := models.Alert{
+		RuleID:      2,
+		Fingerprint: alerting.Fingerprint(alertLabels(rule, map[string]string{"service": "api"})),
+		State:       models.AlertStateResolved,
+		Value:       3,
+	}
+
+	// A tracked group missing from the result had no rows, which breaches.
+	updates := evaluateAlerts(rule, []models.Alert{tracked}, nil, now)
+	if len(updates) != 1 || updates[0].Alert.State != models.AlertStateFiring || updates[0].Alert.Value != 0 {
+		t.Fatalf("expected the silent group to fire, got %+v", updates)
+	}
+
+	// Averages of no rows have no value, so missing groups still clear.
+	rule.Query.Aggregation = "avg"
+	tracked.State = models.AlertStateFiring
+	updates = evaluateAlerts(rule, []models.Alert{tracked}, nil, now)
+	if len(updates) != 1 || updates[0].Alert.State != models.AlertStateResolved {
+		t.Errorf("expected the missing group to resolve, got %+v", updates)
+	}
+}
@@ -0,0 +1,416 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultAlertRuleInterval = time.Minute
+	minAlertRuleInterval     = 10 * time.Second
+	maxAlertQueryWindow      = 7 * 24 * time.Hour
+)
+
+const alertRuleColumns = `id, name, description, query, comparison, threshold, interval_seconds, for_seconds,
+	labels, array_to_json(channel_ids), enabled, created_at, updated_at, last_evaluated_at`
+
+// validateAlertRule fills in defaults and checks that the rule can be
+// evaluated.
+func validateAlertRule(rule *models.AlertRule) error {
+	if rule.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if !alerting.ValidComparison(rule.Comparison) {
+		return fmt.Errorf("invalid comparison: %q", rule.Comparison)
+	}
+
+	if rule.Interval == 0 {
+		rule.Interval = models.Duration(defaultAlertRuleInterval)
+	}
+	if time.Duration(rule.Interval) < minAlertRuleInterval {
+		return fmt.Errorf("interval must be at least %s", minAlertRuleInterval)
+	}
+	if rule.For < 0 {
+		return errors.New("for cannot be negative")
+	}
+
+	if rule.Labels == nil {
+		rule.Labels = map[string]string{}
+	}
+	if _, ok := rule.Labels[alertNameLabel]; ok {
+		return fmt.Errorf("label %s is reserved", alertNameLabel)
+	}
+
+	if rule.ChannelIDs == nil {
+		rule.ChannelIDs = []int{}
+	}
+	seen := map[int]bool{}
+	for _, id := range rule.ChannelIDs {
+		if id <= 0 || seen[id] {
+			return fmt.Errorf("invalid channel_ids: %v", rule.ChannelIDs)
+		}
+		seen[id] = true
+	}
+
+	return validateAlertQuery(rule.Query)
+}
+
+func validateAlertQuery(q models.AlertQuery) error {
+	source, ok := alertSources[q.Source]
+	if !ok {
+		return fmt.Errorf("invalid query source: %q", q.Source)
+	}
+
+	if _, ok := source.aggregations[q.Aggregation]; !ok {
+		return fmt.Errorf("invalid aggregation %q for source %s", q.Aggregation, q.Source)
+	}
+
+	window := time.Duration(q.Window)
+	if window < time.Second || window > maxAlertQueryWindow {
+		return fmt.Errorf("query window must be between 1s and %s", maxAlertQueryWindow)
+	}
+
+	for field, value := range alertQueryFilters(q) {
+		if _, ok := source.columns[field]; !ok && value != "" {
+			return fmt.Errorf("filter %s is not supported for source %s", field, q.Source)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, label := range q.GroupBy {
+		if _, ok := source.columns[label]; !ok || label == "message" {
+			return fmt.Errorf("cannot group %s by %s", q.Source, label)
+		}
+		if seen[label] {
+			return fmt.Errorf("duplicate group_by label: %s", label)
+		}
+		seen[label] = true
+	}
+
+	return nil
+}
+
+func GetAlertRules() ([]models.AlertRule, error) {
+	query := "SELECT " + alertRuleColumns + " FROM alert_rules ORDER BY id"
+
+	rows, err := db.DB.QueryContext(context.Background(), query)
+	if err != nil {
+		log.Println("❌ Error fetching alert rules:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAlertRules(rows)
+}
+
+// GetAlertRule returns a single rule, or sql.ErrNoRows.
+func GetAlertRule(id int) (models.AlertRule, error) {
+	query := "SELECT " + alertRuleColumns + " FROM alert_rules WHERE id = $1"
+	return scanAlertRule(db.DB.QueryRowContext(context.Background(), query, id))
+}
+
+// CreateAlertRule stores a validated rule, setting its ID and timestamps.
+func CreateAlertRule(rule *models.AlertRule) error {
+	params, err := alertRuleParams(*rule)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO alert_rules
+                (name, description, query, comparison, threshold, interval_seconds, for_seconds, labels,
+                 channel_ids, enabled)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+              RETURNING ` + alertRuleColumns
+
+	created, err := scanAlertRule(db.DB.QueryRowContext(context.Background(), query, params...))
+	if err != nil {
+		return err
+	}
+	*rule = created
+	return nil
+}
+
+// UpdateAlertRule replaces a validated rule, returning sql.ErrNoRows when it
+// does not exist. The rule is evaluated again on the next scheduler run.
+func UpdateAlertRule(rule *models.AlertRule) error {
+	params, err := alertRuleParams(*rule)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE alert_rules SET
+                name = $1, description = $2, query = $3, comparison = $4, threshold = $5,
+                interval_seconds = $6, for_seconds = $7, labels = $8, channel_ids = $9, enabled = $10,
+                updated_at = NOW(), last_evaluated_at = NULL
+              WHERE id = $11
+              RETURNING ` + alertRuleColumns
+
+	params = append(params, rule.ID)
+	updated, err := scanAlertRule(db.DB.QueryRowContext(context.Background(), query, params...))
+	if err != nil {
+		return err
+	}
+	*rule = updated
+	return nil
+}
+
+// DeleteAlertRule removes a rule with its alerts and history, returning
+// sql.ErrNoRows when it does not exist.
+func DeleteAlertRule(id int) error {
+	result, err := db.DB.ExecContext(context.Background(), "DELETE FROM alert_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func alertRuleParams(rule models.AlertRule) ([]any, error) {
+	query, err := json.Marshal(rule.Query)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := json.Marshal(rule.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		rule.Name,
+		rule.Description,
+		query,
+		rule.Comparison,
+		rule.Threshold,
+		int(time.Duration(rule.Interval) / time.Second),
+		int(time.Duration(rule.For) / time.Second),
+		labels,
+		rule.ChannelIDs,
+		rule.Enabled,
+	}, nil
+}
+
+func scanAlertRules(rows *sql.Rows) ([]models.AlertRule, error) {
+	rules := make([]models.AlertRule, 0)
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			log.Println("❌ Error scanning alert rule row:", err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func scanAlertRule(row rowScanner) (models.AlertRule, error) {
+	var rule models.AlertRule
+	var query, labels, channelIDs []byte
+	var intervalSeconds, forSeconds int
+	var lastEvaluatedAt sql.NullTime
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Description,
+		&query,
+		&rule.Comparison,
+		&rule.Threshold,
+		&intervalSeconds,
+		&forSeconds,
+		&labels,
+		&channelIDs,
+		&rule.Enabled,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+		&lastEvaluatedAt,
+	)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+
+	if err := json.Unmarshal(query, &rule.Query); err != nil {
+		return models.AlertRule{}, err
+	}
+	if err := json.Unmarshal(labels, &rule.Labels); err != nil {
+		return models.AlertRule{}, err
+	}
+	if err := json.Unmarshal(channelIDs, &rule.ChannelIDs); err != nil {
+		return models.AlertRule{}, err
+	}
+	rule.Interval = models.Duration(time.Duration(intervalSeconds) * time.Second)
+	rule.For = models.Duration(time.Duration(forSeconds) * time.Second)
+	if lastEvaluatedAt.Valid {
+		rule.LastEvaluatedAt = &lastEvaluatedAt.Time
+	}
+
+	return rule, nil
+}
+
+// decodeAlertRule reads a rule from the request body. Rules are enabled
+// unless the body says otherwise.
+func decodeAlertRule(r *http.Request) (models.AlertRule, error) {
+	rule := models.AlertRule{Enabled: true}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rule); err != nil {
+		return rule, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return rule, validateAlertRule(&rule)
+}
+
+// checkAlertRuleChannels rejects rules referring to unknown notification
+// channels, writing the error response and returning false.
+func checkAlertRuleChannels(w http.ResponseWriter, rule models.AlertRule) bool {
+	missing, err := missingNotificationChannels(rule.ChannelIDs)
+	if err != nil {
+		log.Printf("❌ Error checking notification channels: %v", err)
+		http.Error(w, "Failed to check notification channels", http.StatusInternalServerError)
+		return false
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("unknown notification channels: %v", missing), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// parseIDParam reads a numeric ID from the route variable name.
+func parseIDParam(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s", name, mux.Vars(r)[name])
+	}
+	return id, nil
+}
+
+func GetAlertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := GetAlertRules()
+	if err != nil {
+		http.Error(w, "Failed to fetch alert rules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+func CreateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeAlertRule(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !checkAlertRuleChannels(w, rule) {
+		return
+	}
+
+	if err := CreateAlertRule(&rule); err != nil {
+		log.Printf("❌ Error creating alert rule: %v", err)
+		http.Error(w, "Failed to create alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func GetAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := GetAlertRule(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Alert rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching alert rule: %v", err)
+		http.Error(w, "Failed to fetch alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func UpdateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := decodeAlertRule(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !checkAlertRuleChannels(w, rule) {
+		return
+	}
+	rule.ID = id
+
+	err = UpdateAlertRule(&rule)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Alert rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error updating alert rule: %v", err)
+		http.Error(w, "Failed to update alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func DeleteAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = DeleteAlertRule(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Alert rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error deleting alert rule: %v", err)
+		http.Error(w, "Failed to delete alert rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func validAlertRule() models.AlertRule {
+	return models.AlertRule{
+		Name:       "HighErrorRate",
+		Comparison: ">",
+		Threshold:  0.05,
+		Query: models.AlertQuery{
+			Source:      models.AlertSourceMetrics,
+			Aggregation: "error_rate",
+			Window:      models.Duration(5 * time.Minute),
+			Service:     "api",
+		},
+	}
+}
+
+func TestValidateAlertRuleDefaults(t *testing.T) {
+	rule := validAlertRule()
+	if err := validateAlertRule(&rule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Duration(rule.Interval) != time.Minute || rule.Labels == nil || rule.ChannelIDs == nil {
+		t.Errorf("expected defaults to be filled in, got %+v", rule)
+	}
+}
+
+func TestValidateAlertRuleErrors(t *testing.T) {
+	cases := map[string]func(*models.AlertRule){
+		"missing name":       func(r *models.AlertRule) { r.Name = "" },
+		"bad comparison":     func(r *models.AlertRule) { r.Comparison = "=>" },
+		"short interval":     func(r *models.AlertRule) { r.Interval = models.Duration(time.Second) },
+		"negative for":       func(r *models.AlertRule) { r.For = models.Duration(-time.Minute) },
+		"reserved label":     func(r *models.AlertRule) { r.Labels = map[string]string{alertNameLabel: "x"} },
+		"bad source":         func(r *models.AlertRule) { r.Query.Source = "events" },
+		"bad aggregation":    func(r *models.AlertRule) { r.Query.Aggregation = "p95"; r.Query.Source = models.AlertSourceLogs },
+		"missing window":     func(r *models.AlertRule) { r.Query.Window = 0 },
+		"unsupported filter": func(r *models.AlertRule) { r.Query.Level = "ERROR" },
+		"unsupported group":  func(r *models.AlertRule) { r.Query.GroupBy = []string{"operation"} },
+		"duplicate group":    func(r *models.AlertRule) { r.Query.GroupBy = []string{"path", "path"} },
+		"invalid channel":    func(r *models.AlertRule) { r.ChannelIDs = []int{0} },
+		"duplicate channel":  func(r *models.AlertRule) { r.ChannelIDs = []int{2, 2} },
+		"group by message": func(r *models.AlertRule) {
+			r.Query.Source = models.AlertSourceLogs
+			r.Query.Aggregation = "count"
+			r.Query.GroupBy = []string{"message"}
+		},
+	}
+
+	for name, mutate := range cases {
+		rule := validAlertRule()
+		mutate(&rule)
+		if err := validateAlertRule(&rule); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDecodeAlertRuleParsesDurations(t *testing.T) {
+	body := `{"name": "FatalLogs", "comparison": ">=", "threshold": 1, "for": "2m",
+		"query": {"source": "logs", "aggregation": "count", "window": "10m", "level": "FATAL"}}`
+	req := httptest.NewRequest(http.MethodPost, "/alert-rules", strings.NewReader(body))
+
+	rule, err := decodeAlertRule(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !rule.Enabled || time.Duration(rule.For) != 2*time.Minute || time.Duration(rule.Query.Window) != 10*time.Minute {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/alert-rules", strings.NewReader(`{"name": "x", "for": 120}`))
+	if _, err := decodeAlertRule(req); err == nil {
+		t.Error("expected numeric duration to be rejected")
+	}
+}
+
+func TestBuildAlertQuery(t *testing.T) {
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	q := models.AlertQuery{
+		Source:      models.AlertSourceLogs,
+		Aggregation: "rate",
+		Window:      models.Duration(5 * time.Minute),
+		Service:     "api",
+		Message:     "timeout",
+		GroupBy:     []string{"level"},
+	}
+
+	query, params := buildAlertQuery(q, now, false)
+
+	for _, want := range []string{
+		"SELECT log_level::text, COUNT(*)::float8 / 300 FROM logs",
+		"timestamp > $1 AND timestamp <= $2",
+		"message ILIKE $3",
+		"service_name = $4",
+		"GROUP BY log_level",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	if len(params) != 4 || params[2] != "%timeout%" || !params[0].(time.Time).Equal(now.Add(-5*time.Minute)) {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestBuildAlertQueryIncludesSilentGroups(t *testing.T) {
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	q := models.AlertQuery{
+		Source:      models.AlertSourceLogs,
+		Aggregation: "count",
+		Window:      models.Duration(5 * time.Minute),
+		Service:     "api",
+		GroupBy:     []string{"level"},
+	}
+
+	query, params := buildAlertQuery(q, now, true)
+	for _, want := range []string{
+		"COUNT(*) FILTER (WHERE timestamp > $1)::float8",
+		"timestamp > $3 AND timestamp <= $2",
+		"service_name = $4",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	if len(params) != 4 || !params[2].(time.Time).Equal(now.Add(-10*time.Minute)) {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestBuildAlertQueryPercentile(t *testing.T) {
+	q := models.AlertQuery{
+		Source:      models.AlertSourceSpans,
+		Aggregation: "p95",
+		Window:      models.Duration(time.Minute),
+		Operation:   "GET /users",
+	}
+
+	query, _ := buildAlertQuery(q, time.Now(), false)
+	for _, want := range []string{"percentile_cont(0.95) WITHIN GROUP (ORDER BY duration_ms)", "FROM spans", "end_time > $1", "operation = $3"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	if strings.Contains(query, "GROUP BY") {
+		t.Errorf("unexpected GROUP BY in %s", query)
+	}
+}
+
+func TestBuildAlertQueryAnomalies(t *testing.T) {
+	q := models.AlertQuery{
+		Source:      models.AlertSourceAnomalies,
+		Aggregation: "count",
+		Window:      models.Duration(15 * time.Minute),
+		Signal:      "error_rate",
+		GroupBy:     []string{"service"},
+	}
+	if err := validateAlertQuery(q); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	query, params := buildAlertQuery(q, time.Now(), false)
+	for _, want := range []string{"FROM anomalies", "detected_at > $1", "signal = $3", "GROUP BY service_name"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	if len(params) != 3 || params[2] != "error_rate" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	q.Path = "/users"
+	if err := validateAlertQuery(q); err == nil {
+		t.Error("expected the path filter to be rejected for anomalies")
+	}
+}
+
+func TestParseAlertFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/alerts?state=firing&rule_id=3", nil)
+	filter, err := parseAlertFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clause, params, next := filter.whereClause(1)
+	if !strings.Contains(clause, "a.rule_id = $1") || !strings.Contains(clause, "a.state = $2") || len(params) != 2 || next != 3 {
+		t.Errorf("unexpected clause %q with %v", clause, params)
+	}
+
+	for _, rawQuery := range []string{"state=inactive", "rule_id=abc", "since=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/alerts?"+rawQuery, nil)
+		if _, err := parseAlertFilter(req); err == nil {
+			t.Errorf("expected error for %q", rawQuery)
+		}
+	}
+}
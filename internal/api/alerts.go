@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+var validAlertStates = map[string]bool{
+	models.AlertStatePending:  true,
+	models.AlertStateFiring:   true,
+	models.AlertStateResolved: true,
+}
+
+// AlertFilter holds the optional filters for listing alerts and their
+// history.
+type AlertFilter struct {
+	RuleID      int
+	State       string
+	Fingerprint string
+	Since       time.Time
+}
+
+// Conditions use the table alias a; State applies to alerts, Since to history.
+func (f AlertFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
+	var params []any
+
+	if f.RuleID != 0 {
+		clause += fmt.Sprintf(" AND a.rule_id = $%d", paramCount)
+		params = append(params, f.RuleID)
+		paramCount++
+	}
+
+	if f.Fingerprint != "" {
+		clause += fmt.Sprintf(" AND a.fingerprint = $%d", paramCount)
+		params = append(params, f.Fingerprint)
+		paramCount++
+	}
+
+	if f.State != "" {
+		clause += fmt.Sprintf(" AND a.state = $%d", paramCount)
+		params = append(params, f.State)
+		paramCount++
+	}
+
+	if !f.Since.IsZero() {
+		clause += fmt.Sprintf(" AND a.created_at >= $%d", paramCount)
+		params = append(params, f.Since)
+		paramCount++
+	}
+
+	return clause, params, paramCount
+}
+
+func parseAlertFilter(r *http.Request) (AlertFilter, error) {
+	q := r.URL.Query()
+	filter := AlertFilter{
+		State:       q.Get("state"),
+		Fingerprint: q.Get("fingerprint"),
+	}
+
+	if filter.State != "" && !validAlertStates[filter.State] {
+		return filter, fmt.Errorf("invalid state: %s", filter.State)
+	}
+
+	if raw := q.Get("rule_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("invalid rule_id: %s", raw)
+		}
+		filter.RuleID = id
+	}
+
+	if raw := q.Get("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since: %s", raw)
+		}
+		filter.Since = parsed
+	}
+
+	return filter, nil
+}
+
+// alertColumns selects alerts with the alias a joined to their rule as r.
+const alertColumns = `a.id, a.rule_id, r.name, a.fingerprint, a.labels, a.state, a.value,
+	a.active_since, a.fired_at, a.resolved_at, a.last_evaluated_at`
+
+// scanAlert reads a row of alertColumns followed by any extra columns.
+func scanAlert(row rowScanner, extra ...any) (models.Alert, error) {
+	var alert models.Alert
+	var labels []byte
+	var activeSince, firedAt, resolvedAt sql.NullTime
+
+	dest := []any{
+		&alert.ID,
+		&alert.RuleID,
+		&alert.RuleName,
+		&alert.Fingerprint,
+		&labels,
+		&alert.State,
+		&alert.Value,
+		&activeSince,
+		&firedAt,
+		&resolvedAt,
+		&alert.LastEvaluatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return models.Alert{}, err
+	}
+	if err := json.Unmarshal(labels, &alert.Labels); err != nil {
+		return models.Alert{}, err
+	}
+
+	alert.ActiveSince = nullTimePtr(activeSince)
+	alert.FiredAt = nullTimePtr(firedAt)
+	alert.ResolvedAt = nullTimePtr(resolvedAt)
+	return alert, nil
+}
+
+// GetAlerts lists the current alerts matching the filter, firing first.
+func GetAlerts(filter AlertFilter) ([]models.Alert, error) {
+	filter.Since = time.Time{}
+	query := "SELECT " + alertColumns + " FROM alerts a JOIN alert_rules r ON r.id = a.rule_id WHERE 1=1"
+
+	where, params, _ := filter.whereClause(1)
+	query += where
+	query += ` ORDER BY CASE a.state WHEN 'firing' THEN 0 WHEN 'pending' THEN 1 ELSE 2 END,
+                      a.active_since DESC, a.id`
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching alerts:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]models.Alert, 0)
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			log.Println("❌ Error scanning alert row:", err)
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// GetAlertHistory lists state transitions matching the filter, newest first.
+func GetAlertHistory(filter AlertFilter, limit, offset int) ([]models.AlertTransition, error) {
+	filter.State = ""
+	query := `SELECT a.id, a.rule_id, a.fingerprint, a.labels, a.from_state, a.to_state, a.value, a.created_at
+              FROM alert_history a WHERE 1=1`
+
+	where, params, paramCount := filter.whereClause(1)
+	query += where
+	query += fmt.Sprintf(" ORDER BY a.created_at DESC, a.id DESC LIMIT $%d OFFSET $%d", paramCount, paramCount+1)
+	params = append(params, limit, offset)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching alert history:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]models.AlertTransition, 0)
+	for rows.Next() {
+		var transition models.AlertTransition
+		var labels []byte
+
+		err := rows.Scan(
+			&transition.ID,
+			&transition.RuleID,
+			&transition.Fingerprint,
+			&labels,
+			&transition.FromState,
+			&transition.ToState,
+			&transition.Value,
+			&transition.CreatedAt,
+		)
+		if err == nil {
+			err = json.Unmarshal(labels, &transition.Labels)
+		}
+		if err != nil {
+			log.Println("❌ Error scanning alert history row:", err)
+			continue
+		}
+		history = append(history, transition)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// annotateSuppressedAlerts marks the firing alerts whose notifications are
+// muted by silences or inhibition rules.
+func annotateSuppressedAlerts(alerts []models.Alert, suppressor *alerting.Suppressor) {
+	for i := range alerts {
+		if alerts[i].State != models.AlertStateFiring {
+			continue
+		}
+		alerts[i].SilencedBy = suppressor.SilencedBy(alerts[i].Labels)
+		alerts[i].Inhibited = suppressor.Inhibited(alerts[i].Labels)
+	}
+}
+
+func GetAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAlertFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	alerts, err := GetAlerts(filter)
+	if err != nil {
+		http.Error(w, "Failed to fetch alerts", http.StatusInternalServerError)
+		return
+	}
+
+	suppressor, err := alertSuppressor(r.Context(), time.Now())
+	if err != nil {
+		log.Printf("❌ Error loading silences and inhibit rules: %v", err)
+		http.Error(w, "Failed to fetch alerts", http.StatusInternalServerError)
+		return
+	}
+	annotateSuppressedAlerts(alerts, suppressor)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+func GetAlertHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAlertFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, offset := parseLimitOffset(r)
+
+	history, err := GetAlertHistory(filter, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch alert history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
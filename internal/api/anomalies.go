@@ -0,0 +1,466 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/anomaly"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const (
+	// anomalyBucket is the length of the buckets signals are computed over.
+	anomalyBucket = 5 * time.Minute
+	// anomalyDelay is how long a bucket is given for late data after it
+	// ends before it is checked.
+	anomalyDelay = time.Minute
+	// anomalyWeeks is how many previous weeks make up a baseline.
+	anomalyWeeks = 4
+	// anomalyRecentBuckets is how many of the latest complete buckets each
+	// run checks, so that a late run does not skip one.
+	anomalyRecentBuckets = 2
+	// anomalyMinEvents is the number of events a bucket needs for its ratio
+	// and latency signals to count.
+	anomalyMinEvents = 10
+)
+
+// anomalySignal is a per-service value computed over each bucket of a
+// source.
+type anomalySignal struct {
+	name string
+	// expr aggregates the rows of a bucket. Without it the signal is the
+	// rate of rows per second, and buckets without rows count as 0.
+	expr string
+	// minSpread is the smallest baseline spread, in the signal's unit.
+	minSpread float64
+	// high and low select the directions that are anomalies.
+	high, low bool
+}
+
+// anomalySource is a table whose signals are checked for anomalies.
+type anomalySource struct {
+	table      string
+	timeColumn string
+	signals    []anomalySignal
+}
+
+var anomalySources = []anomalySource{
+	{
+		table:      "metrics",
+		timeColumn: "timestamp",
+		signals: []anomalySignal{
+			{name: models.AnomalySignalRequestRate, minSpread: 0.05, high: true, low: true},
+			{
+				name:      models.AnomalySignalErrorRate,
+				expr:      "AVG(CASE WHEN status_code >= 500 THEN 1.0 ELSE 0.0 END)",
+				minSpread: 0.02,
+				high:      true,
+			},
+			{
+				name:      models.AnomalySignalLatencyP95,
+				expr:      "percentile_cont(0.95) WITHIN GROUP (ORDER BY duration)",
+				minSpread: 10,
+				high:      true,
+			},
+		},
+	},
+	{
+		table:      "logs",
+		timeColumn: "timestamp",
+		signals: []anomalySignal{
+			{name: models.AnomalySignalLogVolume, minSpread: 0.1, high: true, low: true},
+		},
+	},
+}
+
+var validAnomalySignals = map[string]bool{
+	models.AnomalySignalRequestRate: true,
+	models.AnomalySignalErrorRate:   true,
+	models.AnomalySignalLatencyP95:  true,
+	models.AnomalySignalLogVolume:   true,
+}
+
+// timeRange is the half-open interval [start, end).
+type timeRange struct {
+	start, end time.Time
+}
+
+// anomalyWindows returns the ranges read to check the bucket starting at
+// bucket: the bucket itself, followed by the same hour of the week in each
+// of the previous weeks.
+func anomalyWindows(bucket time.Time) []timeRange {
+	windows := []timeRange{{start: bucket, end: bucket.Add(anomalyBucket)}}
+
+	hour := bucket.Truncate(time.Hour)
+	for week := 1; week <= anomalyWeeks; week++ {
+		start := hour.Add(-time.Duration(week) * 7 * 24 * time.Hour)
+		windows = append(windows, timeRange{start: start, end: start.Add(time.Hour)})
+	}
+
+	return windows
+}
+
+// buildAnomalyQuery renders the query selecting, per service and bucket of
+// the windows, the service, the bucket, its row count and the value of each
+// signal with an expression.
+func buildAnomalyQuery(source anomalySource, bucket time.Time) (string, []any) {
+	selects := []string{
+		"service_name",
+		fmt.Sprintf("date_bin($1 * INTERVAL '1 second', %s, TIMESTAMP '2000-01-01')", source.timeColumn),
+		"COUNT(*)",
+	}
+	for _, signal := range source.signals {
+		if signal.expr != "" {
+			selects = append(selects, signal.expr+"::float8")
+		}
+	}
+
+	params := []any{int(anomalyBucket / time.Second)}
+	var ranges []string
+	for _, window := range anomalyWindows(bucket) {
+		ranges = append(ranges, fmt.Sprintf("(%s >= $%d AND %s < $%d)",
+			source.timeColumn, len(params)+1, source.timeColumn, len(params)+2))
+		params = append(params, window.start, window.end)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s GROUP BY 1, 2",
+		strings.Join(selects, ", "), source.table, strings.Join(ranges, " OR "))
+
+	return query, params
+}
+
+// anomalyRow is one service's bucket as selected by buildAnomalyQuery.
+type anomalyRow struct {
+	Service string
+	Bucket  time.Time
+	Count   int64
+	// Values holds the signals with an expression, in order.
+	Values []sql.NullFloat64
+}
+
+func queryAnomalyRows(ctx context.Context, source anomalySource, bucket time.Time) ([]anomalyRow, error) {
+	query, params := buildAnomalyQuery(source, bucket)
+
+	rows, err := db.DB.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := 0
+	for _, signal := range source.signals {
+		if signal.expr != "" {
+			values++
+		}
+	}
+
+	var result []anomalyRow
+	for rows.Next() {
+		row := anomalyRow{Values: make([]sql.NullFloat64, values)}
+		dest := []any{&row.Service, &row.Bucket, &row.Count}
+		for i := range row.Values {
+			dest = append(dest, &row.Values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// detectAnomalies checks each service's signals in the bucket starting at
+// bucket against the history in rows. Rate signals count history buckets
+// without rows as 0, but only in weeks in which the service had rows during
+// that hour, so that new services are not compared with empty weeks.
+func detectAnomalies(source anomalySource, rows []anomalyRow, bucket time.Time, detector anomaly.Detector, now time.Time) []models.Anomaly {
+	type serviceRows struct {
+		current *anomalyRow
+		history []anomalyRow
+		weeks   map[int]int
+	}
+
+	hour := bucket.Truncate(time.Hour)
+	services := map[string]*serviceRows{}
+	for i, row := range rows {
+		s, ok := services[row.Service]
+		if !ok {
+			s = &serviceRows{weeks: map[int]int{}}
+			services[row.Service] = s
+		}
+		if row.Bucket.Equal(bucket) {
+			s.current = &rows[i]
+			continue
+		}
+		s.history = append(s.history, row)
+		s.weeks[int((hour.Sub(row.Bucket)+time.Hour)/(7*24*time.Hour))]++
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bucketsPerHour := int(time.Hour / anomalyBucket)
+	seconds := anomalyBucket.Seconds()
+
+	var anomalies []models.Anomaly
+	for _, name := range names {
+		s := services[name]
+		valueIndex := 0
+
+		for _, signal := range source.signals {
+			var value float64
+			var history []float64
+
+			if signal.expr == "" {
+				if s.current != nil {
+					value = float64(s.current.Count) / seconds
+				}
+				for _, row := range s.history {
+					history = append(history, float64(row.Count)/seconds)
+				}
+				for _, n := range s.weeks {
+					for range bucketsPerHour - n {
+						history = append(history, 0)
+					}
+				}
+			} else {
+				i := valueIndex
+				valueIndex++
+
+				current := s.current
+				if current == nil || current.Count < anomalyMinEvents || !current.Values[i].Valid {
+					continue
+				}
+				value = current.Values[i].Float64
+				for _, row := range s.history {
+					if row.Count >= anomalyMinEvents && row.Values[i].Valid {
+						history = append(history, row.Values[i].Float64)
+					}
+				}
+			}
+
+			result, ok := detector.Detect(value, history, signal.minSpread)
+			if !ok {
+				continue
+			}
+			if (result.Direction == anomaly.DirectionHigh && !signal.high) ||
+				(result.Direction == anomaly.DirectionLow && !signal.low) {
+				continue
+			}
+
+			anomalies = append(anomalies, models.Anomaly{
+				Service:    name,
+				Signal:     signal.name,
+				Bucket:     bucket,
+				Value:      result.Value,
+				Baseline:   result.Baseline.Median,
+				Spread:     result.Baseline.Spread,
+				Score:      result.Score,
+				Direction:  result.Direction,
+				Samples:    result.Baseline.Samples,
+				DetectedAt: now,
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// storeAnomalies inserts anomalies that were not detected before and returns
+// the new ones.
+func storeAnomalies(ctx context.Context, anomalies []models.Anomaly) ([]models.Anomaly, error) {
+	var stored []models.Anomaly
+	for _, a := range anomalies {
+		err := db.DB.QueryRowContext(ctx, `INSERT INTO anomalies
+                (service_name, signal, bucket, value, baseline, spread, score, direction, samples, detected_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+              ON CONFLICT (service_name, signal, bucket) DO NOTHING
+              RETURNING id`,
+			a.Service, a.Signal, a.Bucket, a.Value, a.Baseline, a.Spread, a.Score, a.Direction, a.Samples,
+			a.DetectedAt).Scan(&a.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return stored, err
+		}
+		stored = append(stored, a)
+	}
+	return stored, nil
+}
+
+// AnomaliesJob checks the latest complete buckets of every signal against
+// their seasonal baselines.
+func AnomaliesJob(interval time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "anomalies",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			latest := now.Add(-anomalyDelay).Truncate(anomalyBucket).Add(-anomalyBucket)
+
+			var errs []error
+			for i := range anomalyRecentBuckets {
+				bucket := latest.Add(-time.Duration(i) * anomalyBucket)
+				for _, source := range anomalySources {
+					rows, err := queryAnomalyRows(ctx, source, bucket)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("querying %s: %w", source.table, err))
+						continue
+					}
+
+					stored, err := storeAnomalies(ctx, detectAnomalies(source, rows, bucket, anomaly.DefaultDetector, now))
+					if err != nil {
+						errs = append(errs, fmt.Errorf("storing %s anomalies: %w", source.table, err))
+					}
+					for _, a := range stored {
+						log.Printf("📈 Anomaly %s %s %s at %s: %g vs baseline %g (score %.1f)",
+							a.Service, a.Signal, a.Direction, a.Bucket.Format(time.RFC3339), a.Value, a.Baseline, a.Score)
+					}
+				}
+			}
+
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// AnomalyFilter holds the optional filters for listing anomalies. The time
+// range applies to their buckets.
+type AnomalyFilter struct {
+	Service   string
+	Signal    string
+	Direction string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func (f AnomalyFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
+	var params []any
+
+	for _, condition := range []struct {
+		column string
+		value  string
+	}{
+		{"service_name", f.Service},
+		{"signal", f.Signal},
+		{"direction", f.Direction},
+	} {
+		if condition.value != "" {
+			clause += fmt.Sprintf(" AND %s = $%d", condition.column, paramCount)
+			params = append(params, condition.value)
+			paramCount++
+		}
+	}
+
+	if !f.StartTime.IsZero() {
+		clause += fmt.Sprintf(" AND bucket >= $%d", paramCount)
+		params = append(params, f.StartTime)
+		paramCount++
+	}
+
+	if !f.EndTime.IsZero() {
+		clause += fmt.Sprintf(" AND bucket < $%d", paramCount)
+		params = append(params, f.EndTime)
+		paramCount++
+	}
+
+	return clause, params, paramCount
+}
+
+func parseAnomalyFilter(r *http.Request) (AnomalyFilter, error) {
+	q := r.URL.Query()
+	filter := AnomalyFilter{
+		Service:   q.Get("service"),
+		Signal:    q.Get("signal"),
+		Direction: q.Get("direction"),
+	}
+
+	if filter.Signal != "" && !validAnomalySignals[filter.Signal] {
+		return filter, fmt.Errorf("invalid signal: %s", filter.Signal)
+	}
+	if filter.Direction != "" && filter.Direction != anomaly.DirectionHigh && filter.Direction != anomaly.DirectionLow {
+		return filter, fmt.Errorf("invalid direction: %s", filter.Direction)
+	}
+
+	for name, target := range map[string]*time.Time{"start_time": &filter.StartTime, "end_time": &filter.EndTime} {
+		if raw := q.Get(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s: %s", name, raw)
+			}
+			*target = parsed
+		}
+	}
+
+	return filter, nil
+}
+
+func GetAnomalies(filter AnomalyFilter, limit, offset int) ([]models.Anomaly, error) {
+	query := `SELECT id, service_name, signal, bucket, value, baseline, spread, score, direction, samples, detected_at
+              FROM anomalies WHERE 1=1`
+
+	where, params, paramCount := filter.whereClause(1)
+	query += where
+	query += fmt.Sprintf(" ORDER BY bucket DESC, id DESC LIMIT $%d OFFSET $%d", paramCount, paramCount+1)
+	params = append(params, limit, offset)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching anomalies:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := make([]models.Anomaly, 0)
+	for rows.Next() {
+		var a models.Anomaly
+		err := rows.Scan(&a.ID, &a.Service, &a.Signal, &a.Bucket, &a.Value, &a.Baseline, &a.Spread, &a.Score,
+			&a.Direction, &a.Samples, &a.DetectedAt)
+		if err != nil {
+			log.Println("❌ Error scanning anomaly row:", err)
+			continue
+		}
+		anomalies = append(anomalies, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+func GetAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAnomalyFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset := parseLimitOffset(r)
+
+	anomalies, err := GetAnomalies(filter, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch anomalies", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(anomalies)
+}
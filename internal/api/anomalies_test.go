@@ -0,0 +1,145 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/anomaly"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const week = 7 * 24 * time.Hour
+
+func TestBuildAnomalyQuery(t *testing.T) {
+	bucket := time.Date(2025, 6, 2, 14, 25, 0, 0, time.UTC)
+	query, params := buildAnomalyQuery(anomalySources[1], bucket)
+
+	want := "SELECT service_name, date_bin($1 * INTERVAL '1 second', timestamp, TIMESTAMP '2000-01-01'), COUNT(*) " +
+		"FROM logs WHERE (timestamp >= $2 AND timestamp < $3) OR (timestamp >= $4 AND timestamp < $5) " +
+		"OR (timestamp >= $6 AND timestamp < $7) OR (timestamp >= $8 AND timestamp < $9) " +
+		"OR (timestamp >= $10 AND timestamp < $11) GROUP BY 1, 2"
+	if query != want {
+		t.Errorf("unexpected query:\n%s", query)
+	}
+
+	hour := time.Date(2025, 6, 2, 14, 0, 0, 0, time.UTC)
+	if len(params) != 11 || params[0] != 300 || !params[1].(time.Time).Equal(bucket) ||
+		!params[3].(time.Time).Equal(hour.Add(-week)) || !params[10].(time.Time).Equal(hour.Add(-4*week+time.Hour)) {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	query, _ = buildAnomalyQuery(anomalySources[0], bucket)
+	if !strings.Contains(query, "COUNT(*), AVG(CASE WHEN status_code >= 500 THEN 1.0 ELSE 0.0 END)::float8, percentile_cont") {
+		t.Errorf("expected the metric signal expressions, got %s", query)
+	}
+}
+
+// anomalyHistory returns a row for every bucket of bucket's hour of the week
+// in each of the previous weeks.
+func anomalyHistory(service string, bucket time.Time, weeks int, count int64, values ...float64) []anomalyRow {
+	var rows []anomalyRow
+	hour := bucket.Truncate(time.Hour)
+	for w := 1; w <= weeks; w++ {
+		for b := range 12 {
+			row := anomalyRow{
+				Service: service,
+				Bucket:  hour.Add(-time.Duration(w)*week + time.Duration(b)*anomalyBucket),
+				Count:   count + int64(b%3),
+			}
+			for _, v := range values {
+				row.Values = append(row.Values, sql.NullFloat64{Float64: v + float64(b%3), Valid: true})
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func TestDetectAnomaliesMetrics(t *testing.T) {
+	now := time.Date(2025, 6, 2, 14, 31, 0, 0, time.UTC)
+	bucket := time.Date(2025, 6, 2, 14, 25, 0, 0, time.UTC)
+	source := anomalySources[0]
+
+	// Traffic triples and latency jumps while the error rate holds.
+	rows := anomalyHistory("api", bucket, 2, 3000, 0.01, 120)
+	rows = append(rows, anomalyRow{
+		Service: "api",
+		Bucket:  bucket,
+		Count:   9000,
+		Values:  []sql.NullFloat64{{Float64: 0.01, Valid: true}, {Float64: 800, Valid: true}},
+	})
+
+	anomalies := detectAnomalies(source, rows, bucket, anomaly.DefaultDetector, now)
+	if len(anomalies) != 2 {
+		t.Fatalf("expected 2 anomalies, got %+v", anomalies)
+	}
+	if a := anomalies[0]; a.Signal != models.AnomalySignalRequestRate || a.Direction != anomaly.DirectionHigh ||
+		a.Value != 30 || a.Samples != 24 || !a.Bucket.Equal(bucket) || !a.DetectedAt.Equal(now) {
+		t.Errorf("unexpected request rate anomaly %+v", a)
+	}
+	if a := anomalies[1]; a.Signal != models.AnomalySignalLatencyP95 || a.Value != 800 || a.Baseline != 121 {
+		t.Errorf("unexpected latency anomaly %+v", a)
+	}
+
+	// Faster responses at the usual traffic are not anomalies.
+	rows[len(rows)-1].Count = 3000
+	rows[len(rows)-1].Values[1].Float64 = 10
+	if anomalies := detectAnomalies(source, rows, bucket, anomaly.DefaultDetector, now); len(anomalies) != 0 {
+		t.Errorf("expected no anomalies, got %+v", anomalies)
+	}
+}
+
+func TestDetectAnomaliesMissingTraffic(t *testing.T) {
+	now := time.Date(2025, 6, 2, 14, 31, 0, 0, time.UTC)
+	bucket := time.Date(2025, 6, 2, 14, 25, 0, 0, time.UTC)
+	source := anomalySources[1]
+
+	// The service logged every bucket of the hour in previous weeks, but
+	// nothing in the current bucket.
+	rows := anomalyHistory("worker", bucket, 2, 600)
+	anomalies := detectAnomalies(source, rows, bucket, anomaly.DefaultDetector, now)
+	if len(anomalies) != 1 || anomalies[0].Direction != anomaly.DirectionLow || anomalies[0].Value != 0 {
+		t.Fatalf("expected a drop in log volume, got %+v", anomalies)
+	}
+
+	// Buckets without logs in weeks with logs count as 0, so an hour that
+	// is usually quiet is not anomalous when it is quiet.
+	sparse := []anomalyRow{}
+	for _, row := range rows {
+		if row.Bucket.Minute() == 0 {
+			sparse = append(sparse, row)
+		}
+	}
+	if anomalies := detectAnomalies(source, sparse, bucket, anomaly.DefaultDetector, now); len(anomalies) != 0 {
+		t.Errorf("expected no anomalies, got %+v", anomalies)
+	}
+}
+
+func TestAnomalyFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/anomalies?service=api&signal=error_rate&direction=high&start_time=2025-06-01T00:00:00Z", nil)
+
+	filter, err := parseAnomalyFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clause, params, next := filter.whereClause(1)
+	if clause != " AND service_name = $1 AND signal = $2 AND direction = $3 AND bucket >= $4" || next != 5 {
+		t.Errorf("unexpected clause %q (next %d)", clause, next)
+	}
+	if len(params) != 4 || params[0] != "api" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	for _, query := range []string{"signal=cpu", "direction=up", "end_time=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/anomalies?"+query, nil)
+		if _, err := parseAnomalyFilter(req); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/apikeys"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/middleware"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const apiKeyColumns = `id, name, prefix, key_hash, role, array_to_json(scopes), service_name,
+	expires_at, last_used_at, created_at, revoked_at`
+
+var validAPIKeyRoles = map[string]bool{
+	"admin": true,
+	"user":  true,
+}
+
+// apiKeyRequest holds the fields of an API key set by its creator.
+type apiKeyRequest struct {
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Scopes    []string   `json:"scopes"`
+	Service   string     `json:"service"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// validateAPIKeyRequest checks a new key's role and scopes and that it does
+// not expire in the past.
+func validateAPIKeyRequest(req *apiKeyRequest, now time.Time) error {
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if !validAPIKeyRoles[req.Role] {
+		return fmt.Errorf("invalid role: %s", req.Role)
+	}
+	for _, scope := range req.Scopes {
+		if !apikeys.ValidScope(scope) {
+			return fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+	if req.Scopes == nil {
+		req.Scopes = []string{}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return errors.New("expires_at must be in the future")
+	}
+	return nil
+}
+
+// GetAPIKeys returns all API keys, including revoked ones, newest first.
+func GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys ORDER BY id DESC"
+
+	rows, err := db.DB.QueryContext(ctx, query)
+	if err != nil {
+		log.Println("❌ Error fetching API keys:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			log.Println("❌ Error scanning API key row:", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// GetAPIKey returns a single API key, or sql.ErrNoRows.
+func GetAPIKey(id int) (models.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE id = $1"
+	return scanAPIKey(db.DB.QueryRowContext(context.Background(), query, id))
+}
+
+// CreateAPIKey generates and stores a key for a validated request. The
+// returned key is the only place its secret appears.
+func CreateAPIKey(req apiKeyRequest) (models.CreatedAPIKey, error) {
+	secret, prefix, err := apikeys.Generate()
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+
+	query := `INSERT INTO api_keys (name, prefix, key_hash, role, scopes, service_name, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)
+              RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(db.DB.QueryRowContext(context.Background(), query,
+		req.Name, prefix, apikeys.Hash(secret), req.Role, req.Scopes, req.Service, req.ExpiresAt))
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	return models.CreatedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+// RevokeAPIKey stops a key from authenticating, keeping it for reference.
+// Revoking a revoked key changes nothing. It returns sql.ErrNoRows when the
+// key does not exist.
+func RevokeAPIKey(id int, now time.Time) error {
+	result, err := db.DB.ExecContext(context.Background(),
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1", id, now)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// APIKeyStore looks up API keys for middleware.AuthMiddleware.
+type APIKeyStore struct{}
+
+// FindAPIKey returns the key with prefix, or sql.ErrNoRows.
+func (APIKeyStore) FindAPIKey(ctx context.Context, prefix string) (models.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE prefix = $1"
+	return scanAPIKey(db.DB.QueryRowContext(ctx, query, prefix))
+}
+
+// TouchAPIKey records that a key was used at usedAt.
+func (APIKeyStore) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error {
+	_, err := db.DB.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, usedAt)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes []byte
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Role,
+		&scopes,
+		&key.Service,
+		&expiresAt,
+		&lastUsedAt,
+		&key.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	key.Scopes = []string{}
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return models.APIKey{}, err
+	}
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+
+	return key, nil
+}
+
+// checkIngestService refuses data of another service than the one the
+// request's API key is limited to, reporting whether the request may go on.
+func checkIngestService(w http.ResponseWriter, r *http.Request, service string) bool {
+	restricted := middleware.RestrictedService(r.Context())
+	if restricted == "" || service == restricted {
+		return true
+	}
+	log.Printf("🔒 Access denied: API key limited to service %s sent data of %s", restricted, service)
+	http.Error(w, fmt.Sprintf("API key is limited to service %s", restricted), http.StatusForbidden)
+	return false
+}
+
+func GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := GetAPIKeys(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch API keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+func CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var req apiKeyRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateAPIKeyRequest(&req, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := CreateAPIKey(req)
+	if err != nil {
+		log.Printf("❌ Error creating API key: %v", err)
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+func GetAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := GetAPIKey(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching API key: %v", err)
+		http.Error(w, "Failed to fetch API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+func RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = RevokeAPIKey(id, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error revoking API key: %v", err)
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
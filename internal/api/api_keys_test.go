@@ -0,0 +1,33 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateAPIKeyRequest(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	later := now.Add(24 * time.Hour)
+
+	req := apiKeyRequest{Name: "checkout ingest", Role: "user", Scopes: []string{"ingest", "logs:read"}, ExpiresAt: &later}
+	if err := validateAPIKeyRequest(&req, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req = apiKeyRequest{Name: "ci", Role: "admin"}
+	if err := validateAPIKeyRequest(&req, now); err != nil || req.Scopes == nil {
+		t.Errorf("expected empty scopes, got %v (%v)", req.Scopes, err)
+	}
+
+	cases := []apiKeyRequest{
+		{Role: "user"},
+		{Name: "k", Role: "owner"},
+		{Name: "k", Role: "user", Scopes: []string{"logs:write"}},
+		{Name: "k", Role: "user", ExpiresAt: &now},
+	}
+	for _, c := range cases {
+		if err := validateAPIKeyRequest(&c, now); err == nil {
+			t.Errorf("%+v: expected error", c)
+		}
+	}
+}
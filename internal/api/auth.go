@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jwtauth"
+	"github.com/NathanSanchezDev/go-insight/internal/middleware"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/oidc"
+)
+
+const (
+	// sessionTTL is how long a dashboard login lasts. The role is mapped
+	// again at the next login.
+	sessionTTL = 12 * time.Hour
+	// loginCookie holds the state of a login in progress.
+	loginCookie = "go_insight_login"
+	loginTTL    = 10 * time.Minute
+
+	defaultLoginScopes    = "openid profile email"
+	defaultLoginRoleClaim = "groups"
+)
+
+const sessionColumns = "id, token_hash, subject, email, name, role, created_at, expires_at"
+
+// loginSettings configures dashboard login with an OpenID Connect provider.
+type loginSettings struct {
+	config                oidc.Config
+	roles                 jwtauth.RoleMapper
+	postLogoutRedirectURL string
+	// secure marks cookies Secure, which browsers only send over HTTPS. It is
+	// off only when the redirect URL uses plain HTTP, as in local development.
+	secure bool
+}
+
+// login holds the settings and, once discovered, the provider. Discovery is
+// retried on the next login when the provider is unavailable.
+var login struct {
+	sync.Mutex
+	settings *loginSettings
+	provider *oidc.Provider
+}
+
+// loginState is kept in loginCookie between the redirect to the provider
+// and the callback.
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+}
+
+// SetupLogin enables dashboard login when OIDC_ISSUER, OIDC_CLIENT_ID and
+// OIDC_REDIRECT_URL are set, reporting whether it did.
+func SetupLogin() (bool, error) {
+	settings, err := loadLoginSettings()
+	if err != nil || settings == nil {
+		return false, err
+	}
+
+	login.Lock()
+	defer login.Unlock()
+	login.settings = settings
+	login.provider = nil
+	return true, nil
+}
+
+func loadLoginSettings() (*loginSettings, error) {
+	issuer := os.Getenv("OIDC_ISSUER")
+	clientID := os.Getenv("OIDC_CLIENT_ID")
+	redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	if issuer == "" && clientID == "" && redirectURL == "" {
+		return nil, nil
+	}
+	if issuer == "" || clientID == "" || redirectURL == "" {
+		return nil, errors.New("OIDC_ISSUER, OIDC_CLIENT_ID and OIDC_REDIRECT_URL are all required for login")
+	}
+
+	scopes := os.Getenv("OIDC_SCOPES")
+	if scopes == "" {
+		scopes = defaultLoginScopes
+	}
+	roleClaim := os.Getenv("OIDC_ROLE_CLAIM")
+	if roleClaim == "" {
+		roleClaim = defaultLoginRoleClaim
+	}
+	mapping, err := jwtauth.ParseRoleMapping(os.Getenv("OIDC_ROLE_MAPPING"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &loginSettings{
+		config: oidc.Config{
+			Issuer:       issuer,
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  redirectURL,
+			Scopes:       strings.Fields(scopes),
+		},
+		roles:                 jwtauth.RoleMapper{Claim: roleClaim, Mapping: mapping},
+		postLogoutRedirectURL: os.Getenv("OIDC_POST_LOGOUT_REDIRECT_URL"),
+		secure:                !strings.HasPrefix(redirectURL, "http://"),
+	}, nil
+}
+
+// loginProvider returns the login settings and the discovered provider. The
+// settings are nil when login is not configured.
+func loginProvider(ctx context.Context) (*loginSettings, *oidc.Provider, error) {
+	login.Lock()
+	defer login.Unlock()
+
+	if login.settings == nil || login.provider != nil {
+		return login.settings, login.provider, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	provider, err := oidc.NewProvider(ctx, login.settings.config, client)
+	if err != nil {
+		return login.settings, nil, err
+	}
+	login.provider = provider
+	return login.settings, provider, nil
+}
+
+// safeReturnTo keeps redirects after login on this site: only absolute
+// paths are allowed, and not //host or /\host, which browsers treat as
+// other sites. Control characters and backslashes are rejected outright,
+// since browsers drop or rewrite them and would turn /\t/host into //host.
+func safeReturnTo(returnTo string) string {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") {
+		return "/"
+	}
+	if strings.ContainsFunc(returnTo, func(r rune) bool { return r < 0x20 || r == 0x7f || r == '\\' }) {
+		return "/"
+	}
+	parsed, err := url.Parse(returnTo)
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" {
+		return "/"
+	}
+	return returnTo
+}
+
+// sessionFromClaims builds the session of a user from their ID token.
+func sessionFromClaims(claims jwtauth.Claims, roles jwtauth.RoleMapper, now time.Time) models.Session {
+	session := models.Session{
+		Role:      middleware.HighestRole(roles.Roles(claims)),
+		CreatedAt: now,
+		ExpiresAt: now.Add(sessionTTL),
+	}
+	session.Subject, _ = claims["sub"].(string)
+	session.Email, _ = claims["email"].(string)
+	if session.Name, _ = claims["name"].(string); session.Name == "" {
+		session.Name, _ = claims["preferred_username"].(string)
+	}
+	return session
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession stores a session for token, setting its ID, and removes
+// expired sessions.
+func CreateSession(session *models.Session, token string) error {
+	ctx := context.Background()
+	if _, err := db.DB.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= $1", session.CreatedAt); err != nil {
+		log.Printf("❌ Error removing expired sessions: %v", err)
+	}
+
+	query := `INSERT INTO sessions (token_hash, subject, email, name, role, created_at, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)
+              RETURNING id`
+
+	return db.DB.QueryRowContext(ctx, query, hashSessionToken(token), session.Subject, session.Email,
+		session.Name, session.Role, session.CreatedAt, session.ExpiresAt).Scan(&session.ID)
+}
+
+// DeleteSession ends the session of token. Deleting a missing session is
+// not an error.
+func DeleteSession(token string) error {
+	_, err := db.DB.ExecContext(context.Background(), "DELETE FROM sessions WHERE token_hash = $1", hashSessionToken(token))
+	return err
+}
+
+// createSessionFunc and deleteSessionFunc allow tests to mock session storage.
+var (
+	createSessionFunc = CreateSession
+	deleteSessionFunc = DeleteSession
+)
+
+// SessionStore looks up sessions for middleware.AuthMiddleware.
+type SessionStore struct{}
+
+// FindSession returns the session of token, or sql.ErrNoRows.
+func (SessionStore) FindSession(ctx context.Context, token string) (models.Session, error) {
+	var session models.Session
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE token_hash = $1"
+	err := db.DB.QueryRowContext(ctx, query, hashSessionToken(token)).Scan(
+		&session.ID,
+		&session.TokenHash,
+		&session.Subject,
+		&session.Email,
+		&session.Name,
+		&session.Role,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+	)
+	return session, err
+}
+
+func setCookie(w http.ResponseWriter, name, value, path string, expires time.Time, secure bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if expires.IsZero() {
+		cookie.MaxAge = -1
+	} else {
+		cookie.Expires = expires
+		cookie.MaxAge = int(time.Until(expires).Seconds())
+	}
+	http.SetCookie(w, cookie)
+}
+
+func readLoginState(r *http.Request) (loginState, error) {
+	var state loginState
+	cookie, err := r.Cookie(loginCookie)
+	if err != nil {
+		return state, err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	settings, provider, err := loginProvider(r.Context())
+	if settings == nil {
+		http.Error(w, "Login is not configured", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error discovering login provider: %v", err)
+		http.Error(w, "Login provider unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	state := loginState{ReturnTo: safeReturnTo(r.URL.Query().Get("return_to"))}
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		if *value, err = oidc.RandomString(); err != nil {
+			log.Printf("❌ Error starting login: %v", err)
+			http.Error(w, "Failed to start login", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	data, _ := json.Marshal(state)
+	setCookie(w, loginCookie, base64.RawURLEncoding.EncodeToString(data), "/auth/", time.Now().Add(loginTTL), settings.secure)
+	http.Redirect(w, r, provider.AuthCodeURL(state.State, state.Nonce, state.Verifier), http.StatusFound)
+}
+
+func LoginCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	settings, provider, err := loginProvider(r.Context())
+	if settings == nil {
+		http.Error(w, "Login is not configured", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error discovering login provider: %v", err)
+		http.Error(w, "Login provider unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	state, err := readLoginState(r)
+	setCookie(w, loginCookie, "", "/auth/", time.Time{}, settings.secure)
+	if err != nil {
+		http.Error(w, "Login expired, please try again", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	if loginErr := q.Get("error"); loginErr != "" {
+		log.Printf("🔒 Login failed at provider: %s %s", loginErr, q.Get("error_description"))
+		http.Error(w, "Login failed: "+loginErr, http.StatusUnauthorized)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state.State)) != 1 {
+		http.Error(w, "Invalid login state", http.StatusBadRequest)
+		return
+	}
+
+	idToken, err := provider.Exchange(r.Context(), q.Get("code"), state.Verifier)
+	if err != nil {
+		log.Printf("🔒 Login code exchange failed: %v", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	claims, err := provider.VerifyIDToken(r.Context(), idToken, state.Nonce, now)
+	if err != nil {
+		log.Printf("🔒 ID token validation error: %v", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+
+	session := sessionFromClaims(claims, settings.roles, now)
+	if session.Role == "" {
+		log.Printf("🔒 Login refused: %s has no role", session.Subject)
+		http.Error(w, "Your account has no go-insight role", http.StatusForbidden)
+		return
+	}
+
+	token, err := oidc.RandomString()
+	if err == nil {
+		err = createSessionFunc(&session, token)
+	}
+	if err != nil {
+		log.Printf("❌ Error creating session: %v", err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✅ %s logged in as %s", session.Subject, session.Role)
+	setCookie(w, middleware.SessionCookie, token, "/", session.ExpiresAt, settings.secure)
+	http.Redirect(w, r, state.ReturnTo, http.StatusFound)
+}
+
+// LogoutHandler ends the session and, when the provider supports it, sends
+// the browser on to end the session at the provider too.
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(middleware.SessionCookie); err == nil {
+		if err := deleteSessionFunc(cookie.Value); err != nil {
+			log.Printf("❌ Error deleting session: %v", err)
+			http.Error(w, "Failed to log out", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	settings, provider, err := loginProvider(r.Context())
+	target := "/"
+	if settings != nil && err == nil {
+		if logoutURL := provider.LogoutURL(settings.postLogoutRedirectURL); logoutURL != "" {
+			target = logoutURL
+		}
+	}
+
+	secure := settings == nil || settings.secure
+	setCookie(w, middleware.SessionCookie, "", "/", time.Time{}, secure)
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// SessionHandler returns the logged-in user, for the dashboard to show.
+func SessionHandler(w http.ResponseWriter, r *http.Request) {
+	login.Lock()
+	configured := login.settings != nil
+	login.Unlock()
+	if !configured {
+		http.Error(w, "Login is not configured", http.StatusNotFound)
+		return
+	}
+
+	cookie, err := r.Cookie(middleware.SessionCookie)
+	if err != nil {
+		http.Error(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	session, err := SessionStore{}.FindSession(r.Context(), cookie.Value)
+	if err != nil || !time.Now().Before(session.ExpiresAt) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("❌ Error fetching session: %v", err)
+		}
+		http.Error(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
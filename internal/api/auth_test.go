@@ -0,0 +1,216 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/jwtauth"
+	"github.com/NathanSanchezDev/go-insight/internal/middleware"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/oidc"
+)
+
+func TestSafeReturnTo(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"/traces/abc?x=1", "/traces/abc?x=1"},
+		{"", "/"},
+		{"https://evil.example", "/"},
+		{"https:/evil.example", "/"},
+		{"//evil.example/x", "/"},
+		{"/%5cevil.example", "/"},
+		{"/%09/evil.example", "/"},
+		{"/%0a/evil.example", "/"},
+		{"/%0d%0a/evil.example", "/"},
+		{"%2F%2Fevil.example", "/"},
+		{"/logs?q=%2F%2Fevil", "/logs?q=//evil"},
+	}
+	for _, c := range cases {
+		// Cases are written as they appear in the login URL's query.
+		req := httptest.NewRequest(http.MethodGet, "/auth/login?return_to="+c.query, nil)
+		if got := safeReturnTo(req.URL.Query().Get("return_to")); got != c.want {
+			t.Errorf("%q: expected %q, got %q", c.query, c.want, got)
+		}
+	}
+}
+
+func TestSessionFromClaims(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	roles := jwtauth.RoleMapper{Claim: "groups", Mapping: map[string]string{"sre": "admin", "dev": "user"}}
+	claims := jwtauth.Claims{"sub": "u1", "email": "a@example.com", "preferred_username": "alice", "groups": []any{"dev", "sre"}}
+
+	session := sessionFromClaims(claims, roles, now)
+	if session.Subject != "u1" || session.Email != "a@example.com" || session.Name != "alice" ||
+		session.Role != "admin" || !session.ExpiresAt.Equal(now.Add(sessionTTL)) {
+		t.Errorf("unexpected session %+v", session)
+	}
+
+	claims["groups"] = []any{"sales"}
+	if session := sessionFromClaims(claims, roles, now); session.Role != "" {
+		t.Errorf("expected no role, got %s", session.Role)
+	}
+}
+
+// loginIDP is a local provider that answers every code with an ID token for
+// the nonce and PKCE challenge of the last authorization request.
+func loginIDP(t *testing.T, groups []string) *httptest.Server {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	b64 := base64.RawURLEncoding
+	var challenge, nonce string
+
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer": idp.URL, "jwks_uri": idp.URL + "/jwks",
+				"authorization_endpoint": idp.URL + "/authorize", "token_endpoint": idp.URL + "/token",
+			})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+				"kty": "OKP", "crv": "Ed25519", "x": b64.EncodeToString(public),
+			}}})
+		case "/authorize":
+			challenge, nonce = r.URL.Query().Get("code_challenge"), r.URL.Query().Get("nonce")
+		case "/token":
+			if oidc.Challenge(r.PostFormValue("code_verifier")) != challenge {
+				http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+			head, _ := json.Marshal(map[string]string{"alg": "EdDSA"})
+			payload, _ := json.Marshal(map[string]any{
+				"iss": idp.URL, "aud": "go-insight", "sub": "u1", "nonce": nonce,
+				"exp": time.Now().Add(time.Hour).Unix(), "groups": groups,
+			})
+			signed := b64.EncodeToString(head) + "." + b64.EncodeToString(payload)
+			json.NewEncoder(w).Encode(map[string]string{
+				"id_token": signed + "." + b64.EncodeToString(ed25519.Sign(private, []byte(signed))),
+			})
+		}
+	}))
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// startLogin configures login with idp and follows the login redirect to the
+// provider, returning the callback request the provider would send.
+func startLogin(t *testing.T, idp *httptest.Server) *http.Request {
+	t.Setenv("OIDC_ISSUER", idp.URL)
+	t.Setenv("OIDC_CLIENT_ID", "go-insight")
+	t.Setenv("OIDC_REDIRECT_URL", "https://insight.example.com/auth/callback")
+	t.Setenv("OIDC_ROLE_MAPPING", "engineering=user")
+	if enabled, err := SetupLogin(); !enabled || err != nil {
+		t.Fatalf("expected login to be enabled, got %v", err)
+	}
+	t.Cleanup(func() { login.settings, login.provider = nil, nil })
+
+	rec := httptest.NewRecorder()
+	LoginHandler(rec, httptest.NewRequest(http.MethodGet, "/auth/login?return_to=/traces", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d", rec.Code)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	if !strings.HasPrefix(location.String(), idp.URL+"/authorize?") {
+		t.Fatalf("unexpected redirect %s", location)
+	}
+	cookie := rec.Result().Cookies()[0]
+	if cookie.Name != loginCookie || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("unexpected login cookie %+v", cookie)
+	}
+
+	// The user logs in at the provider.
+	resp, err := http.Get(location.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	callback := httptest.NewRequest(http.MethodGet, "/auth/callback?code=c1&state="+location.Query().Get("state"), nil)
+	callback.AddCookie(cookie)
+	return callback
+}
+
+func TestLoginCallback(t *testing.T) {
+	var created models.Session
+	createSessionFunc = func(session *models.Session, token string) error {
+		created = *session
+		return nil
+	}
+	defer func() { createSessionFunc = CreateSession }()
+
+	callback := startLogin(t, loginIDP(t, []string{"engineering"}))
+	rec := httptest.NewRecorder()
+	LoginCallbackHandler(rec, callback)
+
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/traces" {
+		t.Fatalf("expected a redirect to /traces, got %d %s: %s", rec.Code, rec.Header().Get("Location"), rec.Body)
+	}
+	if created.Subject != "u1" || created.Role != "user" {
+		t.Errorf("unexpected session %+v", created)
+	}
+
+	var session *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == middleware.SessionCookie {
+			session = cookie
+		}
+	}
+	if session == nil || session.Value == "" || !session.HttpOnly || !session.Secure || session.Path != "/" {
+		t.Errorf("unexpected session cookie %+v", session)
+	}
+}
+
+func TestLoginCallbackRejects(t *testing.T) {
+	createSessionFunc = func(*models.Session, string) error {
+		t.Error("expected no session")
+		return nil
+	}
+	defer func() { createSessionFunc = CreateSession }()
+
+	// A user whose groups map to no role is refused.
+	rec := httptest.NewRecorder()
+	LoginCallbackHandler(rec, startLogin(t, loginIDP(t, []string{"sales"})))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+
+	// A callback with another state, as in a forged login, is refused.
+	callback := startLogin(t, loginIDP(t, []string{"engineering"}))
+	callback.URL.RawQuery = "code=c1&state=forged"
+	rec = httptest.NewRecorder()
+	LoginCallbackHandler(rec, callback)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	var deleted string
+	deleteSessionFunc = func(token string) error {
+		deleted = token
+		return nil
+	}
+	defer func() { deleteSessionFunc = DeleteSession }()
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.SessionCookie, Value: "tok"})
+	rec := httptest.NewRecorder()
+	LogoutHandler(rec, req)
+
+	if deleted != "tok" || rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Errorf("unexpected logout: deleted %q, %d %s", deleted, rec.Code, rec.Header().Get("Location"))
+	}
+	if cookie := rec.Result().Cookies()[0]; cookie.Name != middleware.SessionCookie || cookie.MaxAge >= 0 {
+		t.Errorf("expected the session cookie to be cleared, got %+v", cookie)
+	}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const (
+	defaultDependencyWindow = time.Hour
+
+	// dependencyLookback is how far back each materialization run recomputes
+	// edges, covering spans that end a while after they start.
+	dependencyLookback = 15 * time.Minute
+)
+
+// DependencyFilter selects the window and services for the dependency graph.
+// Live reads edges straight from spans instead of the materialized table,
+// which is slower but includes spans the job has not processed yet.
+type DependencyFilter struct {
+	Service   string
+	StartTime time.Time
+	EndTime   time.Time
+	Live      bool
+}
+
+func parseDependencyFilter(r *http.Request, now time.Time) (DependencyFilter, error) {
+	q := r.URL.Query()
+	filter := DependencyFilter{
+		Service:   q.Get("service"),
+		EndTime:   now,
+		StartTime: now.Add(-defaultDependencyWindow),
+		Live:      q.Get("live") == "true",
+	}
+
+	if raw := q.Get("end_time"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end_time: %s", raw)
+		}
+		filter.EndTime = parsed
+		filter.StartTime = parsed.Add(-defaultDependencyWindow)
+	}
+
+	if raw := q.Get("start_time"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start_time: %s", raw)
+		}
+		filter.StartTime = parsed
+	}
+
+	if !filter.StartTime.Before(filter.EndTime) {
+		return filter, errors.New("start_time must be before end_time")
+	}
+
+	return filter, nil
+}
+
+// buildDependencyQuery returns a query yielding caller, callee, call count,
+// error count, duration sum, duration count and max duration per edge.
+func buildDependencyQuery(filter DependencyFilter) (string, []any) {
+	var query string
+	params := []any{filter.StartTime, filter.EndTime}
+
+	if filter.Live {
+		query = `SELECT parent.service, child.service, COUNT(*),
+			COUNT(*) FILTER (WHERE child.status = 'ERROR'),
+			COALESCE(SUM(child.duration_ms), 0), COUNT(child.duration_ms),
+			COALESCE(MAX(child.duration_ms), 0)
+			FROM spans child
+			JOIN spans parent ON parent.id = child.parent_id AND parent.trace_id = child.trace_id
+			WHERE child.start_time >= $1 AND child.start_time < $2
+			AND parent.service <> child.service`
+		if filter.Service != "" {
+			query += " AND (parent.service = $3 OR child.service = $3)"
+			params = append(params, filter.Service)
+		}
+		query += " GROUP BY parent.service, child.service"
+	} else {
+		query = `SELECT caller, callee, SUM(call_count), SUM(error_count),
+			SUM(duration_sum_ms), SUM(duration_count), MAX(duration_max_ms)
+			FROM service_dependencies
+			WHERE bucket >= date_trunc('minute', $1::timestamp) AND bucket < $2`
+		if filter.Service != "" {
+			query += " AND (caller = $3 OR callee = $3)"
+			params = append(params, filter.Service)
+		}
+		query += " GROUP BY caller, callee"
+	}
+
+	query += " ORDER BY 3 DESC, 1, 2"
+	return query, params
+}
+
+// GetDependencies returns the service dependency graph for the filter.
+func GetDependencies(filter DependencyFilter) (models.DependencyGraph, error) {
+	query, params := buildDependencyQuery(filter)
+
+	rows, err := db.DB.Query(query, params...)
+	if err != nil {
+		return models.DependencyGraph{}, err
+	}
+	defer rows.Close()
+
+	var edges []models.DependencyEdge
+	for rows.Next() {
+		var edge models.DependencyEdge
+		var durationSum float64
+		var durationCount int64
+		if err := rows.Scan(&edge.Caller, &edge.Callee, &edge.CallCount, &edge.ErrorCount,
+			&durationSum, &durationCount, &edge.MaxMs); err != nil {
+			return models.DependencyGraph{}, err
+		}
+		if edge.CallCount > 0 {
+			edge.ErrorRate = float64(edge.ErrorCount) / float64(edge.CallCount)
+		}
+		if durationCount > 0 {
+			edge.AvgMs = durationSum / float64(durationCount)
+		}
+		edges = append(edges, edge)
+	}
+	if err := rows.Err(); err != nil {
+		return models.DependencyGraph{}, err
+	}
+
+	graph := buildDependencyGraph(edges)
+	graph.StartTime = filter.StartTime
+	graph.EndTime = filter.EndTime
+	return graph, nil
+}
+
+// buildDependencyGraph derives the service nodes from the edges.
+func buildDependencyGraph(edges []models.DependencyEdge) models.DependencyGraph {
+	graph := models.DependencyGraph{
+		Nodes: []models.ServiceNode{},
+		Edges: []models.DependencyEdge{},
+	}
+	graph.Edges = append(graph.Edges, edges...)
+
+	nodes := map[string]*models.ServiceNode{}
+	node := func(name string) *models.ServiceNode {
+		if n, ok := nodes[name]; ok {
+			return n
+		}
+		n := &models.ServiceNode{Name: name}
+		nodes[name] = n
+		return n
+	}
+
+	for _, edge := range edges {
+		caller := node(edge.Caller)
+		caller.CallsOut += edge.CallCount
+		caller.ErrorsOut += edge.ErrorCount
+
+		callee := node(edge.Callee)
+		callee.CallsIn += edge.CallCount
+		callee.ErrorsIn += edge.ErrorCount
+	}
+
+	for _, n := range nodes {
+		graph.Nodes = append(graph.Nodes, *n)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Name < graph.Nodes[j].Name })
+
+	return graph
+}
+
+func GetDependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDependencyFilter(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	graph, err := GetDependencies(filter)
+	if err != nil {
+		log.Printf("❌ Error fetching dependencies: %v", err)
+		http.Error(w, "Error fetching dependencies", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(graph)
+}
+
+// DependencyGraphJob materializes recent service dependency edges.
+func DependencyGraphJob(interval time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "dependency-graph",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			_, err := db.MaterializeDependencies(ctx, now.Add(-dependencyLookback), now)
+			return err
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestParseDependencyFilter(t *testing.T) {
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+
+	req := httptest.NewRequest(http.MethodGet, "/dependencies", nil)
+	filter, err := parseDependencyFilter(req, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filter.EndTime.Equal(now) || !filter.StartTime.Equal(now.Add(-time.Hour)) || filter.Live {
+		t.Errorf("unexpected default filter: %+v", filter)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/dependencies?service=api&start_time=2025-05-26T00:00:00Z&live=true", nil)
+	filter, err = parseDependencyFilter(req, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Service != "api" || !filter.Live || filter.StartTime.Hour() != 0 {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+
+	for _, rawQuery := range []string{"start_time=yesterday", "start_time=2025-05-27T00:00:00Z"} {
+		req := httptest.NewRequest(http.MethodGet, "/dependencies?"+rawQuery, nil)
+		if _, err := parseDependencyFilter(req, now); err == nil {
+			t.Errorf("expected error for %q", rawQuery)
+		}
+	}
+}
+
+func TestBuildDependencyQuery(t *testing.T) {
+	filter := DependencyFilter{Service: "api", StartTime: time.Unix(0, 0), EndTime: time.Unix(3600, 0)}
+
+	query, params := buildDependencyQuery(filter)
+	if !strings.Contains(query, "FROM service_dependencies") || !strings.Contains(query, "(caller = $3 OR callee = $3)") || len(params) != 3 {
+		t.Errorf("unexpected materialized query %q with params %v", query, params)
+	}
+
+	filter.Live = true
+	query, params = buildDependencyQuery(filter)
+	if !strings.Contains(query, "JOIN spans parent") || !strings.Contains(query, "parent.service <> child.service") || len(params) != 3 {
+		t.Errorf("unexpected live query %q with params %v", query, params)
+	}
+}
+
+func TestBuildDependencyGraph(t *testing.T) {
+	graph := buildDependencyGraph([]models.DependencyEdge{
+		{Caller: "gateway", Callee: "users", CallCount: 10, ErrorCount: 1},
+		{Caller: "users", Callee: "postgres", CallCount: 25, ErrorCount: 2},
+	})
+
+	if len(graph.Edges) != 2 || len(graph.Nodes) != 3 {
+		t.Fatalf("unexpected graph: %+v", graph)
+	}
+
+	users := graph.Nodes[2]
+	if users.Name != "users" || users.CallsIn != 10 || users.CallsOut != 25 || users.ErrorsIn != 1 || users.ErrorsOut != 2 {
+		t.Errorf("unexpected users node: %+v", users)
+	}
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/errorgroups"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// maxErrorGroupSamples caps the log IDs kept per group as examples.
+const maxErrorGroupSamples = 10
+
+// errorLogLevels are the levels fingerprinted into error groups.
+var errorLogLevels = map[string]bool{
+	"ERROR": true,
+	"FATAL": true,
+}
+
+var validErrorGroupStatuses = map[string]bool{
+	models.ErrorGroupOpen:     true,
+	models.ErrorGroupResolved: true,
+	models.ErrorGroupIgnored:  true,
+}
+
+// errorGroupSorts maps the sort values accepted by the list endpoint to
+// their ORDER BY clauses.
+var errorGroupSorts = map[string]string{
+	"last_seen":  "last_seen DESC",
+	"first_seen": "first_seen DESC",
+	"count":      "count DESC, last_seen DESC",
+}
+
+const errorGroupColumns = `fingerprint, template, stack, log_level, first_seen, last_seen, count,
+	array_to_json(services), array_to_json(sample_log_ids), status, status_changed_at`
+
+// errorGroupRow is one ERROR or FATAL log reduced to the columns folded into
+// its error group.
+type errorGroupRow struct {
+	Fingerprint string
+	Template    string
+	Stack       string
+	LogLevel    string
+	Timestamp   time.Time
+	ServiceName string
+	LogID       int
+}
+
+// storeErrorGroupsFunc allows tests to mock the error group upsert.
+var storeErrorGroupsFunc = storeErrorGroups
+
+// RecordErrorGroups folds the stored ERROR and FATAL logs into their error
+// groups. Entries must already have their IDs assigned.
+func RecordErrorGroups(logs []models.Log) error {
+	var rows []errorGroupRow
+	for _, entry := range logs {
+		if !errorLogLevels[entry.LogLevel] {
+			continue
+		}
+
+		var metadata []byte
+		if entry.Metadata != nil {
+			metadata = *entry.Metadata
+		}
+		// Messages are HTML-escaped on ingest; fingerprint the original text so
+		// quoted values are recognised as variables.
+		sig := errorgroups.Compute(html.UnescapeString(entry.Message), metadata)
+
+		rows = append(rows, errorGroupRow{
+			Fingerprint: sig.Fingerprint,
+			Template:    sig.Template,
+			Stack:       sig.Stack,
+			LogLevel:    entry.LogLevel,
+			Timestamp:   entry.Timestamp,
+			ServiceName: entry.ServiceName,
+			LogID:       entry.ID,
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	return storeErrorGroupsFunc(rows)
+}
+
+// storeErrorGroups upserts the rows in a single statement, aggregating rows
+// that share a fingerprint before merging them into the existing group.
+func storeErrorGroups(rows []errorGroupRow) error {
+	query := `INSERT INTO error_groups
+                (fingerprint, template, stack, log_level, first_seen, last_seen, count, services, sample_log_ids)
+              SELECT fingerprint, MIN(template), MIN(stack),
+                     (array_agg(log_level ORDER BY ts DESC))[1],
+                     MIN(ts), MAX(ts), COUNT(*),
+                     array_agg(DISTINCT service),
+                     (array_agg(log_id ORDER BY log_id))[1:$8]
+              FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::timestamp[], $6::text[], $7::integer[])
+                AS t(fingerprint, template, stack, log_level, ts, service, log_id)
+              GROUP BY fingerprint
+              ON CONFLICT (fingerprint) DO UPDATE SET
+                first_seen = LEAST(error_groups.first_seen, EXCLUDED.first_seen),
+                last_seen = GREATEST(error_groups.last_seen, EXCLUDED.last_seen),
+                count = error_groups.count + EXCLUDED.count,
+                log_level = EXCLUDED.log_level,
+                services = error_groups.services || ARRAY(
+                                SELECT s FROM unnest(EXCLUDED.services) AS s
+                                WHERE s <> ALL(error_groups.services)),
+                sample_log_ids = (error_groups.sample_log_ids || EXCLUDED.sample_log_ids)[1:$8],
+                status = CASE WHEN error_groups.status = 'resolved' THEN 'open' ELSE error_groups.status END,
+                status_changed_at = CASE WHEN error_groups.status = 'resolved' THEN NOW()
+                                         ELSE error_groups.status_changed_at END`
+
+	fingerprints := make([]string, len(rows))
+	templates := make([]string, len(rows))
+	stacks := make([]string, len(rows))
+	levels := make([]string, len(rows))
+	timestamps := make([]time.Time, len(rows))
+	services := make([]string, len(rows))
+	logIDs := make([]int32, len(rows))
+	for i, row := range rows {
+		fingerprints[i] = row.Fingerprint
+		templates[i] = row.Template
+		stacks[i] = row.Stack
+		levels[i] = row.LogLevel
+		timestamps[i] = row.Timestamp
+		services[i] = row.ServiceName
+		logIDs[i] = int32(row.LogID)
+	}
+
+	_, err := db.DB.ExecContext(context.Background(), query,
+		fingerprints, templates, stacks, levels, timestamps, services, logIDs, maxErrorGroupSamples)
+	if err != nil {
+		return fmt.Errorf("recording %d error group rows: %w", len(rows), err)
+	}
+	return nil
+}
+
+// ErrorGroupFilter holds the optional filters for listing error groups.
+type ErrorGroupFilter struct {
+	Status  string
+	Service string
+	Since   time.Time
+}
+
+func (f ErrorGroupFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
+	var params []any
+
+	if f.Status != "" {
+		clause += fmt.Sprintf(" AND status = $%d", paramCount)
+		params = append(params, f.Status)
+		paramCount++
+	}
+
+	if f.Service != "" {
+		clause += fmt.Sprintf(" AND services @> ARRAY[$%d::text]", paramCount)
+		params = append(params, f.Service)
+		paramCount++
+	}
+
+	if !f.Since.IsZero() {
+		clause += fmt.Sprintf(" AND last_seen >= $%d", paramCount)
+		params = append(params, f.Since)
+		paramCount++
+	}
+
+	return clause, params, paramCount
+}
+
+func parseErrorGroupFilter(r *http.Request) (ErrorGroupFilter, error) {
+	q := r.URL.Query()
+	filter := ErrorGroupFilter{
+		Status:  q.Get("status"),
+		Service: q.Get("service"),
+	}
+
+	if filter.Status != "" && !validErrorGroupStatuses[filter.Status] {
+		return filter, fmt.Errorf("invalid status: %s", filter.Status)
+	}
+
+	if raw := q.Get("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since: %s", raw)
+		}
+		filter.Since = parsed
+	}
+
+	return filter, nil
+}
+
+// GetErrorGroups lists error groups matching the filter in the given order,
+// which must be a key of errorGroupSorts.
+func GetErrorGroups(filter ErrorGroupFilter, sortBy string, limit, offset int) ([]models.ErrorGroup, error) {
+	query := "SELECT " + errorGroupColumns + " FROM error_groups WHERE 1=1"
+
+	where, params, paramCount := filter.whereClause(1)
+	query += where
+	query += " ORDER BY " + errorGroupSorts[sortBy] + ", fingerprint"
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramCount, paramCount+1)
+	params = append(params, limit, offset)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching error groups:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.ErrorGroup, 0)
+	for rows.Next() {
+		group, err := scanErrorGroup(rows)
+		if err != nil {
+			log.Println("❌ Error scanning error group row:", err)
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// GetErrorGroup returns a single error group, or sql.ErrNoRows.
+func GetErrorGroup(fingerprint string) (models.ErrorGroup, error) {
+	query := "SELECT " + errorGroupColumns + " FROM error_groups WHERE fingerprint = $1"
+	return scanErrorGroup(db.DB.QueryRowContext(context.Background(), query, fingerprint))
+}
+
+// UpdateErrorGroupStatus sets the status of a group, returning sql.ErrNoRows
+// when it does not exist.
+func UpdateErrorGroupStatus(fingerprint, status string) (models.ErrorGroup, error) {
+	query := `UPDATE error_groups SET status = $1, status_changed_at = NOW()
+              WHERE fingerprint = $2
+              RETURNING ` + errorGroupColumns
+	return scanErrorGroup(db.DB.QueryRowContext(context.Background(), query, status, fingerprint))
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanErrorGroup(row rowScanner) (models.ErrorGroup, error) {
+	var group models.ErrorGroup
+	var services, samples []byte
+	var statusChangedAt sql.NullTime
+
+	err := row.Scan(
+		&group.Fingerprint,
+		&group.Template,
+		&group.Stack,
+		&group.LogLevel,
+		&group.FirstSeen,
+		&group.LastSeen,
+		&group.Count,
+		&services,
+		&samples,
+		&group.Status,
+		&statusChangedAt,
+	)
+	if err != nil {
+		return models.ErrorGroup{}, err
+	}
+
+	if err := json.Unmarshal(services, &group.Services); err != nil {
+		return models.ErrorGroup{}, err
+	}
+	if err := json.Unmarshal(samples, &group.SampleLogIDs); err != nil {
+		return models.ErrorGroup{}, err
+	}
+	if statusChangedAt.Valid {
+		group.StatusChangedAt = &statusChangedAt.Time
+	}
+
+	return group, nil
+}
+
+func GetErrorGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseErrorGroupFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = "last_seen"
+	}
+	if _, ok := errorGroupSorts[sortBy]; !ok {
+		http.Error(w, fmt.Sprintf("invalid sort: %s", sortBy), http.StatusBadRequest)
+		return
+	}
+
+	limit, offset := parseLimitOffset(r)
+
+	groups, err := GetErrorGroups(filter, sortBy, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch error groups", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+func GetErrorGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, err := GetErrorGroup(mux.Vars(r)["fingerprint"])
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Error group not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching error group: %v", err)
+		http.Error(w, "Failed to fetch error group", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// UpdateErrorGroupHandler handles PATCH /error-groups/{fingerprint}, used to
+// mark a group resolved, ignored or open again.
+func UpdateErrorGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validErrorGroupStatuses[req.Status] {
+		http.Error(w, fmt.Sprintf("invalid status: %s", req.Status), http.StatusBadRequest)
+		return
+	}
+
+	group, err := UpdateErrorGroupStatus(mux.Vars(r)["fingerprint"], req.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Error group not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error updating error group: %v", err)
+		http.Error(w, "Failed to update error group", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestParseErrorGroupFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/error-groups?status=open&service=payments&since=2025-05-26T00:00:00Z", nil)
+	filter, err := parseErrorGroupFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clause, params, next := filter.whereClause(1)
+	for _, want := range []string{"status = $1", "services @> ARRAY[$2::text]", "last_seen >= $3"} {
+		if !strings.Contains(clause, want) {
+			t.Errorf("clause %q missing %q", clause, want)
+		}
+	}
+	if len(params) != 3 || next != 4 {
+		t.Errorf("unexpected params %v (next %d)", params, next)
+	}
+
+	for _, rawQuery := range []string{"status=closed", "since=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/error-groups?"+rawQuery, nil)
+		if _, err := parseErrorGroupFilter(req); err == nil {
+			t.Errorf("expected error for %q", rawQuery)
+		}
+	}
+}
+
+func TestGetErrorGroupsHandlerRejectsUnknownSort(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/error-groups?sort=name", nil)
+	rec := httptest.NewRecorder()
+
+	GetErrorGroupsHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestUpdateErrorGroupHandlerValidatesStatus(t *testing.T) {
+	for _, body := range []string{`{"status":"closed"}`, `{"state":"resolved"}`, `{`} {
+		req := httptest.NewRequest(http.MethodPatch, "/error-groups/abc", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+
+		UpdateErrorGroupHandler(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestPostLogsBulkHandlerGroupsQuotedVariants(t *testing.T) {
+	postLogsBulkFunc = func(entries []models.Log) error {
+		for i := range entries {
+			entries[i].ID = i + 1
+		}
+		afterLogsStored(entries)
+		return nil
+	}
+	defer func() { postLogsBulkFunc = PostLogsBulk }()
+
+	var stored []errorGroupRow
+	storeErrorGroupsFunc = func(rows []errorGroupRow) error {
+		stored = append(stored, rows...)
+		return nil
+	}
+	defer func() { storeErrorGroupsFunc = storeErrorGroups }()
+
+	body := `[{"service_name":"svc","log_level":"ERROR","message":"key \"cache-a\" missing in 'pool-x'"},
+		{"service_name":"svc","log_level":"ERROR","message":"key \"session-b\" missing in 'pool-y'"}]`
+	req := httptest.NewRequest(http.MethodPost, "/logs/bulk", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	PostLogsBulkHandler(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("expected 2 rows in one upsert, got %d", len(stored))
+	}
+	if stored[0].Fingerprint != stored[1].Fingerprint {
+		t.Errorf("expected one group, got templates %q and %q", stored[0].Template, stored[1].Template)
+	}
+	if stored[0].Template != "key <str> missing in <str>" {
+		t.Errorf("unexpected template %q", stored[0].Template)
+	}
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const inhibitRuleColumns = `id, name, source_matchers, target_matchers, array_to_json(equal_labels),
+	created_at, updated_at`
+
+// validateInhibitRule checks that both sides of the rule select alerts.
+func validateInhibitRule(rule *models.InhibitRule) error {
+	if rule.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if len(rule.SourceMatchers) == 0 || len(rule.TargetMatchers) == 0 {
+		return errors.New("source_matchers and target_matchers are required")
+	}
+	if _, err := alerting.CompileMatchers(rule.SourceMatchers); err != nil {
+		return fmt.Errorf("invalid source_matchers: %w", err)
+	}
+	if _, err := alerting.CompileMatchers(rule.TargetMatchers); err != nil {
+		return fmt.Errorf("invalid target_matchers: %w", err)
+	}
+
+	if rule.Equal == nil {
+		rule.Equal = []string{}
+	}
+	for _, label := range rule.Equal {
+		if label == "" {
+			return errors.New("equal labels cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+func GetInhibitRules(ctx context.Context) ([]models.InhibitRule, error) {
+	query := "SELECT " + inhibitRuleColumns + " FROM inhibit_rules ORDER BY id"
+
+	rows, err := db.DB.QueryContext(ctx, query)
+	if err != nil {
+		log.Println("❌ Error fetching inhibit rules:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]models.InhibitRule, 0)
+	for rows.Next() {
+		rule, err := scanInhibitRule(rows)
+		if err != nil {
+			log.Println("❌ Error scanning inhibit rule row:", err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GetInhibitRule returns a single rule, or sql.ErrNoRows.
+func GetInhibitRule(id int) (models.InhibitRule, error) {
+	query := "SELECT " + inhibitRuleColumns + " FROM inhibit_rules WHERE id = $1"
+	return scanInhibitRule(db.DB.QueryRowContext(context.Background(), query, id))
+}
+
+// CreateInhibitRule stores a validated rule, setting its ID and timestamps.
+func CreateInhibitRule(rule *models.InhibitRule) error {
+	params, err := inhibitRuleParams(*rule)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO inhibit_rules (name, source_matchers, target_matchers, equal_labels)
+              VALUES ($1, $2, $3, $4)
+              RETURNING ` + inhibitRuleColumns
+
+	created, err := scanInhibitRule(db.DB.QueryRowContext(context.Background(), query, params...))
+	if err != nil {
+		return err
+	}
+	*rule = created
+	return nil
+}
+
+// UpdateInhibitRule replaces a validated rule, returning sql.ErrNoRows when
+// it does not exist.
+func UpdateInhibitRule(rule *models.InhibitRule) error {
+	params, err := inhibitRuleParams(*rule)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE inhibit_rules SET
+                name = $1, source_matchers = $2, target_matchers = $3, equal_labels = $4, updated_at = NOW()
+              WHERE id = $5
+              RETURNING ` + inhibitRuleColumns
+
+	params = append(params, rule.ID)
+	updated, err := scanInhibitRule(db.DB.QueryRowContext(context.Background(), query, params...))
+	if err != nil {
+		return err
+	}
+	*rule = updated
+	return nil
+}
+
+// DeleteInhibitRule removes a rule, returning sql.ErrNoRows when it does not
+// exist.
+func DeleteInhibitRule(id int) error {
+	result, err := db.DB.ExecContext(context.Background(), "DELETE FROM inhibit_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func inhibitRuleParams(rule models.InhibitRule) ([]any, error) {
+	source, err := json.Marshal(rule.SourceMatchers)
+	if err != nil {
+		return nil, err
+	}
+	target, err := json.Marshal(rule.TargetMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{rule.Name, source, target, rule.Equal}, nil
+}
+
+func scanInhibitRule(row rowScanner) (models.InhibitRule, error) {
+	var rule models.InhibitRule
+	var source, target, equal []byte
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&source,
+		&target,
+		&equal,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return models.InhibitRule{}, err
+	}
+
+	if err := json.Unmarshal(source, &rule.SourceMatchers); err != nil {
+		return models.InhibitRule{}, err
+	}
+	if err := json.Unmarshal(target, &rule.TargetMatchers); err != nil {
+		return models.InhibitRule{}, err
+	}
+	if err := json.Unmarshal(equal, &rule.Equal); err != nil {
+		return models.InhibitRule{}, err
+	}
+
+	return rule, nil
+}
+
+func decodeInhibitRule(r *http.Request) (models.InhibitRule, error) {
+	var rule models.InhibitRule
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rule); err != nil {
+		return rule, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return rule, validateInhibitRule(&rule)
+}
+
+func GetInhibitRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := GetInhibitRules(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch inhibit rules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+func CreateInhibitRuleHandler(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeInhibitRule(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := CreateInhibitRule(&rule); err != nil {
+		log.Printf("❌ Error creating inhibit rule: %v", err)
+		http.Error(w, "Failed to create inhibit rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func GetInhibitRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := GetInhibitRule(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Inhibit rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching inhibit rule: %v", err)
+		http.Error(w, "Failed to fetch inhibit rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func UpdateInhibitRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := decodeInhibitRule(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rule.ID = id
+
+	err = UpdateInhibitRule(&rule)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Inhibit rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error updating inhibit rule: %v", err)
+		http.Error(w, "Failed to update inhibit rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+func DeleteInhibitRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = DeleteInhibitRule(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Inhibit rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error deleting inhibit rule: %v", err)
+		http.Error(w, "Failed to delete inhibit rule", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
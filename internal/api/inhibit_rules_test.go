@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeInhibitRule(t *testing.T) {
+	body := `{"name": "down-inhibits-latency",
+		"source_matchers": [{"name": "alertname", "op": "=", "value": "ServiceDown"}],
+		"target_matchers": [{"name": "alertname", "op": "=~", "value": ".*Latency"}],
+		"equal": ["service"]}`
+	req := httptest.NewRequest(http.MethodPost, "/inhibit-rules", strings.NewReader(body))
+
+	rule, err := decodeInhibitRule(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rule.Equal) != 1 || rule.TargetMatchers[0].Op != "=~" {
+		t.Errorf("unexpected rule %+v", rule)
+	}
+
+	invalid := []string{
+		`{"name": "x", "target_matchers": [{"name": "a", "op": "=", "value": "b"}]}`,
+		`{"name": "x", "source_matchers": [{"name": "a", "op": "=~", "value": "("}],
+			"target_matchers": [{"name": "a", "op": "=", "value": "b"}]}`,
+		`{"source_matchers": [{"name": "a", "op": "=", "value": "b"}],
+			"target_matchers": [{"name": "a", "op": "=", "value": "b"}]}`,
+		`{"name": "x", "source_matchers": [{"name": "a", "op": "=", "value": "b"}],
+			"target_matchers": [{"name": "a", "op": "=", "value": "c"}], "equal": [""]}`,
+	}
+	for _, body := range invalid {
+		req := httptest.NewRequest(http.MethodPost, "/inhibit-rules", strings.NewReader(body))
+		if _, err := decodeInhibitRule(req); err == nil {
+			t.Errorf("%s: expected error", body)
+		}
+	}
+}
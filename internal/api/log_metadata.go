@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metadata filters are query parameters of the form meta.<path><op><value>,
+// where path is a dot separated list of JSON keys:
+//
+//	meta.user_id=42            equality (matches 42 and "42")
+//	meta.user_id!=42           inequality
+//	meta.http.status>=500      numeric comparison (>, >=, <, <=)
+//	meta.error=*               key exists
+//	meta.error!=*              key does not exist
+//
+// Conditions are rendered with the @>, @? and @@ operators so they can use a
+// jsonb_path_ops GIN index. The same syntax filters span attributes with the
+// attr. prefix on GET /traces.
+
+const (
+	metadataParamPrefix  = "meta."
+	attributeParamPrefix = "attr."
+)
+
+// metadataKeyPattern restricts keys so they can be quoted into jsonpath
+// expressions without escaping.
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
+type metadataCondition struct {
+	Path  []string
+	Op    string
+	Value string
+}
+
+// parseMetadataFilters extracts all conditions with the given parameter
+// prefix from the query string. Because operators like >= contain '=', the
+// key and value are rejoined and split again on the first operator.
+func parseMetadataFilters(q url.Values, prefix string) ([]metadataCondition, error) {
+	var keys []string
+	for key := range q {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var conditions []metadataCondition
+	for _, key := range keys {
+		for _, value := range q[key] {
+			expr := key
+			if value != "" || !strings.ContainsAny(key, "<>") {
+				expr += "=" + value
+			}
+
+			cond, err := parseMetadataCondition(strings.TrimPrefix(expr, prefix))
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, cond)
+		}
+	}
+
+	return conditions, nil
+}
+
+func parseMetadataCondition(expr string) (metadataCondition, error) {
+	opStart := strings.IndexAny(expr, "!<>=")
+	if opStart <= 0 {
+		return metadataCondition{}, fmt.Errorf("invalid metadata filter: %s", expr)
+	}
+
+	op := expr[opStart : opStart+1]
+	if opStart+1 < len(expr) && expr[opStart+1] == '=' && op != "=" {
+		op += "="
+	}
+	if op == "!" {
+		return metadataCondition{}, fmt.Errorf("invalid metadata filter: %s", expr)
+	}
+
+	cond := metadataCondition{
+		Path:  strings.Split(expr[:opStart], "."),
+		Op:    op,
+		Value: expr[opStart+len(op):],
+	}
+
+	for _, key := range cond.Path {
+		if !metadataKeyPattern.MatchString(key) {
+			return metadataCondition{}, fmt.Errorf("invalid metadata key: %q", key)
+		}
+	}
+
+	switch cond.Op {
+	case ">", ">=", "<", "<=":
+		n, err := strconv.ParseFloat(cond.Value, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return metadataCondition{}, fmt.Errorf("metadata filter %s requires a numeric value", cond.Op)
+		}
+	}
+
+	return cond, nil
+}
+
+// jsonPath renders the condition's key path as a jsonpath expression.
+func (c metadataCondition) jsonPath() string {
+	path := "$"
+	for _, key := range c.Path {
+		path += `."` + key + `"`
+	}
+	return path
+}
+
+// containment builds the nested JSON document {"a":{"b":value}} for @>.
+func (c metadataCondition) containment(value any) string {
+	var doc any = value
+	for i := len(c.Path) - 1; i >= 0; i-- {
+		doc = map[string]any{c.Path[i]: doc}
+	}
+	data, _ := json.Marshal(doc)
+	return string(data)
+}
+
+// candidateValues returns the JSON values an equality filter should match:
+// the raw string plus its number or boolean interpretation.
+func (c metadataCondition) candidateValues() []any {
+	values := []any{c.Value}
+	if n, err := strconv.ParseFloat(c.Value, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+		values = append(values, n)
+	} else if b, err := strconv.ParseBool(c.Value); err == nil && (c.Value == "true" || c.Value == "false") {
+		values = append(values, b)
+	}
+	return values
+}
+
+// whereClause renders the condition against the JSONB column as SQL starting
+// at placeholder $paramCount and returns the condition, its parameters and
+// the next free placeholder number.
+func (c metadataCondition) whereClause(column string, paramCount int) (string, []any, int) {
+	var params []any
+
+	if c.Value == "*" && (c.Op == "=" || c.Op == "!=") {
+		clause := fmt.Sprintf("%s @? $%d::jsonpath", column, paramCount)
+		if c.Op == "!=" {
+			clause = "NOT (" + clause + ")"
+		}
+		return " AND " + clause, []any{c.jsonPath()}, paramCount + 1
+	}
+
+	switch c.Op {
+	case "=", "!=":
+		var matches []string
+		for _, value := range c.candidateValues() {
+			matches = append(matches, fmt.Sprintf("%s @> $%d::jsonb", column, paramCount))
+			params = append(params, c.containment(value))
+			paramCount++
+		}
+		clause := "(" + strings.Join(matches, " OR ") + ")"
+		if c.Op == "!=" {
+			clause = "NOT " + clause
+		}
+		return " AND " + clause, params, paramCount
+	default:
+		n, _ := strconv.ParseFloat(c.Value, 64)
+		predicate := c.jsonPath() + " " + c.Op + " " + strconv.FormatFloat(n, 'f', -1, 64)
+		return fmt.Sprintf(" AND %s @@ $%d::jsonpath", column, paramCount), []any{predicate}, paramCount + 1
+	}
+}
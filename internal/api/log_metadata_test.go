@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseMetadataFilters(t *testing.T) {
+	q, err := url.ParseQuery("meta.user_id=42&meta.http.status>=500&meta.latency<250&meta.error=*&service=svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conditions, err := parseMetadataFilters(q, metadataParamPrefix)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []metadataCondition{
+		{Path: []string{"error"}, Op: "=", Value: "*"},
+		{Path: []string{"http", "status"}, Op: ">=", Value: "500"},
+		{Path: []string{"latency"}, Op: "<", Value: "250"},
+		{Path: []string{"user_id"}, Op: "=", Value: "42"},
+	}
+	if !reflect.DeepEqual(conditions, want) {
+		t.Errorf("parseMetadataFilters = %+v, want %+v", conditions, want)
+	}
+
+	for _, raw := range []string{"meta.status>=high", "meta.=1", "meta.a'b=1", "meta.a..b=1", "meta.a!1"} {
+		q, _ := url.ParseQuery(raw)
+		if _, err := parseMetadataFilters(q, metadataParamPrefix); err == nil {
+			t.Errorf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestMetadataConditionWhereClause(t *testing.T) {
+	cases := []struct {
+		cond       metadataCondition
+		wantClause string
+		wantParams []any
+	}{
+		{
+			cond:       metadataCondition{Path: []string{"user_id"}, Op: "=", Value: "42"},
+			wantClause: " AND (metadata @> $1::jsonb OR metadata @> $2::jsonb)",
+			wantParams: []any{`{"user_id":"42"}`, `{"user_id":42}`},
+		},
+		{
+			cond:       metadataCondition{Path: []string{"customer"}, Op: "!=", Value: "acme"},
+			wantClause: " AND NOT (metadata @> $1::jsonb)",
+			wantParams: []any{`{"customer":"acme"}`},
+		},
+		{
+			cond:       metadataCondition{Path: []string{"http", "status"}, Op: ">=", Value: "500"},
+			wantClause: " AND metadata @@ $1::jsonpath",
+			wantParams: []any{`$."http"."status" >= 500`},
+		},
+		{
+			cond:       metadataCondition{Path: []string{"error"}, Op: "!=", Value: "*"},
+			wantClause: " AND NOT (metadata @? $1::jsonpath)",
+			wantParams: []any{`$."error"`},
+		},
+		{
+			cond:       metadataCondition{Path: []string{"retry"}, Op: "=", Value: "true"},
+			wantClause: " AND (metadata @> $1::jsonb OR metadata @> $2::jsonb)",
+			wantParams: []any{`{"retry":"true"}`, `{"retry":true}`},
+		},
+	}
+
+	for _, c := range cases {
+		clause, params, next := c.cond.whereClause("metadata", 1)
+		if clause != c.wantClause {
+			t.Errorf("clause = %q, want %q", clause, c.wantClause)
+		}
+		if !reflect.DeepEqual(params, c.wantParams) {
+			t.Errorf("params = %v, want %v", params, c.wantParams)
+		}
+		if next != 1+len(c.wantParams) {
+			t.Errorf("next placeholder = %d, want %d", next, 1+len(c.wantParams))
+		}
+	}
+}
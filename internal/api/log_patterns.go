@@ -0,0 +1,319 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/patterns"
+)
+
+// logPatternMiner clusters ingested log messages. Its counts are persisted
+// by LogPatternsJob.
+var logPatternMiner = patterns.NewMiner(patterns.DefaultConfig())
+
+const (
+	// logPatternSeedWindow is how far back templates are loaded into the
+	// miner on startup.
+	logPatternSeedWindow = 7 * 24 * time.Hour
+	logPatternSeedLimit  = 10000
+)
+
+// logPatternSorts maps the sort values accepted by the patterns endpoint to
+// their ORDER BY clauses.
+var logPatternSorts = map[string]string{
+	"count": "c.count DESC",
+	"new":   "p.first_seen DESC",
+	"spike": "(c.count + 1)::float / (COALESCE(pr.count, 0) + 1) DESC",
+}
+
+// mineLogPatterns feeds stored logs to the pattern miner.
+func mineLogPatterns(entries []models.Log) {
+	for _, entry := range entries {
+		logPatternMiner.Add(entry.ServiceName, entry.Message, entry.Timestamp)
+	}
+}
+
+// LogPatternsJob persists the template counts accumulated by the miner. Its
+// first run loads recently seen templates so that clusters survive restarts.
+func LogPatternsJob(interval time.Duration) jobs.Job {
+	seeded := false
+
+	return jobs.Job{
+		Name:     "log-patterns",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			if !seeded {
+				if err := seedLogPatterns(ctx, logPatternMiner); err != nil {
+					return fmt.Errorf("seeding log patterns: %w", err)
+				}
+				seeded = true
+			}
+			return flushLogPatterns(ctx, logPatternMiner)
+		},
+	}
+}
+
+func seedLogPatterns(ctx context.Context, miner *patterns.Miner) error {
+	rows, err := db.DB.QueryContext(ctx, `SELECT service_name, template FROM log_patterns
+              WHERE last_seen >= $1 ORDER BY count DESC LIMIT $2`,
+		time.Now().Add(-logPatternSeedWindow), logPatternSeedLimit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var service, template string
+		if err := rows.Scan(&service, &template); err != nil {
+			return err
+		}
+		miner.Seed(service, template)
+	}
+
+	return rows.Err()
+}
+
+// flushLogPatterns writes the miner's counts in one transaction, handing
+// them back to the miner if the write fails.
+func flushLogPatterns(ctx context.Context, miner *patterns.Miner) (err error) {
+	counts := miner.TakeCounts()
+	if len(counts) == 0 {
+		return nil
+	}
+
+	defer func() {
+		if err != nil {
+			miner.Restore(counts)
+		}
+	}()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range counts {
+		var patternID int
+		err := tx.QueryRowContext(ctx, `INSERT INTO log_patterns
+                    (service_name, template, sample, first_seen, last_seen, count)
+                  VALUES ($1, $2, $3, $4, $4, $5)
+                  ON CONFLICT (service_name, md5(template)) DO UPDATE SET
+                    first_seen = LEAST(log_patterns.first_seen, EXCLUDED.first_seen),
+                    last_seen = GREATEST(log_patterns.last_seen, EXCLUDED.last_seen),
+                    count = log_patterns.count + EXCLUDED.count
+                  RETURNING id`,
+			c.Service, c.Template, c.Sample, c.Bucket, c.Count).Scan(&patternID)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO log_pattern_counts (pattern_id, bucket, count)
+                  VALUES ($1, $2, $3)
+                  ON CONFLICT (pattern_id, bucket) DO UPDATE SET
+                    count = log_pattern_counts.count + EXCLUDED.count`,
+			patternID, c.Bucket, c.Count)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if c.Previous != "" {
+			if err := mergeLogPattern(ctx, tx, c.Service, c.Previous, patternID); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// mergeLogPattern folds the stored pattern with template from into the
+// pattern targetID after its cluster was generalized, so that its history
+// carries over instead of the generalized template appearing as new.
+func mergeLogPattern(ctx context.Context, tx *sql.Tx, service, from string, targetID int) error {
+	var sourceID int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM log_patterns
+              WHERE service_name = $1 AND md5(template) = md5($2) AND id <> $3`,
+		service, from, targetID).Scan(&sourceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE log_patterns p SET
+                first_seen = LEAST(p.first_seen, s.first_seen),
+                last_seen = GREATEST(p.last_seen, s.last_seen),
+                count = p.count + s.count
+              FROM log_patterns s
+              WHERE p.id = $1 AND s.id = $2`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO log_pattern_counts (pattern_id, bucket, count)
+              SELECT $1, bucket, count FROM log_pattern_counts WHERE pattern_id = $2
+              ON CONFLICT (pattern_id, bucket) DO UPDATE SET
+                count = log_pattern_counts.count + EXCLUDED.count`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM log_patterns WHERE id = $1", sourceID)
+	return err
+}
+
+// LogPatternFilter selects the window and service for pattern queries.
+type LogPatternFilter struct {
+	Service   string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func parseLogPatternFilter(r *http.Request, now time.Time) (LogPatternFilter, error) {
+	q := r.URL.Query()
+	filter := LogPatternFilter{
+		Service: q.Get("service"),
+		EndTime: now,
+	}
+
+	if raw := q.Get("end_time"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end_time: %s", raw)
+		}
+		filter.EndTime = parsed
+	}
+
+	filter.StartTime = filter.EndTime.Add(-defaultAggregateWindow)
+	if raw := q.Get("start_time"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start_time: %s", raw)
+		}
+		filter.StartTime = parsed
+	}
+
+	if !filter.StartTime.Before(filter.EndTime) {
+		return filter, errors.New("start_time must be before end_time")
+	}
+
+	return filter, nil
+}
+
+// buildLogPatternQuery sums template counts over the filter window and the
+// equally long window before it.
+func buildLogPatternQuery(filter LogPatternFilter, sortBy string, limit int) (string, []any) {
+	previousStart := filter.StartTime.Add(-filter.EndTime.Sub(filter.StartTime))
+	params := []any{filter.StartTime, filter.EndTime, previousStart}
+
+	query := `WITH current AS (
+                  SELECT pattern_id, SUM(count) AS count FROM log_pattern_counts
+                  WHERE bucket >= $1 AND bucket < $2 GROUP BY pattern_id
+              ), previous AS (
+                  SELECT pattern_id, SUM(count) AS count FROM log_pattern_counts
+                  WHERE bucket >= $3 AND bucket < $1 GROUP BY pattern_id
+              )
+              SELECT p.id, p.service_name, p.template, p.sample, p.first_seen, p.last_seen,
+                     c.count, COALESCE(pr.count, 0)
+              FROM current c
+              JOIN log_patterns p ON p.id = c.pattern_id
+              LEFT JOIN previous pr ON pr.pattern_id = c.pattern_id
+              WHERE 1=1`
+
+	paramCount := 4
+	if filter.Service != "" {
+		query += fmt.Sprintf(" AND p.service_name = $%d", paramCount)
+		params = append(params, filter.Service)
+		paramCount++
+	}
+
+	query += " ORDER BY " + logPatternSorts[sortBy] + ", p.id"
+	query += fmt.Sprintf(" LIMIT $%d", paramCount)
+	params = append(params, limit)
+
+	return query, params
+}
+
+// GetLogPatterns returns the templates seen in the filter window. sortBy
+// must be a key of logPatternSorts.
+func GetLogPatterns(filter LogPatternFilter, sortBy string, limit int) ([]models.LogPattern, error) {
+	query, params := buildLogPatternQuery(filter, sortBy, limit)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching log patterns:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.LogPattern, 0)
+	for rows.Next() {
+		var pattern models.LogPattern
+		err := rows.Scan(
+			&pattern.ID,
+			&pattern.ServiceName,
+			&pattern.Template,
+			&pattern.Sample,
+			&pattern.FirstSeen,
+			&pattern.LastSeen,
+			&pattern.Count,
+			&pattern.PreviousCount,
+		)
+		if err != nil {
+			log.Println("❌ Error scanning log pattern row:", err)
+			continue
+		}
+
+		pattern.Change = float64(pattern.Count+1) / float64(pattern.PreviousCount+1)
+		pattern.New = !pattern.FirstSeen.Before(filter.StartTime)
+		result = append(result, pattern)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func GetLogPatternsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLogPatternFilter(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy == "" {
+		sortBy = "count"
+	}
+	if _, ok := logPatternSorts[sortBy]; !ok {
+		http.Error(w, fmt.Sprintf("invalid sort: %s", sortBy), http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := parseLimitOffset(r)
+
+	result, err := GetLogPatterns(filter, sortBy, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch log patterns", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
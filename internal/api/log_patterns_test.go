@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogPatternFilterDefaults(t *testing.T) {
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+	req := httptest.NewRequest(http.MethodGet, "/logs/patterns?service=api", nil)
+
+	filter, err := parseLogPatternFilter(req, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Service != "api" || !filter.EndTime.Equal(now) || !filter.StartTime.Equal(now.Add(-time.Hour)) {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	for _, rawQuery := range []string{
+		"start_time=yesterday",
+		"start_time=2025-05-26T13:00:00Z&end_time=2025-05-26T12:00:00Z",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/logs/patterns?"+rawQuery, nil)
+		if _, err := parseLogPatternFilter(req, now); err == nil {
+			t.Errorf("expected error for %q", rawQuery)
+		}
+	}
+}
+
+func TestBuildLogPatternQuery(t *testing.T) {
+	start := time.Date(2025, 5, 26, 11, 0, 0, 0, time.UTC)
+	filter := LogPatternFilter{Service: "api", StartTime: start, EndTime: start.Add(time.Hour)}
+
+	query, params := buildLogPatternQuery(filter, "spike", 10)
+
+	for _, want := range []string{"p.service_name = $4", "LIMIT $5", "COALESCE(pr.count, 0) + 1) DESC"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+	if len(params) != 5 {
+		t.Fatalf("expected 5 params, got %v", params)
+	}
+	if previous := params[2].(time.Time); !previous.Equal(start.Add(-time.Hour)) {
+		t.Errorf("previous window starts at %v", previous)
+	}
+}
+
+func TestGetLogPatternsHandlerRejectsUnknownSort(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/logs/patterns?sort=name", nil)
+	rec := httptest.NewRecorder()
+
+	GetLogPatternsHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Log search queries (the q parameter of GET /logs) support:
+//
+//	timeout database        both terms (implicit AND)
+//	timeout AND database    both terms
+//	timeout OR refused      either term
+//	NOT debug, -debug       exclude a term
+//	"connection refused"    exact phrase
+//	conn*                   prefix match
+//	(a OR b) AND c          grouping
+//
+// parseSearchQuery compiles such a query into PostgreSQL to_tsquery syntax.
+// Terms and phrases are emitted as quoted strings, which to_tsquery splits
+// with the same parser used to build logs.message_tsv, so "user-42" matches
+// exactly what was indexed and user input can never inject tsquery operators.
+
+type searchTokenKind int
+
+const (
+	searchTerm searchTokenKind = iota
+	searchPhrase
+	searchAnd
+	searchOr
+	searchNot
+	searchLParen
+	searchRParen
+)
+
+type searchToken struct {
+	kind   searchTokenKind
+	text   string
+	value  string
+	prefix bool
+}
+
+var errEmptySearch = errors.New("search query is empty")
+
+// parseSearchQuery converts a user search query into a to_tsquery expression.
+func parseSearchQuery(raw string) (string, error) {
+	tokens, err := tokenizeSearch(raw)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", errEmptySearch
+	}
+
+	p := &searchParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return "", err
+	}
+	if p.pos < len(p.tokens) {
+		return "", fmt.Errorf("unexpected %q in search query", p.tokens[p.pos].text)
+	}
+
+	return expr, nil
+}
+
+func tokenizeSearch(raw string) ([]searchToken, error) {
+	var tokens []searchToken
+	runes := []rune(raw)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, searchToken{kind: searchLParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, searchToken{kind: searchRParen, text: ")"})
+			i++
+		case r == '-':
+			tokens = append(tokens, searchToken{kind: searchNot, text: "-"})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.New("unterminated phrase in search query")
+			}
+			phrase := string(runes[i+1 : end])
+			if hasWordChar(phrase) {
+				tokens = append(tokens, searchToken{kind: searchPhrase, text: string(runes[i : end+1]), value: phrase})
+			}
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"`, runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			i = end
+
+			switch word {
+			case "AND":
+				tokens = append(tokens, searchToken{kind: searchAnd, text: word})
+				continue
+			case "OR":
+				tokens = append(tokens, searchToken{kind: searchOr, text: word})
+				continue
+			case "NOT":
+				tokens = append(tokens, searchToken{kind: searchNot, text: word})
+				continue
+			}
+
+			value := strings.TrimRight(word, "*")
+			if !hasWordChar(value) {
+				continue
+			}
+			tokens = append(tokens, searchToken{kind: searchTerm, text: word, value: value, prefix: value != word})
+		}
+	}
+
+	return tokens, nil
+}
+
+// hasWordChar reports whether text contains anything the text search parser
+// would index; punctuation-only terms are dropped.
+func hasWordChar(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+}
+
+func (p *searchParser) peek() *searchToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *searchParser) parseOr() (string, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return "", err
+	}
+
+	for tok := p.peek(); tok != nil && tok.kind == searchOr; tok = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " | " + right + ")"
+	}
+
+	return left, nil
+}
+
+func (p *searchParser) parseAnd() (string, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return "", err
+	}
+
+	for tok := p.peek(); tok != nil && tok.kind != searchOr && tok.kind != searchRParen; tok = p.peek() {
+		if tok.kind == searchAnd {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return "", err
+		}
+		left = left + " & " + right
+	}
+
+	return left, nil
+}
+
+func (p *searchParser) parseUnary() (string, error) {
+	tok := p.peek()
+	if tok == nil {
+		return "", errors.New("search query ends with an operator")
+	}
+
+	switch tok.kind {
+	case searchNot:
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return "", err
+		}
+		return "!" + operand, nil
+	case searchLParen:
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if next := p.peek(); next == nil || next.kind != searchRParen {
+			return "", errors.New("missing closing parenthesis in search query")
+		}
+		p.pos++
+		return "(" + inner + ")", nil
+	case searchTerm, searchPhrase:
+		p.pos++
+		return quoteLexeme(tok.value, tok.prefix), nil
+	default:
+		return "", fmt.Errorf("unexpected %q in search query", tok.text)
+	}
+}
+
+// quoteLexeme quotes text for to_tsquery. Multi-word text becomes a phrase
+// of adjacent lexemes; with prefix set the last lexeme matches as a prefix.
+func quoteLexeme(text string, prefix bool) string {
+	quoted := "'" + tsqueryEscaper.Replace(text) + "'"
+	if prefix {
+		quoted += ":*"
+	}
+	return quoted
+}
+
+var tsqueryEscaper = strings.NewReplacer(`\`, `\\`, "'", "''")
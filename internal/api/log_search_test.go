@@ -0,0 +1,45 @@
+package api
+
+import "testing"
+
+func TestParseSearchQuery(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "timeout", want: `'timeout'`},
+		{raw: "timeout database", want: `'timeout' & 'database'`},
+		{raw: "timeout AND database", want: `'timeout' & 'database'`},
+		{raw: "timeout OR refused", want: `('timeout' | 'refused')`},
+		{raw: "error NOT debug", want: `'error' & !'debug'`},
+		{raw: "error -debug", want: `'error' & !'debug'`},
+		{raw: `"connection refused"`, want: `'connection refused'`},
+		{raw: "conn*", want: `'conn':*`},
+		{raw: "(db OR cache) AND timeout", want: `(('db' | 'cache')) & 'timeout'`},
+		{raw: "user-42", want: `'user-42'`},
+		{raw: "-user-42", want: `!'user-42'`},
+		{raw: "timeout ... db", want: `'timeout' & 'db'`},
+		{raw: "it's", want: `'it''s'`},
+		{raw: `a\b`, want: `'a\\b'`},
+		{raw: "'x' & y", want: `'''x''' & 'y'`},
+	}
+
+	for _, c := range cases {
+		got, err := parseSearchQuery(c.raw)
+		if err != nil {
+			t.Errorf("parseSearchQuery(%q) unexpected error: %v", c.raw, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseSearchQuery(%q) = %q, want %q", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	for _, raw := range []string{"", "   ", `"unterminated`, "(timeout", "timeout)", "timeout OR", "NOT", "AND timeout"} {
+		if got, err := parseSearchQuery(raw); err == nil {
+			t.Errorf("parseSearchQuery(%q) expected error, got %q", raw, got)
+		}
+	}
+}
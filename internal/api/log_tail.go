@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/stream"
+)
+
+// logHub fans newly stored logs out to live tail clients.
+var logHub = stream.NewHub[models.Log]()
+
+// publishLogs hands stored logs to the live tail clients.
+func publishLogs(entries []models.Log) {
+	for _, entry := range entries {
+		logHub.Publish(entry)
+	}
+}
+
+// matches reports whether entry satisfies the service, level and message
+// filters, mirroring the SQL conditions of whereClause. Other fields are
+// ignored.
+func (f LogFilter) matches(entry models.Log) bool {
+	if f.ServiceName != "" && entry.ServiceName != f.ServiceName {
+		return false
+	}
+	if f.LogLevel != "" && entry.LogLevel != f.LogLevel {
+		return false
+	}
+	if f.MessageContains != "" &&
+		!strings.Contains(strings.ToLower(entry.Message), strings.ToLower(f.MessageContains)) {
+		return false
+	}
+	return true
+}
+
+// TailLogsHandler streams logs as they are ingested, filtered by service,
+// level and message like GET /logs. Clients that upgrade the connection get
+// a WebSocket; all others get Server-Sent Events.
+func TailLogsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := LogFilter{
+		ServiceName:     q.Get("service"),
+		LogLevel:        q.Get("level"),
+		MessageContains: q.Get("message"),
+	}
+
+	sub := logHub.Subscribe(streamBufferSize, filter.matches)
+	serveStream(w, r, sub, "log")
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+func TestLogFilterMatches(t *testing.T) {
+	entry := models.Log{ServiceName: "api", LogLevel: "ERROR", Message: "Connection Timeout"}
+
+	cases := []struct {
+		filter LogFilter
+		want   bool
+	}{
+		{LogFilter{}, true},
+		{LogFilter{ServiceName: "api", LogLevel: "ERROR"}, true},
+		{LogFilter{MessageContains: "timeout"}, true},
+		{LogFilter{ServiceName: "web"}, false},
+		{LogFilter{LogLevel: "INFO"}, false},
+		{LogFilter{MessageContains: "refused"}, false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.filter.matches(entry); got != tc.want {
+			t.Errorf("%+v: expected %v, got %v", tc.filter, tc.want, got)
+		}
+	}
+}
+
+func TestTailLogsHandlerServerSentEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(TailLogsHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?service=api")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	waitForSubscribers(t, logHub, 1)
+	publishLogs([]models.Log{
+		{ID: 1, ServiceName: "web", Message: "skipped"},
+		{ID: 2, ServiceName: "api", Message: "delivered"},
+	})
+
+	reader := bufio.NewReader(resp.Body)
+	var event, data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+
+	var entry models.Log
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		t.Fatalf("invalid event data %q: %v", data, err)
+	}
+	if event != "log" || entry.ID != 2 {
+		t.Errorf("unexpected event %q with log %+v", event, entry)
+	}
+}
+
+func TestTailLogsHandlerWebSocket(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(TailLogsHandler))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?level=ERROR"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	waitForSubscribers(t, logHub, 1)
+	publishLogs([]models.Log{
+		{ID: 1, ServiceName: "api", LogLevel: "INFO", Message: "skipped"},
+		{ID: 2, ServiceName: "api", LogLevel: "ERROR", Message: "delivered"},
+	})
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg struct {
+		Event string     `json:"event"`
+		Data  models.Log `json:"data"`
+	}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	if msg.Event != "log" || msg.Data.ID != 2 {
+		t.Errorf("unexpected message %+v", msg)
+	}
+}
+
+// waitForSubscribers waits until the handler under test has subscribed to
+// the hub, so that published events are not missed.
+func waitForSubscribers(t *testing.T, hub interface{ Len() int }, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for hub.Len() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d subscribers, got %d", n, hub.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,51 +16,157 @@ import (
 	"github.com/NathanSanchezDev/go-insight/internal/models"
 )
 
-func GetLogs(serviceName, logLevel, messageContains string, startTime, endTime time.Time, limit, offset int) ([]models.Log, error) {
-	query := `SELECT id, service_name, log_level, message, timestamp, trace_id, span_id, metadata 
-              FROM logs WHERE 1=1`
+// LogFilter holds the optional filters for log queries.
+type LogFilter struct {
+	ServiceName     string
+	LogLevel        string
+	MessageContains string
+	StartTime       time.Time
+	EndTime         time.Time
+	Metadata        []metadataCondition
+
+	// Search is a compiled to_tsquery expression, see parseSearchQuery.
+	Search string
+	// RankBySearch orders results by search relevance instead of time.
+	RankBySearch bool
+}
+
+// Accepted values for the sort query parameter.
+const (
+	LogSortTime      = "time"
+	LogSortRelevance = "relevance"
+)
 
+func (f LogFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
 	var params []any
-	paramCount := 1
 
-	if serviceName != "" {
-		query += fmt.Sprintf(" AND service_name = $%d", paramCount)
-		params = append(params, serviceName)
+	if f.ServiceName != "" {
+		clause += fmt.Sprintf(" AND service_name = $%d", paramCount)
+		params = append(params, f.ServiceName)
 		paramCount++
 	}
 
-	if logLevel != "" {
-		query += fmt.Sprintf(" AND log_level = $%d", paramCount)
-		params = append(params, logLevel)
+	if f.LogLevel != "" {
+		clause += fmt.Sprintf(" AND log_level = $%d", paramCount)
+		params = append(params, f.LogLevel)
 		paramCount++
 	}
 
-	if messageContains != "" {
-		query += fmt.Sprintf(" AND message ILIKE $%d", paramCount)
-		params = append(params, "%"+messageContains+"%")
+	if f.MessageContains != "" {
+		clause += fmt.Sprintf(" AND message ILIKE $%d", paramCount)
+		params = append(params, "%"+f.MessageContains+"%")
+		paramCount++
+	}
+
+	for _, cond := range f.Metadata {
+		var condParams []any
+		var condClause string
+		condClause, condParams, paramCount = cond.whereClause("metadata", paramCount)
+		clause += condClause
+		params = append(params, condParams...)
+	}
+
+	if f.Search != "" {
+		clause += fmt.Sprintf(" AND message_tsv @@ to_tsquery('simple', $%d)", paramCount)
+		params = append(params, f.Search)
 		paramCount++
 	}
 
-	if !startTime.IsZero() {
-		query += fmt.Sprintf(" AND timestamp >= $%d", paramCount)
-		params = append(params, startTime)
+	if !f.StartTime.IsZero() {
+		clause += fmt.Sprintf(" AND timestamp >= $%d", paramCount)
+		params = append(params, f.StartTime)
 		paramCount++
 	}
 
-	if !endTime.IsZero() {
-		query += fmt.Sprintf(" AND timestamp <= $%d", paramCount)
-		params = append(params, endTime)
+	if !f.EndTime.IsZero() {
+		clause += fmt.Sprintf(" AND timestamp <= $%d", paramCount)
+		params = append(params, f.EndTime)
 		paramCount++
 	}
 
-	query += " ORDER BY timestamp DESC"
+	return clause, params, paramCount
+}
+
+// parseLogFilter reads the log filters from the query string. Malformed
+// times are ignored; an invalid search query, metadata filter or sort order
+// is an error.
+func parseLogFilter(r *http.Request) (LogFilter, error) {
+	q := r.URL.Query()
+	filter := LogFilter{
+		ServiceName:     q.Get("service"),
+		LogLevel:        q.Get("level"),
+		MessageContains: q.Get("message"),
+	}
+
+	metadata, err := parseMetadataFilters(q, metadataParamPrefix)
+	if err != nil {
+		return filter, err
+	}
+	filter.Metadata = metadata
+
+	if raw := q.Get("q"); raw != "" {
+		search, err := parseSearchQuery(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.Search = search
+	}
+
+	switch q.Get("sort") {
+	case "", LogSortTime:
+	case LogSortRelevance:
+		if filter.Search == "" {
+			return filter, errors.New("sort=relevance requires a search query (q)")
+		}
+		filter.RankBySearch = true
+	default:
+		return filter, fmt.Errorf("invalid sort: %s", q.Get("sort"))
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, q.Get("start_time")); err == nil {
+		filter.StartTime = parsed
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, q.Get("end_time")); err == nil {
+		filter.EndTime = parsed
+	}
+
+	return filter, nil
+}
+
+// GetLogs returns logs matching the filter, newest first or by relevance when
+// filter.RankBySearch is set. When cursor is set only logs strictly after the
+// cursor position are returned and offset is ignored; cursors cannot be
+// combined with relevance ordering.
+func GetLogs(filter LogFilter, cursor *pageCursor, limit, offset int) ([]models.Log, error) {
+	query := `SELECT id, service_name, log_level, message, timestamp, trace_id, span_id, metadata 
+              FROM logs WHERE 1=1`
+
+	where, params, paramCount := filter.whereClause(1)
+	query += where
+
+	if cursor != nil {
+		query += fmt.Sprintf(" AND (timestamp, id) < ($%d, $%d)", paramCount, paramCount+1)
+		id, _ := strconv.Atoi(cursor.ID)
+		params = append(params, cursor.Timestamp, id)
+		paramCount += 2
+	}
+
+	if filter.RankBySearch {
+		query += fmt.Sprintf(" ORDER BY ts_rank(message_tsv, to_tsquery('simple', $%d)) DESC, timestamp DESC, id DESC", paramCount)
+		params = append(params, filter.Search)
+		paramCount++
+	} else {
+		query += " ORDER BY timestamp DESC, id DESC"
+	}
 
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", paramCount)
 		params = append(params, limit)
 		paramCount++
 
-		if offset > 0 {
+		if offset > 0 && cursor == nil {
 			query += fmt.Sprintf(" OFFSET $%d", paramCount)
 			params = append(params, offset)
 		}
@@ -72,6 +179,12 @@ func GetLogs(serviceName, logLevel, messageContains string, startTime, endTime t
 	}
 	defer rows.Close()
 
+	return scanLogs(rows)
+}
+
+// scanLogs reads rows with the columns id, service_name, log_level, message,
+// timestamp, trace_id, span_id and metadata.
+func scanLogs(rows *sql.Rows) ([]models.Log, error) {
 	logs := make([]models.Log, 0)
 
 	for rows.Next() {
@@ -104,7 +217,7 @@ func GetLogs(serviceName, logLevel, messageContains string, startTime, endTime t
 		logs = append(logs, logEntry)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		log.Printf("❌ Row iteration error: %v", err)
 		return nil, err
 	}
@@ -196,45 +309,67 @@ func PostLog(logEntry *models.Log) error {
 		return err
 	}
 
+	afterLogsStored([]models.Log{*logEntry})
 	return nil
 }
 
+// afterLogsStored runs the ingest-time processing of newly stored logs.
+// Failures are logged and do not fail ingestion, since the logs themselves
+// are already saved.
+func afterLogsStored(entries []models.Log) {
+	if err := RecordErrorGroups(entries); err != nil {
+		log.Printf("⚠️ Error grouping failed: %v", err)
+	}
+	mineLogPatterns(entries)
+	publishLogs(entries)
+}
+
+// EstimateLogs returns the planner's row estimate for the filter.
+func EstimateLogs(filter LogFilter) (int64, error) {
+	where, params, _ := filter.whereClause(1)
+	return db.EstimateRows("SELECT 1 FROM logs WHERE 1=1"+where, params...)
+}
+
 func GetLogsHandler(w http.ResponseWriter, r *http.Request) {
-	serviceName := r.URL.Query().Get("service")
-	logLevel := r.URL.Query().Get("level")
-	messageContains := r.URL.Query().Get("message")
+	filter, err := parseLogFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	var startTime, endTime time.Time
+	limit, offset := parseLimitOffset(r)
 
-	if startTimeStr := r.URL.Query().Get("start_time"); startTimeStr != "" {
-		parsedTime, err := time.Parse(time.RFC3339, startTimeStr)
-		if err == nil {
-			startTime = parsedTime
+	if r.URL.Query().Has("cursor") {
+		if filter.RankBySearch {
+			http.Error(w, "cursor pagination is not supported with sort=relevance", http.StatusBadRequest)
+			return
 		}
-	}
 
-	if endTimeStr := r.URL.Query().Get("end_time"); endTimeStr != "" {
-		parsedTime, err := time.Parse(time.RFC3339, endTimeStr)
-		if err == nil {
-			endTime = parsedTime
+		cursor, err := decodeCursor(r.URL.Query().Get("cursor"), true)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-	}
 
-	limit := 100
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
+		logs, err := GetLogs(filter, cursor, limit+1, 0)
+		if err != nil {
+			log.Printf("❌ GetLogs failed: %v", err)
+			http.Error(w, "Failed to fetch logs", http.StatusInternalServerError)
+			return
 		}
-	}
 
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+		page := buildPage(logs, limit, func(l models.Log) pageCursor {
+			return pageCursor{Timestamp: l.Timestamp, ID: strconv.Itoa(l.ID)}
+		})
+		if page.TotalEstimate, err = EstimateLogs(filter); err != nil {
+			log.Printf("⚠️ Log count estimate failed: %v", err)
 		}
+
+		writePage(w, page)
+		return
 	}
 
-	logs, err := GetLogs(serviceName, logLevel, messageContains, startTime, endTime, limit, offset)
+	logs, err := GetLogs(filter, nil, limit, offset)
 	if err != nil {
 		log.Printf("❌ GetLogs failed: %v", err)
 		http.Error(w, "Failed to fetch logs", http.StatusInternalServerError)
@@ -272,6 +407,9 @@ func PostLogHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !checkIngestService(w, r, logEntry.ServiceName) {
+		return
+	}
 
 	sanitizeLogEntry(&logEntry)
 
@@ -343,7 +481,12 @@ func PostLogsBulk(logs []models.Log) error {
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	afterLogsStored(logs)
+	return nil
 }
 
 // postLogsBulkFunc allows tests to mock bulk insertion.
@@ -369,6 +512,9 @@ func PostLogsBulkHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if !checkIngestService(w, r, entries[i].ServiceName) {
+			return
+		}
 		sanitizeLogEntry(&entries[i])
 	}
 
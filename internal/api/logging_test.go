@@ -1,6 +1,9 @@
 package api
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/NathanSanchezDev/go-insight/internal/models"
@@ -27,3 +30,45 @@ func TestValidateLogEntry(t *testing.T) {
 		t.Errorf("expected error for bad log level")
 	}
 }
+
+func TestParseLogFilterSearch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, `/logs?q=%22connection+refused%22+OR+timeout&sort=relevance`, nil)
+	filter, err := parseLogFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Search != `('connection refused' | 'timeout')` || !filter.RankBySearch {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+
+	clause, params, _ := filter.whereClause(1)
+	if !strings.Contains(clause, "message_tsv @@ to_tsquery('simple', $1)") || len(params) != 1 {
+		t.Errorf("unexpected clause %q with params %v", clause, params)
+	}
+
+	for _, rawQuery := range []string{"sort=relevance", "q=timeout&sort=random", "q=(timeout"} {
+		req := httptest.NewRequest(http.MethodGet, "/logs?"+rawQuery, nil)
+		if _, err := parseLogFilter(req); err == nil {
+			t.Errorf("expected error for %q", rawQuery)
+		}
+	}
+}
+
+func TestLogFilterWhereClauseMetadata(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/logs?service=svc&meta.user_id=42&meta.http.status%3E%3D500", nil)
+	filter, err := parseLogFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clause, params, next := filter.whereClause(1)
+	want := " AND service_name = $1" +
+		" AND metadata @@ $2::jsonpath" +
+		" AND (metadata @> $3::jsonb OR metadata @> $4::jsonb)"
+	if clause != want {
+		t.Errorf("clause = %q, want %q", clause, want)
+	}
+	if len(params) != 4 || next != 5 {
+		t.Errorf("unexpected params %v, next %d", params, next)
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/logql"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+// RunLogQuery parses and executes a LogQL-style query.
+func RunLogQuery(query string, opts logql.Options) (*models.LogQueryResult, error) {
+	expr, err := logql.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := logql.NewPlan(expr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.QueryContext(context.Background(), plan.SQL, plan.Params...)
+	if err != nil {
+		log.Println("❌ Error running log query:", err)
+		return nil, fmt.Errorf("%w: %w", errLogQueryFailed, err)
+	}
+	defer rows.Close()
+
+	if !plan.Metric {
+		logs, err := scanLogs(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errLogQueryFailed, err)
+		}
+		return &models.LogQueryResult{Type: "logs", Logs: logs}, nil
+	}
+
+	series, err := scanLogSeries(rows, plan.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errLogQueryFailed, err)
+	}
+	return &models.LogQueryResult{Type: "series", Series: series}, nil
+}
+
+// errLogQueryFailed distinguishes database failures from invalid queries. It
+// wraps the database error, which may still be a regex PostgreSQL rejected.
+var errLogQueryFailed = errors.New("log query failed")
+
+// scanLogSeries groups rows of (timestamp, labels..., value), ordered by
+// labels then time, into one series per label set.
+func scanLogSeries(rows *sql.Rows, labels []string) ([]models.LogSeries, error) {
+	series := make([]models.LogSeries, 0)
+	lastKey := ""
+
+	for rows.Next() {
+		var point models.LogSeriesPoint
+		values := make([]string, len(labels))
+
+		dest := []any{&point.Timestamp}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		dest = append(dest, &point.Value)
+
+		if err := rows.Scan(dest...); err != nil {
+			log.Println("❌ Error scanning log series row:", err)
+			continue
+		}
+
+		key := strings.Join(values, "\x00")
+		if len(series) == 0 || key != lastKey {
+			labelSet := make(map[string]string, len(labels))
+			for i, label := range labels {
+				labelSet[label] = values[i]
+			}
+			series = append(series, models.LogSeries{Labels: labelSet})
+			lastKey = key
+		}
+
+		current := &series[len(series)-1]
+		current.Points = append(current.Points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// parseLogQueryOptions reads the time range, step and limit for a log query,
+// defaulting to the last hour.
+func parseLogQueryOptions(r *http.Request, now time.Time) (logql.Options, error) {
+	step, err := parseStep(r.URL.Query().Get("step"))
+	if err != nil {
+		return logql.Options{}, err
+	}
+
+	limit, _ := parseLimitOffset(r)
+	opts := logql.Options{Step: step, Limit: limit, End: now}
+
+	if parsed, err := time.Parse(time.RFC3339, r.URL.Query().Get("end_time")); err == nil {
+		opts.End = parsed
+	}
+
+	opts.Start = opts.End.Add(-defaultAggregateWindow)
+	if parsed, err := time.Parse(time.RFC3339, r.URL.Query().Get("start_time")); err == nil {
+		opts.Start = parsed
+	}
+
+	if !opts.Start.Before(opts.End) {
+		return logql.Options{}, errors.New("start_time must be before end_time")
+	}
+
+	if opts.End.Sub(opts.Start)/step > maxSeriesBuckets {
+		return logql.Options{}, fmt.Errorf("time range too large for step, at most %d buckets allowed", maxSeriesBuckets)
+	}
+
+	return opts, nil
+}
+
+// runLogQueryFunc allows tests to stub query execution.
+var runLogQueryFunc = RunLogQuery
+
+func GetLogsQueryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseLogQueryOptions(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := runLogQueryFunc(query, opts)
+	if isInvalidRegex(err) {
+		http.Error(w, "invalid regex: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, errLogQueryFailed) {
+		http.Error(w, "Failed to run log query", http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
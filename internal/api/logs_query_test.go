@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/logql"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestParseLogQueryOptions(t *testing.T) {
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+
+	opts, err := parseLogQueryOptions(httptest.NewRequest(http.MethodGet, "/logs/query?step=5m&limit=20", nil), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.End.Equal(now) || !opts.Start.Equal(now.Add(-time.Hour)) || opts.Step != 5*time.Minute || opts.Limit != 20 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, rawQuery := range []string{
+		"step=1ms",
+		"start_time=2025-05-26T13:00:00Z",
+		"start_time=2025-05-01T00:00:00Z&step=1s",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/logs/query?"+rawQuery, nil)
+		if _, err := parseLogQueryOptions(req, now); err == nil {
+			t.Errorf("expected error for %q", rawQuery)
+		}
+	}
+}
+
+func TestGetLogsQueryHandler(t *testing.T) {
+	defer func() { runLogQueryFunc = RunLogQuery }()
+
+	runLogQueryFunc = func(query string, opts logql.Options) (*models.LogQueryResult, error) {
+		return &models.LogQueryResult{Type: "logs"}, nil
+	}
+
+	rr := httptest.NewRecorder()
+	GetLogsQueryHandler(rr, httptest.NewRequest(http.MethodGet, `/logs/query?query={service="api"}`, nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	GetLogsQueryHandler(rr, httptest.NewRequest(http.MethodGet, "/logs/query", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing query, got %d", rr.Code)
+	}
+
+	runLogQueryFunc = func(query string, opts logql.Options) (*models.LogQueryResult, error) {
+		return nil, errors.New("parse error at position 0: expected '{'")
+	}
+	rr = httptest.NewRecorder()
+	GetLogsQueryHandler(rr, httptest.NewRequest(http.MethodGet, "/logs/query?query=oops", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid query, got %d", rr.Code)
+	}
+
+	runLogQueryFunc = func(query string, opts logql.Options) (*models.LogQueryResult, error) {
+		return nil, errLogQueryFailed
+	}
+	rr = httptest.NewRecorder()
+	GetLogsQueryHandler(rr, httptest.NewRequest(http.MethodGet, `/logs/query?query={service="api"}`, nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for database failure, got %d", rr.Code)
+	}
+
+	// PostgreSQL rejects some regexes that Go accepts.
+	runLogQueryFunc = func(query string, opts logql.Options) (*models.LogQueryResult, error) {
+		return nil, fmt.Errorf("%w: %w", errLogQueryFailed, &pgconn.PgError{Code: "2201B", Message: "invalid regular expression"})
+	}
+	rr = httptest.NewRecorder()
+	GetLogsQueryHandler(rr, httptest.NewRequest(http.MethodGet, `/logs/query?query={service="api"}|~"(?P<id>x)"`, nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a regex PostgreSQL rejects, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/stream"
+)
+
+// metricHub fans per-second metric summaries out to live clients.
+var metricHub = stream.NewHub[models.MetricTick]()
+
+var liveMetrics = newMetricTickAggregator()
+
+type metricTickKey struct {
+	service string
+	second  time.Time
+}
+
+// metricTickAggregator sums metrics per service and second of arrival until
+// the second is over.
+type metricTickAggregator struct {
+	mu    sync.Mutex
+	ticks map[metricTickKey]*models.MetricTick
+}
+
+func newMetricTickAggregator() *metricTickAggregator {
+	return &metricTickAggregator{ticks: map[metricTickKey]*models.MetricTick{}}
+}
+
+func (a *metricTickAggregator) add(metric models.EndpointMetric, at time.Time) {
+	key := metricTickKey{service: metric.ServiceName, second: at.Truncate(time.Second)}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tick, ok := a.ticks[key]
+	if !ok {
+		tick = &models.MetricTick{ServiceName: key.service, Timestamp: key.second}
+		a.ticks[key] = tick
+	}
+
+	tick.Count++
+	if metric.StatusCode >= 500 {
+		tick.ErrorCount++
+	}
+	// AvgMs holds the running sum until the tick is flushed.
+	tick.AvgMs += metric.Duration
+	if metric.Duration > tick.MaxMs {
+		tick.MaxMs = metric.Duration
+	}
+}
+
+// flush removes and returns the ticks of seconds before the given time,
+// ordered by second and service.
+func (a *metricTickAggregator) flush(before time.Time) []models.MetricTick {
+	a.mu.Lock()
+	var done []models.MetricTick
+	for key, tick := range a.ticks {
+		if key.second.Before(before) {
+			done = append(done, *tick)
+			delete(a.ticks, key)
+		}
+	}
+	a.mu.Unlock()
+
+	for i := range done {
+		done[i].AvgMs /= float64(done[i].Count)
+		done[i].ErrorRate = float64(done[i].ErrorCount) / float64(done[i].Count)
+	}
+
+	sort.Slice(done, func(i, j int) bool {
+		if !done[i].Timestamp.Equal(done[j].Timestamp) {
+			return done[i].Timestamp.Before(done[j].Timestamp)
+		}
+		return done[i].ServiceName < done[j].ServiceName
+	})
+
+	return done
+}
+
+// recordLiveMetric counts a stored metric towards the current second. Nothing
+// is kept while no client is streaming metrics.
+func recordLiveMetric(metric models.EndpointMetric) {
+	if metricHub.Len() == 0 {
+		return
+	}
+	liveMetrics.add(metric, time.Now())
+}
+
+// LiveMetricsJob publishes the per-second metric summaries of completed
+// seconds to streaming clients.
+func LiveMetricsJob() jobs.Job {
+	return jobs.Job{
+		Name:     "live-metrics",
+		Interval: time.Second,
+		Run: func(ctx context.Context) error {
+			for _, tick := range liveMetrics.flush(time.Now().Truncate(time.Second)) {
+				metricHub.Publish(tick)
+			}
+			return nil
+		},
+	}
+}
+
+// StreamMetricsHandler streams per-second request count, error rate and
+// latency summaries per service, optionally limited to one service.
+func StreamMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+
+	sub := metricHub.Subscribe(streamBufferSize, func(tick models.MetricTick) bool {
+		return service == "" || tick.ServiceName == service
+	})
+	serveStream(w, r, sub, "metric")
+}
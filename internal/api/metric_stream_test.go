@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestMetricTickAggregatorFlushesCompletedSeconds(t *testing.T) {
+	agg := newMetricTickAggregator()
+	second := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+
+	agg.add(models.EndpointMetric{ServiceName: "web", StatusCode: 200, Duration: 10}, second)
+	agg.add(models.EndpointMetric{ServiceName: "api", StatusCode: 200, Duration: 10}, second.Add(100*time.Millisecond))
+	agg.add(models.EndpointMetric{ServiceName: "api", StatusCode: 503, Duration: 30}, second.Add(900*time.Millisecond))
+	agg.add(models.EndpointMetric{ServiceName: "api", StatusCode: 200, Duration: 5}, second.Add(time.Second))
+
+	ticks := agg.flush(second.Add(time.Second))
+	if len(ticks) != 2 {
+		t.Fatalf("expected 2 ticks, got %+v", ticks)
+	}
+
+	api := ticks[0]
+	if api.ServiceName != "api" || !api.Timestamp.Equal(second) {
+		t.Fatalf("unexpected first tick %+v", api)
+	}
+	if api.Count != 2 || api.ErrorCount != 1 || api.ErrorRate != 0.5 || api.AvgMs != 20 || api.MaxMs != 30 {
+		t.Errorf("unexpected aggregates %+v", api)
+	}
+
+	if ticks[1].ServiceName != "web" {
+		t.Errorf("expected web second, got %+v", ticks[1])
+	}
+
+	rest := agg.flush(second.Add(2 * time.Second))
+	if len(rest) != 1 || rest[0].Count != 1 {
+		t.Errorf("expected the following second to be kept until flushed, got %+v", rest)
+	}
+}
@@ -7,59 +7,223 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NathanSanchezDev/go-insight/internal/db"
 	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
-func GetMetrics(serviceName, path, method string, minStatus, maxStatus int, limit, offset int) ([]models.EndpointMetric, error) {
-	query := `SELECT id, service_name, path, method, status_code, duration, 
-                     language, framework, version, environment, timestamp, request_id 
-              FROM metrics WHERE 1=1`
+// Path match modes accepted by the path_match query parameter.
+const (
+	PathMatchContains = "contains"
+	PathMatchExact    = "exact"
+	PathMatchPrefix   = "prefix"
+	PathMatchRegex    = "regex"
+)
+
+// MetricFilter holds the optional filters shared by metric queries.
+type MetricFilter struct {
+	ServiceName string
+	Path        string
+	PathMatch   string
+	Method      string
+	Environment string
+	RequestID   string
+	Language    string
+	Framework   string
+	MinStatus   int
+	MaxStatus   int
+	MinDuration float64
+	MaxDuration float64
+	StartTime   time.Time
+	EndTime     time.Time
+}
+
+// likeEscaper escapes LIKE wildcards so user input is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (f MetricFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
+	var params []any
+
+	if f.ServiceName != "" {
+		clause += fmt.Sprintf(" AND service_name = $%d", paramCount)
+		params = append(params, f.ServiceName)
+		paramCount++
+	}
+
+	if f.Path != "" {
+		switch f.PathMatch {
+		case PathMatchExact:
+			clause += fmt.Sprintf(" AND path = $%d", paramCount)
+			params = append(params, f.Path)
+		case PathMatchPrefix:
+			clause += fmt.Sprintf(` AND path LIKE $%d ESCAPE '\'`, paramCount)
+			params = append(params, likeEscaper.Replace(f.Path)+"%")
+		case PathMatchRegex:
+			clause += fmt.Sprintf(" AND path ~ $%d", paramCount)
+			params = append(params, f.Path)
+		default:
+			clause += fmt.Sprintf(" AND path LIKE $%d", paramCount)
+			params = append(params, "%"+f.Path+"%")
+		}
+		paramCount++
+	}
+
+	if f.Method != "" {
+		clause += fmt.Sprintf(" AND method = $%d", paramCount)
+		params = append(params, f.Method)
+		paramCount++
+	}
+
+	if f.Environment != "" {
+		clause += fmt.Sprintf(" AND environment = $%d", paramCount)
+		params = append(params, f.Environment)
+		paramCount++
+	}
+
+	if f.RequestID != "" {
+		clause += fmt.Sprintf(" AND request_id = $%d", paramCount)
+		params = append(params, f.RequestID)
+		paramCount++
+	}
+
+	if f.Language != "" {
+		clause += fmt.Sprintf(" AND language = $%d", paramCount)
+		params = append(params, f.Language)
+		paramCount++
+	}
+
+	if f.Framework != "" {
+		clause += fmt.Sprintf(" AND framework = $%d", paramCount)
+		params = append(params, f.Framework)
+		paramCount++
+	}
 
-	var params []interface{}
-	paramCount := 1
+	if f.MinStatus > 0 {
+		clause += fmt.Sprintf(" AND status_code >= $%d", paramCount)
+		params = append(params, f.MinStatus)
+		paramCount++
+	}
 
-	if serviceName != "" {
-		query += fmt.Sprintf(" AND service_name = $%d", paramCount)
-		params = append(params, serviceName)
+	if f.MaxStatus > 0 {
+		clause += fmt.Sprintf(" AND status_code <= $%d", paramCount)
+		params = append(params, f.MaxStatus)
 		paramCount++
 	}
 
-	if path != "" {
-		query += fmt.Sprintf(" AND path LIKE $%d", paramCount)
-		params = append(params, "%"+path+"%")
+	if f.MinDuration > 0 {
+		clause += fmt.Sprintf(" AND duration >= $%d", paramCount)
+		params = append(params, f.MinDuration)
 		paramCount++
 	}
 
-	if method != "" {
-		query += fmt.Sprintf(" AND method = $%d", paramCount)
-		params = append(params, method)
+	if f.MaxDuration > 0 {
+		clause += fmt.Sprintf(" AND duration <= $%d", paramCount)
+		params = append(params, f.MaxDuration)
 		paramCount++
 	}
 
-	if minStatus > 0 {
-		query += fmt.Sprintf(" AND status_code >= $%d", paramCount)
-		params = append(params, minStatus)
+	if !f.StartTime.IsZero() {
+		clause += fmt.Sprintf(" AND timestamp >= $%d", paramCount)
+		params = append(params, f.StartTime)
 		paramCount++
 	}
 
-	if maxStatus > 0 {
-		query += fmt.Sprintf(" AND status_code <= $%d", paramCount)
-		params = append(params, maxStatus)
+	if !f.EndTime.IsZero() {
+		clause += fmt.Sprintf(" AND timestamp <= $%d", paramCount)
+		params = append(params, f.EndTime)
 		paramCount++
 	}
 
-	query += " ORDER BY timestamp DESC"
+	return clause, params, paramCount
+}
+
+// parseMetricFilter reads the common metric filters from the query string.
+// Malformed numbers and times are ignored, matching the other GET handlers;
+// an unknown path_match mode or an invalid regex is reported as an error.
+func parseMetricFilter(r *http.Request) (MetricFilter, error) {
+	q := r.URL.Query()
+	filter := MetricFilter{
+		ServiceName: q.Get("service"),
+		Path:        q.Get("path"),
+		PathMatch:   q.Get("path_match"),
+		Method:      q.Get("method"),
+		Environment: q.Get("environment"),
+		RequestID:   q.Get("request_id"),
+		Language:    q.Get("language"),
+		Framework:   q.Get("framework"),
+	}
+
+	switch filter.PathMatch {
+	case "":
+		filter.PathMatch = PathMatchContains
+	case PathMatchContains, PathMatchExact, PathMatchPrefix:
+	case PathMatchRegex:
+		if _, err := regexp.Compile(filter.Path); err != nil {
+			return filter, fmt.Errorf("invalid path regex: %v", err)
+		}
+	default:
+		return filter, fmt.Errorf("invalid path_match: %s", filter.PathMatch)
+	}
+
+	if parsed, err := strconv.Atoi(q.Get("min_status")); err == nil {
+		filter.MinStatus = parsed
+	}
+
+	if parsed, err := strconv.Atoi(q.Get("max_status")); err == nil {
+		filter.MaxStatus = parsed
+	}
+
+	if parsed, err := strconv.ParseFloat(q.Get("min_duration"), 64); err == nil {
+		filter.MinDuration = parsed
+	}
+
+	if parsed, err := strconv.ParseFloat(q.Get("max_duration"), 64); err == nil {
+		filter.MaxDuration = parsed
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, q.Get("start_time")); err == nil {
+		filter.StartTime = parsed
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, q.Get("end_time")); err == nil {
+		filter.EndTime = parsed
+	}
+
+	return filter, nil
+}
+
+// GetMetrics returns metrics matching the filter, newest first. When cursor
+// is set only metrics strictly after the cursor position are returned and
+// offset is ignored.
+func GetMetrics(filter MetricFilter, cursor *pageCursor, limit, offset int) ([]models.EndpointMetric, error) {
+	query := `SELECT id, service_name, path, method, status_code, duration, 
+                     language, framework, version, environment, timestamp, request_id 
+              FROM metrics WHERE 1=1`
+
+	where, params, paramCount := filter.whereClause(1)
+	query += where
+
+	if cursor != nil {
+		query += fmt.Sprintf(" AND (timestamp, id) < ($%d, $%d)", paramCount, paramCount+1)
+		id, _ := strconv.Atoi(cursor.ID)
+		params = append(params, cursor.Timestamp, id)
+		paramCount += 2
+	}
+
+	query += " ORDER BY timestamp DESC, id DESC"
 
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", paramCount)
 		params = append(params, limit)
 		paramCount++
 
-		if offset > 0 {
+		if offset > 0 && cursor == nil {
 			query += fmt.Sprintf(" OFFSET $%d", paramCount)
 			params = append(params, offset)
 		}
@@ -171,39 +335,67 @@ func PostMetric(metric *models.EndpointMetric) error {
 	return nil
 }
 
+// invalidRegexSQLState is the SQLSTATE PostgreSQL reports for a regular
+// expression it cannot compile.
+const invalidRegexSQLState = "2201B"
+
+// isInvalidRegex reports whether err is PostgreSQL rejecting a regular
+// expression. Path and LogQL regexes are checked with Go's RE2 syntax when
+// parsed but run as PostgreSQL advanced regular expressions, and a few
+// constructs valid in RE2, such as (?P<name>...), are not valid there.
+func isInvalidRegex(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == invalidRegexSQLState
+}
+
+// EstimateMetrics returns the planner's row estimate for the filter.
+func EstimateMetrics(filter MetricFilter) (int64, error) {
+	where, params, _ := filter.whereClause(1)
+	return db.EstimateRows("SELECT 1 FROM metrics WHERE 1=1"+where, params...)
+}
+
 func GetMetricsHandler(w http.ResponseWriter, r *http.Request) {
-	serviceName := r.URL.Query().Get("service")
-	path := r.URL.Query().Get("path")
-	method := r.URL.Query().Get("method")
-
-	var minStatus, maxStatus int
-	if minStatusStr := r.URL.Query().Get("min_status"); minStatusStr != "" {
-		if parsed, err := strconv.Atoi(minStatusStr); err == nil {
-			minStatus = parsed
-		}
+	filter, err := parseMetricFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	if maxStatusStr := r.URL.Query().Get("max_status"); maxStatusStr != "" {
-		if parsed, err := strconv.Atoi(maxStatusStr); err == nil {
-			maxStatus = parsed
+	limit, offset := parseLimitOffset(r)
+
+	if r.URL.Query().Has("cursor") {
+		cursor, err := decodeCursor(r.URL.Query().Get("cursor"), true)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-	}
 
-	limit := 100 // Default limit
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
+		metrics, err := GetMetrics(filter, cursor, limit+1, 0)
+		if isInvalidRegex(err) {
+			http.Error(w, "invalid path regex: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to fetch metrics", http.StatusInternalServerError)
+			return
 		}
-	}
 
-	offset := 0 // Default offset
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+		page := buildPage(metrics, limit, func(m models.EndpointMetric) pageCursor {
+			return pageCursor{Timestamp: m.Timestamp, ID: strconv.Itoa(m.ID)}
+		})
+		if page.TotalEstimate, err = EstimateMetrics(filter); err != nil {
+			log.Printf("⚠️ Metric count estimate failed: %v", err)
 		}
+
+		writePage(w, page)
+		return
 	}
 
-	metrics, err := GetMetrics(serviceName, path, method, minStatus, maxStatus, limit, offset)
+	metrics, err := GetMetrics(filter, nil, limit, offset)
+	if isInvalidRegex(err) {
+		http.Error(w, "invalid path regex: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to fetch metrics", http.StatusInternalServerError)
 		return
@@ -233,12 +425,16 @@ func PostMetricHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !checkIngestService(w, r, metric.ServiceName) {
+		return
+	}
 
 	err = PostMetric(&metric)
 	if err != nil {
 		http.Error(w, "Failed to save metric", http.StatusInternalServerError)
 		return
 	}
+	recordLiveMetric(metric)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+// metricGroupColumns maps the group_by names accepted by the API to the
+// columns they aggregate on.
+var metricGroupColumns = map[string]string{
+	"service":     "service_name",
+	"path":        "path",
+	"method":      "method",
+	"status":      "status_code::text",
+	"environment": "COALESCE(environment, '')",
+}
+
+// defaultAggregateWindow is used when no start_time is supplied.
+const defaultAggregateWindow = time.Hour
+
+// parseGroupBy validates a comma separated group_by list against
+// metricGroupColumns, dropping duplicates while preserving order.
+func parseGroupBy(raw string) ([]string, error) {
+	var groupBy []string
+	seen := make(map[string]bool)
+
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if _, ok := metricGroupColumns[field]; !ok {
+			return nil, fmt.Errorf("invalid group_by field: %s", field)
+		}
+		seen[field] = true
+		groupBy = append(groupBy, field)
+	}
+
+	return groupBy, nil
+}
+
+func buildMetricAggregateQuery(filter MetricFilter, groupBy []string) (string, []any) {
+	var groupCols []string
+	for _, field := range groupBy {
+		groupCols = append(groupCols, metricGroupColumns[field])
+	}
+
+	query := "SELECT "
+	for _, col := range groupCols {
+		query += col + ", "
+	}
+	query += `COUNT(*),
+                     COALESCE(AVG(CASE WHEN status_code >= 500 THEN 1.0 ELSE 0.0 END), 0),
+                     COALESCE(AVG(duration), 0),
+                     COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY duration), 0),
+                     COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY duration), 0),
+                     COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY duration), 0),
+                     COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY duration), 0)
+              FROM metrics WHERE 1=1`
+
+	where, params, _ := filter.whereClause(1)
+	query += where
+
+	if len(groupCols) > 0 {
+		query += " GROUP BY " + strings.Join(groupCols, ", ")
+		query += " ORDER BY COUNT(*) DESC"
+	}
+
+	return query, params
+}
+
+// GetMetricAggregates computes request count, error rate and latency
+// percentiles for the filtered metrics, grouped by the given fields.
+// Requests with a 5xx status are counted as errors.
+func GetMetricAggregates(filter MetricFilter, groupBy []string) ([]models.MetricAggregate, error) {
+	query, params := buildMetricAggregateQuery(filter, groupBy)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error aggregating metrics:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := make([]models.MetricAggregate, 0)
+
+	for rows.Next() {
+		var agg models.MetricAggregate
+		groupValues := make([]sql.NullString, len(groupBy))
+
+		dest := make([]any, 0, len(groupBy)+7)
+		for i := range groupValues {
+			dest = append(dest, &groupValues[i])
+		}
+		dest = append(dest, &agg.Count, &agg.ErrorRate, &agg.AvgMs,
+			&agg.P50Ms, &agg.P90Ms, &agg.P95Ms, &agg.P99Ms)
+
+		if err := rows.Scan(dest...); err != nil {
+			log.Println("❌ Error scanning metric aggregate row:", err)
+			continue
+		}
+
+		agg.Group = make(map[string]string, len(groupBy))
+		for i, field := range groupBy {
+			agg.Group[field] = groupValues[i].String
+		}
+
+		aggregates = append(aggregates, agg)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return aggregates, nil
+}
+
+func GetMetricsAggregateHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseMetricFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.StartTime.IsZero() {
+		filter.StartTime = time.Now().Add(-defaultAggregateWindow)
+	}
+
+	groupBy, err := parseGroupBy(r.URL.Query().Get("group_by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	aggregates, err := GetMetricAggregates(filter, groupBy)
+	if isInvalidRegex(err) {
+		http.Error(w, "invalid path regex: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to aggregate metrics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aggregates)
+}
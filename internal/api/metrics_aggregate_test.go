@@ -0,0 +1,56 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	groupBy, err := parseGroupBy("service, path,service,,status")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"service", "path", "status"}
+	if strings.Join(groupBy, ",") != strings.Join(want, ",") {
+		t.Errorf("parseGroupBy = %v, want %v", groupBy, want)
+	}
+
+	if groupBy, err := parseGroupBy(""); err != nil || len(groupBy) != 0 {
+		t.Errorf("expected empty group_by to be accepted, got %v, %v", groupBy, err)
+	}
+
+	if _, err := parseGroupBy("service,request_id"); err == nil {
+		t.Errorf("expected error for unknown group_by field")
+	}
+}
+
+func TestBuildMetricAggregateQuery(t *testing.T) {
+	filter := MetricFilter{
+		ServiceName: "svc",
+		MinStatus:   500,
+		StartTime:   time.Now().Add(-time.Hour),
+	}
+
+	query, params := buildMetricAggregateQuery(filter, []string{"service", "status"})
+
+	if len(params) != 3 {
+		t.Fatalf("expected 3 params, got %d", len(params))
+	}
+	for _, fragment := range []string{
+		"percentile_cont(0.99)",
+		"service_name = $1",
+		"status_code >= $2",
+		"timestamp >= $3",
+		"GROUP BY service_name, status_code::text",
+	} {
+		if !strings.Contains(query, fragment) {
+			t.Errorf("query missing %q:\n%s", fragment, query)
+		}
+	}
+
+	query, _ = buildMetricAggregateQuery(MetricFilter{}, nil)
+	if strings.Contains(query, "GROUP BY") {
+		t.Errorf("ungrouped query should not contain GROUP BY:\n%s", query)
+	}
+}
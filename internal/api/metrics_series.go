@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const (
+	defaultSeriesStep = time.Minute
+	minSeriesStep     = time.Second
+	maxSeriesBuckets  = 10000
+)
+
+// parseStep parses the step query parameter (e.g. 30s, 1m, 5m, 1h),
+// falling back to defaultSeriesStep when empty.
+func parseStep(raw string) (time.Duration, error) {
+	if raw == "" {
+		return defaultSeriesStep, nil
+	}
+
+	step, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid step: %s", raw)
+	}
+
+	if step < minSeriesStep {
+		return 0, fmt.Errorf("step must be at least %s", minSeriesStep)
+	}
+
+	return step, nil
+}
+
+// buildMetricSeriesQuery buckets the filtered metrics with date_bin and joins
+// them against generate_series so that empty buckets are still returned.
+// The filter's StartTime and EndTime must be set.
+func buildMetricSeriesQuery(filter MetricFilter, step time.Duration) (string, []any) {
+	params := []any{step.Seconds(), filter.StartTime, filter.EndTime}
+	where, filterParams, _ := filter.whereClause(4)
+	params = append(params, filterParams...)
+
+	query := `WITH buckets AS (
+                  SELECT generate_series(
+                      date_bin($1 * INTERVAL '1 second', $2::timestamp, TIMESTAMP '2000-01-01'),
+                      $3::timestamp,
+                      $1 * INTERVAL '1 second'
+                  ) AS bucket
+              ), data AS (
+                  SELECT date_bin($1 * INTERVAL '1 second', timestamp, TIMESTAMP '2000-01-01') AS bucket,
+                         COUNT(*) AS count,
+                         AVG(CASE WHEN status_code >= 500 THEN 1.0 ELSE 0.0 END) AS error_rate,
+                         percentile_cont(0.5) WITHIN GROUP (ORDER BY duration) AS p50,
+                         percentile_cont(0.9) WITHIN GROUP (ORDER BY duration) AS p90,
+                         percentile_cont(0.95) WITHIN GROUP (ORDER BY duration) AS p95,
+                         percentile_cont(0.99) WITHIN GROUP (ORDER BY duration) AS p99
+                  FROM metrics WHERE 1=1` + where + `
+                  GROUP BY 1
+              )
+              SELECT b.bucket, COALESCE(d.count, 0), COALESCE(d.error_rate, 0),
+                     COALESCE(d.p50, 0), COALESCE(d.p90, 0), COALESCE(d.p95, 0), COALESCE(d.p99, 0)
+              FROM buckets b LEFT JOIN data d ON d.bucket = b.bucket
+              ORDER BY b.bucket`
+
+	return query, params
+}
+
+// GetMetricSeries returns one point per step between the filter's start and
+// end time. Buckets without data are reported with zero values.
+func GetMetricSeries(filter MetricFilter, step time.Duration) ([]models.MetricSeriesPoint, error) {
+	query, params := buildMetricSeriesQuery(filter, step)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching metric series:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]models.MetricSeriesPoint, 0)
+
+	for rows.Next() {
+		var point models.MetricSeriesPoint
+
+		err := rows.Scan(
+			&point.Timestamp, &point.Count, &point.ErrorRate,
+			&point.P50Ms, &point.P90Ms, &point.P95Ms, &point.P99Ms,
+		)
+		if err != nil {
+			log.Println("❌ Error scanning metric series row:", err)
+			continue
+		}
+
+		point.RequestRate = float64(point.Count) / step.Seconds()
+		points = append(points, point)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// resolveSeriesRange fills in a default time range and rejects ranges that
+// would produce more than maxSeriesBuckets points.
+func resolveSeriesRange(filter *MetricFilter, step time.Duration, now time.Time) error {
+	if filter.EndTime.IsZero() {
+		filter.EndTime = now
+	}
+
+	if filter.StartTime.IsZero() {
+		filter.StartTime = filter.EndTime.Add(-defaultAggregateWindow)
+	}
+
+	if !filter.StartTime.Before(filter.EndTime) {
+		return errors.New("start_time must be before end_time")
+	}
+
+	if filter.EndTime.Sub(filter.StartTime)/step > maxSeriesBuckets {
+		return fmt.Errorf("time range too large for step, at most %d buckets allowed", maxSeriesBuckets)
+	}
+
+	return nil
+}
+
+func GetMetricsSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	step, err := parseStep(r.URL.Query().Get("step"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseMetricFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := resolveSeriesRange(&filter, step, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	points, err := GetMetricSeries(filter, step)
+	if isInvalidRegex(err) {
+		http.Error(w, "invalid path regex: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch metric series", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(points)
+}
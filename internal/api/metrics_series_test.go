@@ -0,0 +1,75 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStep(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{raw: "", want: time.Minute},
+		{raw: "5m", want: 5 * time.Minute},
+		{raw: "1h", want: time.Hour},
+		{raw: "500ms", wantErr: true},
+		{raw: "soon", wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := parseStep(c.raw)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseStep(%q) expected error", c.raw)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("parseStep(%q) = %v, %v, want %v", c.raw, got, err, c.want)
+		}
+	}
+}
+
+func TestResolveSeriesRange(t *testing.T) {
+	now := time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC)
+
+	filter := MetricFilter{}
+	if err := resolveSeriesRange(&filter, time.Minute, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filter.EndTime.Equal(now) || !filter.StartTime.Equal(now.Add(-time.Hour)) {
+		t.Errorf("unexpected default range %v - %v", filter.StartTime, filter.EndTime)
+	}
+
+	inverted := MetricFilter{StartTime: now, EndTime: now.Add(-time.Minute)}
+	if err := resolveSeriesRange(&inverted, time.Minute, now); err == nil {
+		t.Errorf("expected error for inverted range")
+	}
+
+	tooWide := MetricFilter{StartTime: now.Add(-30 * 24 * time.Hour), EndTime: now}
+	if err := resolveSeriesRange(&tooWide, time.Second, now); err == nil {
+		t.Errorf("expected error for too many buckets")
+	}
+}
+
+func TestBuildMetricSeriesQuery(t *testing.T) {
+	now := time.Now()
+	filter := MetricFilter{ServiceName: "svc", StartTime: now.Add(-time.Hour), EndTime: now}
+
+	query, params := buildMetricSeriesQuery(filter, 5*time.Minute)
+
+	if params[0] != 300.0 {
+		t.Errorf("expected step of 300 seconds, got %v", params[0])
+	}
+	for _, fragment := range []string{"generate_series", "LEFT JOIN data", "service_name = $4", "timestamp >= $5", "timestamp <= $6"} {
+		if !strings.Contains(query, fragment) {
+			t.Errorf("query missing %q:\n%s", fragment, query)
+		}
+	}
+	if len(params) != 6 {
+		t.Errorf("expected 6 params, got %d", len(params))
+	}
+}
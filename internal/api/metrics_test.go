@@ -1,9 +1,15 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func TestValidateMetric(t *testing.T) {
@@ -43,3 +49,76 @@ func TestValidateMetric(t *testing.T) {
 		t.Errorf("expected error for missing source language")
 	}
 }
+
+func TestMetricFilterPathMatch(t *testing.T) {
+	cases := []struct {
+		mode      string
+		fragment  string
+		wantParam string
+	}{
+		{mode: PathMatchContains, fragment: "path LIKE $1", wantParam: "%/api/users%"},
+		{mode: PathMatchExact, fragment: "path = $1", wantParam: "/api/users"},
+		{mode: PathMatchPrefix, fragment: `path LIKE $1 ESCAPE '\'`, wantParam: "/api/users%"},
+		{mode: PathMatchRegex, fragment: "path ~ $1", wantParam: "/api/users"},
+	}
+
+	for _, c := range cases {
+		filter := MetricFilter{Path: "/api/users", PathMatch: c.mode}
+		clause, params, next := filter.whereClause(1)
+		if !strings.Contains(clause, c.fragment) {
+			t.Errorf("%s: clause %q missing %q", c.mode, clause, c.fragment)
+		}
+		if len(params) != 1 || params[0] != c.wantParam {
+			t.Errorf("%s: params = %v, want [%s]", c.mode, params, c.wantParam)
+		}
+		if next != 2 {
+			t.Errorf("%s: next placeholder = %d, want 2", c.mode, next)
+		}
+	}
+
+	filter := MetricFilter{Path: "/api/100%_done", PathMatch: PathMatchPrefix}
+	_, params, _ := filter.whereClause(1)
+	if params[0] != `/api/100\%\_done%` {
+		t.Errorf("prefix wildcards not escaped: %v", params[0])
+	}
+}
+
+func TestParseMetricFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics?service=svc&environment=prod&language=go&min_duration=250&start_time=2025-05-26T00:00:00Z&end_time=bad", nil)
+	filter, err := parseMetricFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.ServiceName != "svc" || filter.Environment != "prod" || filter.Language != "go" {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+	if filter.MinDuration != 250 || filter.StartTime.IsZero() || !filter.EndTime.IsZero() {
+		t.Errorf("unexpected parsed values: %+v", filter)
+	}
+	if filter.PathMatch != PathMatchContains {
+		t.Errorf("expected default path_match %q, got %q", PathMatchContains, filter.PathMatch)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics?path=/api&path_match=glob", nil)
+	if _, err := parseMetricFilter(req); err == nil {
+		t.Errorf("expected error for unknown path_match")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics?path=(&path_match=regex", nil)
+	if _, err := parseMetricFilter(req); err == nil {
+		t.Errorf("expected error for invalid regex")
+	}
+}
+
+func TestIsInvalidRegex(t *testing.T) {
+	pgErr := &pgconn.PgError{Code: "2201B", Message: "invalid regular expression: invalid escape \\ sequence"}
+	if !isInvalidRegex(fmt.Errorf("fetching metrics: %w", pgErr)) {
+		t.Errorf("expected wrapped 2201B to be an invalid regex")
+	}
+
+	for _, err := range []error{nil, errors.New("boom"), &pgconn.PgError{Code: "57014"}} {
+		if isInvalidRegex(err) {
+			t.Errorf("%v: unexpected invalid regex", err)
+		}
+	}
+}
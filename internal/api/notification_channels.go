@@ -0,0 +1,573 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/notify"
+)
+
+const notificationChannelColumns = `id, name, type, config, title_template, body_template, send_resolved,
+	array_to_json(group_by), group_wait_seconds, group_interval_seconds, repeat_interval_seconds, enabled,
+	created_at, updated_at`
+
+// Notification grouping defaults, used when a channel does not set them.
+const (
+	defaultGroupWait      = 30 * time.Second
+	defaultGroupInterval  = 5 * time.Minute
+	defaultRepeatInterval = 4 * time.Hour
+)
+
+// validateNotificationChannel checks that the channel's config and templates
+// can be used to send notifications.
+func validateNotificationChannel(channel *models.NotificationChannel) error {
+	if channel.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(channel.Config) == 0 {
+		return errors.New("config is required")
+	}
+	if _, err := notify.NewSender(channel.Type, channel.Config); err != nil {
+		return err
+	}
+	if _, err := notify.ParseTemplates(channel.TitleTemplate, channel.BodyTemplate); err != nil {
+		return err
+	}
+
+	if channel.GroupBy == nil {
+		channel.GroupBy = []string{}
+	}
+	seen := map[string]bool{}
+	for _, label := range channel.GroupBy {
+		if label == "" || seen[label] {
+			return fmt.Errorf("invalid group_by: %v", channel.GroupBy)
+		}
+		seen[label] = true
+	}
+
+	if channel.GroupWait < 0 {
+		return errors.New("group_wait cannot be negative")
+	}
+	if time.Duration(channel.GroupInterval) < time.Second || time.Duration(channel.RepeatInterval) < time.Second {
+		return errors.New("group_interval and repeat_interval must be at least 1s")
+	}
+
+	return nil
+}
+
+// redactNotificationChannel hides the channel's secrets for API responses.
+func redactNotificationChannel(channel models.NotificationChannel) models.NotificationChannel {
+	channel.Config = notify.Redact(channel.Type, channel.Config)
+	return channel
+}
+
+func GetNotificationChannels() ([]models.NotificationChannel, error) {
+	query := "SELECT " + notificationChannelColumns + " FROM notification_channels ORDER BY id"
+
+	rows, err := db.DB.QueryContext(context.Background(), query)
+	if err != nil {
+		log.Println("❌ Error fetching notification channels:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := make([]models.NotificationChannel, 0)
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
+			log.Println("❌ Error scanning notification channel row:", err)
+			continue
+		}
+		channels = append(channels, channel)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// GetNotificationChannel returns a single channel, or sql.ErrNoRows.
+func GetNotificationChannel(ctx context.Context, id int) (models.NotificationChannel, error) {
+	query := "SELECT " + notificationChannelColumns + " FROM notification_channels WHERE id = $1"
+	return scanNotificationChannel(db.DB.QueryRowContext(ctx, query, id))
+}
+
+// CreateNotificationChannel stores a validated channel, setting its ID and
+// timestamps.
+func CreateNotificationChannel(channel *models.NotificationChannel) error {
+	query := `INSERT INTO notification_channels
+                (name, type, config, title_template, body_template, send_resolved,
+                 group_by, group_wait_seconds, group_interval_seconds, repeat_interval_seconds, enabled)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+              RETURNING ` + notificationChannelColumns
+
+	created, err := scanNotificationChannel(db.DB.QueryRowContext(context.Background(), query,
+		notificationChannelParams(*channel)...))
+	if err != nil {
+		return err
+	}
+	*channel = created
+	return nil
+}
+
+// UpdateNotificationChannel replaces a validated channel, returning
+// sql.ErrNoRows when it does not exist.
+func UpdateNotificationChannel(channel *models.NotificationChannel) error {
+	query := `UPDATE notification_channels SET
+                name = $1, type = $2, config = $3, title_template = $4, body_template = $5,
+                send_resolved = $6, group_by = $7, group_wait_seconds = $8, group_interval_seconds = $9,
+                repeat_interval_seconds = $10, enabled = $11, updated_at = NOW()
+              WHERE id = $12
+              RETURNING ` + notificationChannelColumns
+
+	params := append(notificationChannelParams(*channel), channel.ID)
+	updated, err := scanNotificationChannel(db.DB.QueryRowContext(context.Background(), query, params...))
+	if err != nil {
+		return err
+	}
+	*channel = updated
+	return nil
+}
+
+// DeleteNotificationChannel removes a channel with its delivery log and
+// detaches it from alert rules, returning sql.ErrNoRows when it does not
+// exist.
+func DeleteNotificationChannel(id int) error {
+	tx, err := db.DB.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM notification_channels WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec("UPDATE alert_rules SET channel_ids = array_remove(channel_ids, $1) WHERE $1 = ANY(channel_ids)", id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// missingNotificationChannels returns the IDs in ids that do not refer to a
+// channel.
+func missingNotificationChannels(ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.DB.QueryContext(context.Background(),
+		"SELECT id FROM notification_channels WHERE id = ANY($1)", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []int
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+func notificationChannelParams(channel models.NotificationChannel) []any {
+	return []any{
+		channel.Name,
+		channel.Type,
+		[]byte(channel.Config),
+		channel.TitleTemplate,
+		channel.BodyTemplate,
+		channel.SendResolved,
+		channel.GroupBy,
+		int(time.Duration(channel.GroupWait) / time.Second),
+		int(time.Duration(channel.GroupInterval) / time.Second),
+		int(time.Duration(channel.RepeatInterval) / time.Second),
+		channel.Enabled,
+	}
+}
+
+func scanNotificationChannel(row rowScanner) (models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	var config, groupBy []byte
+	var groupWait, groupInterval, repeatInterval int
+
+	err := row.Scan(
+		&channel.ID,
+		&channel.Name,
+		&channel.Type,
+		&config,
+		&channel.TitleTemplate,
+		&channel.BodyTemplate,
+		&channel.SendResolved,
+		&groupBy,
+		&groupWait,
+		&groupInterval,
+		&repeatInterval,
+		&channel.Enabled,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+	if err != nil {
+		return models.NotificationChannel{}, err
+	}
+
+	if err := json.Unmarshal(groupBy, &channel.GroupBy); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	channel.Config = json.RawMessage(config)
+	channel.GroupWait = models.Duration(time.Duration(groupWait) * time.Second)
+	channel.GroupInterval = models.Duration(time.Duration(groupInterval) * time.Second)
+	channel.RepeatInterval = models.Duration(time.Duration(repeatInterval) * time.Second)
+	return channel, nil
+}
+
+// decodeNotificationChannel reads a channel from the request body. Channels
+// are enabled, send resolved notifications and group alerts by alertname
+// with the default timing unless the body says otherwise. Secrets still
+// masked by redaction are taken from previous.
+func decodeNotificationChannel(r *http.Request, previous json.RawMessage) (models.NotificationChannel, error) {
+	channel := models.NotificationChannel{
+		SendResolved:   true,
+		GroupBy:        []string{alertNameLabel},
+		GroupWait:      models.Duration(defaultGroupWait),
+		GroupInterval:  models.Duration(defaultGroupInterval),
+		RepeatInterval: models.Duration(defaultRepeatInterval),
+		Enabled:        true,
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&channel); err != nil {
+		return channel, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	if previous != nil {
+		channel.Config = notify.Unredact(channel.Type, channel.Config, previous)
+	}
+
+	return channel, validateNotificationChannel(&channel)
+}
+
+// NotificationDeliveryFilter selects entries of the delivery log.
+type NotificationDeliveryFilter struct {
+	ChannelID int
+	RuleID    int
+	Success   *bool
+}
+
+func (f NotificationDeliveryFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
+	var params []any
+
+	if f.ChannelID != 0 {
+		clause += fmt.Sprintf(" AND channel_id = $%d", paramCount)
+		params = append(params, f.ChannelID)
+		paramCount++
+	}
+
+	if f.RuleID != 0 {
+		clause += fmt.Sprintf(" AND rule_id = $%d", paramCount)
+		params = append(params, f.RuleID)
+		paramCount++
+	}
+
+	if f.Success != nil {
+		clause += fmt.Sprintf(" AND success = $%d", paramCount)
+		params = append(params, *f.Success)
+		paramCount++
+	}
+
+	return clause, params, paramCount
+}
+
+func parseNotificationDeliveryFilter(r *http.Request) (NotificationDeliveryFilter, error) {
+	q := r.URL.Query()
+	var filter NotificationDeliveryFilter
+
+	for name, target := range map[string]*int{"channel_id": &filter.ChannelID, "rule_id": &filter.RuleID} {
+		if raw := q.Get(name); raw != "" {
+			id, err := strconv.Atoi(raw)
+			if err != nil || id <= 0 {
+				return filter, fmt.Errorf("invalid %s: %s", name, raw)
+			}
+			*target = id
+		}
+	}
+
+	if raw := q.Get("success"); raw != "" {
+		success, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid success: %s", raw)
+		}
+		filter.Success = &success
+	}
+
+	return filter, nil
+}
+
+func GetNotificationDeliveries(filter NotificationDeliveryFilter, limit, offset int) ([]models.NotificationDelivery, error) {
+	query := `SELECT id, channel_id, rule_id, group_key, status, array_to_json(fingerprints), title, success, attempts,
+                     error, created_at
+              FROM notification_deliveries WHERE 1=1`
+
+	where, params, paramCount := filter.whereClause(1)
+	query += where
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", paramCount, paramCount+1)
+	params = append(params, limit, offset)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching notification deliveries:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.NotificationDelivery, 0)
+	for rows.Next() {
+		var delivery models.NotificationDelivery
+		var ruleID sql.NullInt64
+		var fingerprints []byte
+
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.ChannelID,
+			&ruleID,
+			&delivery.GroupKey,
+			&delivery.Status,
+			&fingerprints,
+			&delivery.Title,
+			&delivery.Success,
+			&delivery.Attempts,
+			&delivery.Error,
+			&delivery.CreatedAt,
+		)
+		if err == nil {
+			err = json.Unmarshal(fingerprints, &delivery.Fingerprints)
+		}
+		if err != nil {
+			log.Println("❌ Error scanning notification delivery row:", err)
+			continue
+		}
+
+		if ruleID.Valid {
+			id := int(ruleID.Int64)
+			delivery.RuleID = &id
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// sampleNotification is the sample sent by the channel test endpoint.
+func sampleNotification(now time.Time) notify.Notification {
+	labels := map[string]string{alertNameLabel: "TestNotification", "service": "go-insight"}
+	return notify.NewNotification(map[string]string{alertNameLabel: "TestNotification"}, []notify.Alert{{
+		Status:      notify.StatusFiring,
+		Fingerprint: "test",
+		Labels:      labels,
+		StartsAt:    now,
+	}})
+}
+
+func GetNotificationChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	channels, err := GetNotificationChannels()
+	if err != nil {
+		http.Error(w, "Failed to fetch notification channels", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range channels {
+		channels[i] = redactNotificationChannel(channels[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channels)
+}
+
+func CreateNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	channel, err := decodeNotificationChannel(r, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := CreateNotificationChannel(&channel); err != nil {
+		log.Printf("❌ Error creating notification channel: %v", err)
+		http.Error(w, "Failed to create notification channel", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(redactNotificationChannel(channel))
+}
+
+func GetNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	channel, err := GetNotificationChannel(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Notification channel not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching notification channel: %v", err)
+		http.Error(w, "Failed to fetch notification channel", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redactNotificationChannel(channel))
+}
+
+func UpdateNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := GetNotificationChannel(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Notification channel not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching notification channel: %v", err)
+		http.Error(w, "Failed to update notification channel", http.StatusInternalServerError)
+		return
+	}
+
+	channel, err := decodeNotificationChannel(r, existing.Config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	channel.ID = id
+
+	err = UpdateNotificationChannel(&channel)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Notification channel not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error updating notification channel: %v", err)
+		http.Error(w, "Failed to update notification channel", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redactNotificationChannel(channel))
+}
+
+func DeleteNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = DeleteNotificationChannel(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Notification channel not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error deleting notification channel: %v", err)
+		http.Error(w, "Failed to delete notification channel", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestNotificationChannelHandler sends a sample notification to the channel
+// once, without retries, and returns the logged delivery. Failed deliveries
+// are answered with 502.
+func TestNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	channel, err := GetNotificationChannel(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Notification channel not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching notification channel: %v", err)
+		http.Error(w, "Failed to test notification channel", http.StatusInternalServerError)
+		return
+	}
+
+	delivery := deliverNotification(r.Context(), channel, nil, "", sampleNotification(time.Now()), notify.RetryPolicy{Attempts: 1})
+
+	w.Header().Set("Content-Type", "application/json")
+	if !delivery.Success {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	json.NewEncoder(w).Encode(delivery)
+}
+
+func GetNotificationDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseNotificationDeliveryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, offset := parseLimitOffset(r)
+
+	deliveries, err := GetNotificationDeliveries(filter, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to fetch notification deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
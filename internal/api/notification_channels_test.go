@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestValidateNotificationChannel(t *testing.T) {
+	valid := models.NotificationChannel{
+		Name:           "ops",
+		Type:           "webhook",
+		Config:         json.RawMessage(`{"url": "https://example.com/hook", "secret": "s3cret"}`),
+		GroupInterval:  models.Duration(5 * time.Minute),
+		RepeatInterval: models.Duration(4 * time.Hour),
+	}
+	if err := validateNotificationChannel(&valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := map[string]func(*models.NotificationChannel){
+		"missing name":   func(c *models.NotificationChannel) { c.Name = "" },
+		"missing config": func(c *models.NotificationChannel) { c.Config = nil },
+		"bad type":       func(c *models.NotificationChannel) { c.Type = "pager" },
+		"bad config":     func(c *models.NotificationChannel) { c.Config = json.RawMessage(`{"url": ""}`) },
+		"bad template":   func(c *models.NotificationChannel) { c.TitleTemplate = "{{ .Status" },
+		"bad group_by":   func(c *models.NotificationChannel) { c.GroupBy = []string{"service", "service"} },
+		"negative wait":  func(c *models.NotificationChannel) { c.GroupWait = models.Duration(-time.Second) },
+		"zero repeat":    func(c *models.NotificationChannel) { c.RepeatInterval = 0 },
+	}
+
+	for name, mutate := range cases {
+		channel := valid
+		mutate(&channel)
+		if err := validateNotificationChannel(&channel); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDecodeNotificationChannelKeepsRedactedSecrets(t *testing.T) {
+	body := `{"name": "ops", "type": "webhook", "send_resolved": false,
+		"config": {"url": "https://example.com/new", "secret": "********"}}`
+	req := httptest.NewRequest(http.MethodPut, "/notification-channels/1", strings.NewReader(body))
+
+	channel, err := decodeNotificationChannel(req, json.RawMessage(`{"url": "https://example.com/old", "secret": "s3cret"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !channel.Enabled || channel.SendResolved {
+		t.Errorf("unexpected flags %+v", channel)
+	}
+	if len(channel.GroupBy) != 1 || channel.GroupBy[0] != alertNameLabel || time.Duration(channel.RepeatInterval) != 4*time.Hour {
+		t.Errorf("expected grouping defaults, got %+v", channel)
+	}
+
+	var config map[string]string
+	json.Unmarshal(channel.Config, &config)
+	if config["secret"] != "s3cret" || config["url"] != "https://example.com/new" {
+		t.Errorf("unexpected config %s", channel.Config)
+	}
+
+	if redacted := redactNotificationChannel(channel); strings.Contains(string(redacted.Config), "s3cret") {
+		t.Errorf("secret not redacted: %s", redacted.Config)
+	}
+}
+
+func TestNotificationDeliveryFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/notification-deliveries?channel_id=3&rule_id=7&success=false", nil)
+
+	filter, err := parseNotificationDeliveryFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clause, params, next := filter.whereClause(1)
+	if clause != " AND channel_id = $1 AND rule_id = $2 AND success = $3" || next != 4 {
+		t.Errorf("unexpected clause %q (next %d)", clause, next)
+	}
+	if !reflect.DeepEqual(params, []any{3, 7, false}) {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	for _, query := range []string{"channel_id=x", "rule_id=-1", "success=maybe"} {
+		req := httptest.NewRequest(http.MethodGet, "/notification-deliveries?"+query, nil)
+		if _, err := parseNotificationDeliveryFilter(req); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/notify"
+)
+
+// notificationTimeout bounds a delivery including its retries.
+const notificationTimeout = 2 * time.Minute
+
+// routedAlert is an alert together with the channels of its rule.
+type routedAlert struct {
+	models.Alert
+	ChannelIDs []int
+}
+
+// groupID identifies a notification group of a channel.
+type groupID struct {
+	channelID int
+	key       string
+}
+
+// storedGroup is a notification group as stored between dispatch runs.
+type storedGroup struct {
+	Labels map[string]string
+	State  alerting.GroupState
+}
+
+// groupPlan is the outcome of a dispatch run for one group: the state to
+// store or whether to delete it, and the notification to send, if any.
+type groupPlan struct {
+	ID           groupID
+	Labels       map[string]string
+	State        alerting.GroupState
+	Store        bool
+	Delete       bool
+	Notification *notify.Notification
+}
+
+// notifyAlert converts an alert for inclusion in a notification.
+func notifyAlert(alert models.Alert) notify.Alert {
+	status := notify.StatusFiring
+	if alert.State == models.AlertStateResolved {
+		status = notify.StatusResolved
+	}
+
+	startsAt := alert.LastEvaluatedAt
+	if alert.FiredAt != nil {
+		startsAt = *alert.FiredAt
+	} else if alert.ActiveSince != nil {
+		startsAt = *alert.ActiveSince
+	}
+
+	return notify.Alert{
+		Status:      status,
+		Fingerprint: alert.Fingerprint,
+		Labels:      alert.Labels,
+		Value:       alert.Value,
+		StartsAt:    startsAt,
+		EndsAt:      alert.ResolvedAt,
+	}
+}
+
+// planNotifications groups the unsuppressed firing alerts of each enabled
+// channel by the channel's group_by labels and decides which groups are due
+// a notification. A notification lists the group's firing alerts and, when
+// the channel sends resolved notifications, the alerts of the previous
+// notification that have resolved since. Groups without firing alerts are
+// dropped once their resolution has been announced.
+func planNotifications(channels []models.NotificationChannel, alerts []routedAlert, suppressor *alerting.Suppressor,
+	groups map[groupID]storedGroup, now time.Time) []groupPlan {
+	var plans []groupPlan
+
+	for _, channel := range channels {
+		if !channel.Enabled {
+			continue
+		}
+		timing := alerting.GroupTiming{
+			GroupWait:      time.Duration(channel.GroupWait),
+			GroupInterval:  time.Duration(channel.GroupInterval),
+			RepeatInterval: time.Duration(channel.RepeatInterval),
+		}
+
+		byFingerprint := map[string]models.Alert{}
+		firing := map[string][]models.Alert{}
+		groupLabels := map[string]map[string]string{}
+		for _, alert := range alerts {
+			if !slices.Contains(alert.ChannelIDs, channel.ID) {
+				continue
+			}
+			byFingerprint[alert.Fingerprint] = alert.Alert
+			if alert.State != models.AlertStateFiring || suppressor.Suppressed(alert.Labels) {
+				continue
+			}
+
+			labels := alerting.GroupLabels(channel.GroupBy, alert.Labels)
+			key := alerting.Fingerprint(labels)
+			firing[key] = append(firing[key], alert.Alert)
+			groupLabels[key] = labels
+		}
+
+		keys := make([]string, 0, len(firing))
+		for key := range firing {
+			keys = append(keys, key)
+		}
+		for id := range groups {
+			if id.channelID == channel.ID && firing[id.key] == nil {
+				keys = append(keys, id.key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			id := groupID{channelID: channel.ID, key: key}
+			stored, exists := groups[id]
+			if !exists {
+				stored = storedGroup{Labels: groupLabels[key], State: alerting.GroupState{CreatedAt: now}}
+			}
+
+			members := firing[key]
+			sort.Slice(members, func(i, j int) bool { return members[i].Fingerprint < members[j].Fingerprint })
+			fingerprints := make([]string, len(members))
+			for i, alert := range members {
+				fingerprints[i] = alert.Fingerprint
+			}
+
+			plan := groupPlan{ID: id, Labels: stored.Labels, State: stored.State}
+			switch {
+			case alerting.ShouldNotify(stored.State, fingerprints, timing, now):
+				included := make([]notify.Alert, 0, len(members))
+				for _, alert := range members {
+					included = append(included, notifyAlert(alert))
+				}
+				if channel.SendResolved {
+					for _, fingerprint := range stored.State.Fingerprints {
+						alert, ok := byFingerprint[fingerprint]
+						if ok && alert.State == models.AlertStateResolved {
+							included = append(included, notifyAlert(alert))
+						}
+					}
+				}
+				if len(included) > 0 {
+					n := notify.NewNotification(stored.Labels, included)
+					plan.Notification = &n
+				}
+
+				notifiedAt := now
+				plan.State = alerting.GroupState{Fingerprints: fingerprints, CreatedAt: stored.State.CreatedAt, LastNotifiedAt: &notifiedAt}
+				plan.Store = len(fingerprints) > 0
+				plan.Delete = len(fingerprints) == 0 && exists
+			case len(fingerprints) == 0 && stored.State.LastNotifiedAt == nil:
+				// Resolved or muted before it was ever announced.
+				plan.Delete = exists
+			default:
+				plan.Store = !exists
+			}
+
+			if plan.Store || plan.Delete || plan.Notification != nil {
+				plans = append(plans, plan)
+			}
+		}
+	}
+
+	return plans
+}
+
+// loadRoutedAlerts returns the firing alerts of rules with channels, and the
+// resolved alerts that were firing in a group's last notification.
+func loadRoutedAlerts(ctx context.Context) ([]routedAlert, error) {
+	query := `SELECT ` + alertColumns + `, array_to_json(r.channel_ids)
+              FROM alerts a JOIN alert_rules r ON r.id = a.rule_id
+              WHERE cardinality(r.channel_ids) > 0
+                AND (a.state = 'firing' OR (a.state = 'resolved'
+                     AND a.fingerprint IN (SELECT unnest(fingerprints) FROM notification_groups)))`
+
+	rows, err := db.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []routedAlert
+	for rows.Next() {
+		var channelIDs []byte
+		alert, err := scanAlert(rows, &channelIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		routed := routedAlert{Alert: alert}
+		if err := json.Unmarshal(channelIDs, &routed.ChannelIDs); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, routed)
+	}
+
+	return alerts, rows.Err()
+}
+
+func loadNotificationGroups(ctx context.Context) (map[groupID]storedGroup, error) {
+	query := `SELECT channel_id, group_key, group_labels, array_to_json(fingerprints), created_at, last_notified_at
+              FROM notification_groups`
+
+	rows, err := db.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := map[groupID]storedGroup{}
+	for rows.Next() {
+		var id groupID
+		var group storedGroup
+		var labels, fingerprints []byte
+		var lastNotifiedAt sql.NullTime
+
+		if err := rows.Scan(&id.channelID, &id.key, &labels, &fingerprints, &group.State.CreatedAt, &lastNotifiedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(labels, &group.Labels); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fingerprints, &group.State.Fingerprints); err != nil {
+			return nil, err
+		}
+		group.State.LastNotifiedAt = nullTimePtr(lastNotifiedAt)
+		groups[id] = group
+	}
+
+	return groups, rows.Err()
+}
+
+func storeGroupPlan(ctx context.Context, plan groupPlan) error {
+	if plan.Delete {
+		_, err := db.DB.ExecContext(ctx,
+			"DELETE FROM notification_groups WHERE channel_id = $1 AND group_key = $2", plan.ID.channelID, plan.ID.key)
+		return err
+	}
+	if !plan.Store {
+		return nil
+	}
+
+	labels, err := json.Marshal(plan.Labels)
+	if err != nil {
+		return err
+	}
+	fingerprints := plan.State.Fingerprints
+	if fingerprints == nil {
+		fingerprints = []string{}
+	}
+
+	_, err = db.DB.ExecContext(ctx,
+		`INSERT INTO notification_groups (channel_id, group_key, group_labels, fingerprints, created_at, last_notified_at)
+         VALUES ($1, $2, $3, $4, $5, $6)
+         ON CONFLICT (channel_id, group_key) DO UPDATE SET
+             fingerprints = EXCLUDED.fingerprints,
+             last_notified_at = EXCLUDED.last_notified_at`,
+		plan.ID.channelID, plan.ID.key, labels, fingerprints, plan.State.CreatedAt, plan.State.LastNotifiedAt)
+	return err
+}
+
+// alertSuppressor loads the active silences, the inhibition rules and the
+// labels of all firing alerts as inhibition sources.
+func alertSuppressor(ctx context.Context, now time.Time) (*alerting.Suppressor, error) {
+	silences, err := GetSilences(ctx, models.SilenceActive, now)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := GetInhibitRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.QueryContext(ctx, "SELECT labels FROM alerts WHERE state = 'firing'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var firing []map[string]string
+	for rows.Next() {
+		var raw []byte
+		var labels map[string]string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &labels); err != nil {
+			return nil, err
+		}
+		firing = append(firing, labels)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return alerting.NewSuppressor(silences, rules, firing, now)
+}
+
+// dispatchNotifications runs one round of grouping and sends the
+// notifications that are due. Group state is stored before sending, so a
+// failed delivery is not repeated before the next interval.
+func dispatchNotifications(ctx context.Context, now time.Time) error {
+	channels, err := GetNotificationChannels()
+	if err != nil {
+		return err
+	}
+	alerts, err := loadRoutedAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("loading alerts: %w", err)
+	}
+	groups, err := loadNotificationGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("loading notification groups: %w", err)
+	}
+	suppressor, err := alertSuppressor(ctx, now)
+	if err != nil {
+		return fmt.Errorf("loading silences and inhibit rules: %w", err)
+	}
+
+	channelsByID := map[int]models.NotificationChannel{}
+	for _, channel := range channels {
+		channelsByID[channel.ID] = channel
+	}
+	ruleIDs := map[string]int{}
+	for _, alert := range alerts {
+		ruleIDs[alert.Fingerprint] = alert.RuleID
+	}
+
+	var errs []error
+	for _, plan := range planNotifications(channels, alerts, suppressor, groups, now) {
+		if err := storeGroupPlan(ctx, plan); err != nil {
+			errs = append(errs, fmt.Errorf("group %s of channel %d: %w", plan.ID.key, plan.ID.channelID, err))
+			continue
+		}
+		if plan.Notification == nil {
+			continue
+		}
+
+		channel, n, key := channelsByID[plan.ID.channelID], *plan.Notification, plan.ID.key
+		ruleID := notificationRuleID(n, ruleIDs)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+			defer cancel()
+			deliverNotification(ctx, channel, ruleID, key, n, notify.DefaultRetryPolicy)
+		}()
+	}
+
+	return errors.Join(errs...)
+}
+
+// notificationRuleID returns the rule of the notification's alerts when
+// they all belong to one rule.
+func notificationRuleID(n notify.Notification, ruleIDs map[string]int) *int {
+	var ruleID *int
+	for _, alert := range n.Alerts {
+		id := ruleIDs[alert.Fingerprint]
+		if ruleID != nil && *ruleID != id {
+			return nil
+		}
+		ruleID = &id
+	}
+	return ruleID
+}
+
+// NotificationsJob sends due notifications for firing and resolved alerts.
+func NotificationsJob(interval time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "notifications",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			return dispatchNotifications(ctx, time.Now())
+		},
+	}
+}
+
+// deliverNotification renders and sends n to the channel and records the
+// outcome in the delivery log.
+func deliverNotification(ctx context.Context, channel models.NotificationChannel, ruleID *int, groupKey string,
+	n notify.Notification, policy notify.RetryPolicy) models.NotificationDelivery {
+	delivery := models.NotificationDelivery{
+		ChannelID: channel.ID,
+		RuleID:    ruleID,
+		GroupKey:  groupKey,
+		Status:    n.Status,
+	}
+	for _, alert := range n.Alerts {
+		delivery.Fingerprints = append(delivery.Fingerprints, alert.Fingerprint)
+	}
+
+	err := func() error {
+		sender, err := notify.NewSender(channel.Type, channel.Config)
+		if err != nil {
+			return err
+		}
+		templates, err := notify.ParseTemplates(channel.TitleTemplate, channel.BodyTemplate)
+		if err != nil {
+			return err
+		}
+		msg, err := templates.Render(n)
+		if err != nil {
+			return err
+		}
+
+		delivery.Title = msg.Title
+		delivery.Attempts, err = notify.Deliver(ctx, sender, msg, policy)
+		return err
+	}()
+
+	delivery.Success = err == nil
+	if err != nil {
+		delivery.Error = err.Error()
+		log.Printf("❌ Notification to channel %d (%s) failed after %d attempts: %v",
+			channel.ID, channel.Name, delivery.Attempts, err)
+	}
+
+	if err := storeNotificationDelivery(&delivery); err != nil {
+		log.Printf("❌ Error storing notification delivery: %v", err)
+	}
+	return delivery
+}
+
+func storeNotificationDelivery(delivery *models.NotificationDelivery) error {
+	if delivery.Fingerprints == nil {
+		delivery.Fingerprints = []string{}
+	}
+
+	query := `INSERT INTO notification_deliveries
+                (channel_id, rule_id, group_key, status, fingerprints, title, success, attempts, error)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+              RETURNING id, created_at`
+
+	return db.DB.QueryRowContext(context.Background(), query,
+		delivery.ChannelID,
+		delivery.RuleID,
+		delivery.GroupKey,
+		delivery.Status,
+		delivery.Fingerprints,
+		delivery.Title,
+		delivery.Success,
+		delivery.Attempts,
+		delivery.Error,
+	).Scan(&delivery.ID, &delivery.CreatedAt)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/notify"
+)
+
+func testChannel() models.NotificationChannel {
+	return models.NotificationChannel{
+		ID:             1,
+		SendResolved:   true,
+		GroupBy:        []string{"service"},
+		GroupWait:      models.Duration(30 * time.Second),
+		GroupInterval:  models.Duration(5 * time.Minute),
+		RepeatInterval: models.Duration(4 * time.Hour),
+		Enabled:        true,
+	}
+}
+
+func testRoutedAlert(fingerprint, state string, labels map[string]string) routedAlert {
+	return routedAlert{
+		Alert:      models.Alert{RuleID: 1, Fingerprint: fingerprint, State: state, Labels: labels},
+		ChannelIDs: []int{1},
+	}
+}
+
+func testSuppressor(t *testing.T, silences []models.Silence, rules []models.InhibitRule, alerts []routedAlert, now time.Time) *alerting.Suppressor {
+	t.Helper()
+	var firing []map[string]string
+	for _, alert := range alerts {
+		if alert.State == models.AlertStateFiring {
+			firing = append(firing, alert.Labels)
+		}
+	}
+	suppressor, err := alerting.NewSuppressor(silences, rules, firing, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return suppressor
+}
+
+func TestPlanNotificationsGroupsAlerts(t *testing.T) {
+	now := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	channels := []models.NotificationChannel{testChannel()}
+	alerts := []routedAlert{
+		testRoutedAlert("b", models.AlertStateFiring, map[string]string{"alertname": "HighLatency", "service": "api", "path": "/b"}),
+		testRoutedAlert("a", models.AlertStateFiring, map[string]string{"alertname": "HighLatency", "service": "api", "path": "/a"}),
+		testRoutedAlert("c", models.AlertStateFiring, map[string]string{"alertname": "HighLatency", "service": "web"}),
+	}
+	suppressor := testSuppressor(t, nil, nil, alerts, now)
+
+	// New groups wait for group_wait before the first notification.
+	plans := planNotifications(channels, alerts, suppressor, map[groupID]storedGroup{}, now)
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 new groups, got %+v", plans)
+	}
+	groups := map[groupID]storedGroup{}
+	for _, plan := range plans {
+		if !plan.Store || plan.Notification != nil {
+			t.Errorf("expected a waiting group, got %+v", plan)
+		}
+		groups[plan.ID] = storedGroup{Labels: plan.Labels, State: plan.State}
+	}
+
+	plans = planNotifications(channels, alerts, suppressor, groups, now.Add(30*time.Second))
+	if len(plans) != 2 {
+		t.Fatalf("expected 2 notifications, got %+v", plans)
+	}
+	apiPlan := plans[0]
+	if apiPlan.Labels["service"] != "api" {
+		apiPlan = plans[1]
+	}
+	n := apiPlan.Notification
+	if n == nil || len(n.Alerts) != 2 || n.Alerts[0].Fingerprint != "a" || n.GroupLabels["service"] != "api" {
+		t.Fatalf("expected one notification for both api alerts, got %+v", n)
+	}
+	if n.CommonLabels["alertname"] != "HighLatency" || n.CommonLabels["path"] != "" {
+		t.Errorf("unexpected common labels %v", n.CommonLabels)
+	}
+}
+
+func TestPlanNotificationsRepeatsAndResolves(t *testing.T) {
+	now := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	notifiedAt := now
+	channels := []models.NotificationChannel{testChannel()}
+	labels := map[string]string{"alertname": "HighLatency", "service": "api"}
+	key := alerting.Fingerprint(map[string]string{"service": "api"})
+	id := groupID{channelID: 1, key: key}
+	groups := map[groupID]storedGroup{id: {
+		Labels: map[string]string{"service": "api"},
+		State:  alerting.GroupState{Fingerprints: []string{"a"}, CreatedAt: now, LastNotifiedAt: &notifiedAt},
+	}}
+
+	firing := []routedAlert{testRoutedAlert("a", models.AlertStateFiring, labels)}
+	suppressor := testSuppressor(t, nil, nil, firing, now)
+
+	if plans := planNotifications(channels, firing, suppressor, groups, now.Add(time.Hour)); len(plans) != 0 {
+		t.Errorf("expected no notification before repeat_interval, got %+v", plans)
+	}
+	plans := planNotifications(channels, firing, suppressor, groups, now.Add(4*time.Hour))
+	if len(plans) != 1 || plans[0].Notification == nil || !plans[0].Store {
+		t.Errorf("expected a repeated notification, got %+v", plans)
+	}
+
+	resolved := []routedAlert{testRoutedAlert("a", models.AlertStateResolved, labels)}
+	plans = planNotifications(channels, resolved, suppressor, groups, now.Add(5*time.Minute))
+	if len(plans) != 1 || !plans[0].Delete || plans[0].Notification == nil ||
+		plans[0].Notification.Status != notify.StatusResolved {
+		t.Fatalf("expected a resolved notification and the group removed, got %+v", plans)
+	}
+
+	channels[0].SendResolved = false
+	plans = planNotifications(channels, resolved, suppressor, groups, now.Add(5*time.Minute))
+	if len(plans) != 1 || !plans[0].Delete || plans[0].Notification != nil {
+		t.Errorf("expected the group removed silently, got %+v", plans)
+	}
+}
+
+func TestPlanNotificationsSuppression(t *testing.T) {
+	now := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	channel := testChannel()
+	channel.GroupBy = []string{"alertname"}
+	channel.GroupWait = 0
+
+	alerts := []routedAlert{
+		testRoutedAlert("down", models.AlertStateFiring, map[string]string{"alertname": "ServiceDown", "service": "api"}),
+		testRoutedAlert("slow", models.AlertStateFiring, map[string]string{"alertname": "HighLatency", "service": "api"}),
+		testRoutedAlert("errors", models.AlertStateFiring, map[string]string{"alertname": "HighErrorRate", "service": "api"}),
+	}
+	silences := []models.Silence{{
+		ID:       1,
+		Matchers: []models.LabelMatcher{{Name: "alertname", Op: models.MatchEqual, Value: "HighErrorRate"}},
+		StartsAt: now.Add(-time.Minute),
+		EndsAt:   now.Add(time.Hour),
+	}}
+	rules := []models.InhibitRule{{
+		SourceMatchers: []models.LabelMatcher{{Name: "alertname", Op: models.MatchEqual, Value: "ServiceDown"}},
+		TargetMatchers: []models.LabelMatcher{{Name: "alertname", Op: models.MatchEqual, Value: "HighLatency"}},
+		Equal:          []string{"service"},
+	}}
+	suppressor := testSuppressor(t, silences, rules, alerts, now)
+
+	plans := planNotifications([]models.NotificationChannel{channel}, alerts, suppressor, map[groupID]storedGroup{}, now)
+	if len(plans) != 1 || plans[0].Notification == nil || plans[0].Labels["alertname"] != "ServiceDown" {
+		t.Fatalf("expected only ServiceDown to be notified, got %+v", plans)
+	}
+
+	channel.Enabled = false
+	if plans := planNotifications([]models.NotificationChannel{channel}, alerts, suppressor, map[groupID]storedGroup{}, now); len(plans) != 0 {
+		t.Errorf("expected disabled channel to be skipped, got %+v", plans)
+	}
+}
+
+func TestNotificationRuleID(t *testing.T) {
+	n := notify.Notification{Alerts: []notify.Alert{{Fingerprint: "a"}, {Fingerprint: "b"}}}
+
+	if id := notificationRuleID(n, map[string]int{"a": 3, "b": 3}); id == nil || *id != 3 {
+		t.Errorf("expected rule 3, got %v", id)
+	}
+	if id := notificationRuleID(n, map[string]int{"a": 3, "b": 4}); id != nil {
+		t.Errorf("expected no rule for mixed alerts, got %d", *id)
+	}
+}
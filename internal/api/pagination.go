@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// pageCursor is the position of the last row of a page. It is handed to
+// clients as an opaque base64 string.
+type pageCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Page is the response envelope used when a list endpoint is called in
+// cursor mode. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Data          []T    `json:"data"`
+	NextCursor    string `json:"next_cursor,omitempty"`
+	TotalEstimate int64  `json:"total_estimate"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor previously returned by encodeCursor. An empty
+// string requests the first page and yields a nil cursor. numericID requires
+// the ID to be an integer, for tables keyed by SERIAL columns.
+func decodeCursor(raw string, numericID bool) (*pageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || c.Timestamp.IsZero() {
+		return nil, errInvalidCursor
+	}
+
+	if numericID {
+		if _, err := strconv.Atoi(c.ID); err != nil {
+			return nil, errInvalidCursor
+		}
+	}
+
+	return &c, nil
+}
+
+// buildPage trims items fetched with limit+1 down to limit and sets the next
+// cursor when the extra row shows that more results exist.
+func buildPage[T any](items []T, limit int, cursorOf func(T) pageCursor) Page[T] {
+	page := Page[T]{Data: items}
+	if page.Data == nil {
+		page.Data = make([]T, 0)
+	}
+
+	if len(page.Data) > limit {
+		page.Data = page.Data[:limit]
+		page.NextCursor = encodeCursor(cursorOf(page.Data[limit-1]))
+	}
+
+	return page
+}
+
+func writePage[T any](w http.ResponseWriter, page Page[T]) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		log.Printf("❌ JSON encoding failed: %v", err)
+	}
+}
+
+// parseLimitOffset reads limit (default 100) and offset (default 0) from
+// the query string, ignoring invalid values.
+func parseLimitOffset(r *http.Request) (int, int) {
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	return limit, offset
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	ts := time.Date(2025, 5, 26, 10, 30, 15, 123456000, time.UTC)
+	raw := encodeCursor(pageCursor{Timestamp: ts, ID: "42"})
+
+	cursor, err := decodeCursor(raw, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cursor.Timestamp.Equal(ts) || cursor.ID != "42" {
+		t.Errorf("round trip mismatch: %+v", cursor)
+	}
+
+	if cursor, err := decodeCursor("", true); cursor != nil || err != nil {
+		t.Errorf("empty cursor should request the first page, got %v, %v", cursor, err)
+	}
+
+	if _, err := decodeCursor("not-a-cursor!", false); err == nil {
+		t.Errorf("expected error for malformed cursor")
+	}
+
+	textID := encodeCursor(pageCursor{Timestamp: ts, ID: "550e8400-e29b-41d4-a716-446655440000"})
+	if _, err := decodeCursor(textID, true); err == nil {
+		t.Errorf("expected error for non-numeric ID")
+	}
+	if _, err := decodeCursor(textID, false); err != nil {
+		t.Errorf("unexpected error for text ID: %v", err)
+	}
+}
+
+func TestBuildPage(t *testing.T) {
+	base := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	cursorOf := func(i int) pageCursor {
+		return pageCursor{Timestamp: base.Add(-time.Duration(i) * time.Second), ID: strconv.Itoa(i)}
+	}
+
+	page := buildPage([]int{1, 2, 3}, 2, cursorOf)
+	if len(page.Data) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(page.Data))
+	}
+	next, err := decodeCursor(page.NextCursor, true)
+	if err != nil || next.ID != "2" {
+		t.Errorf("next cursor should point at last returned item, got %+v, %v", next, err)
+	}
+
+	last := buildPage([]int{1, 2}, 2, cursorOf)
+	if last.NextCursor != "" {
+		t.Errorf("last page should not have a next cursor")
+	}
+
+	empty := buildPage[int](nil, 2, cursorOf)
+	if empty.Data == nil || len(empty.Data) != 0 {
+		t.Errorf("empty page should have non-nil empty data")
+	}
+}
+
+func TestParseLimitOffset(t *testing.T) {
+	limit, offset := parseLimitOffset(httptest.NewRequest("GET", "/logs?limit=25&offset=50", nil))
+	if limit != 25 || offset != 50 {
+		t.Errorf("got limit=%d offset=%d", limit, offset)
+	}
+
+	limit, offset = parseLimitOffset(httptest.NewRequest("GET", "/logs?limit=-1&offset=x", nil))
+	if limit != 100 || offset != 0 {
+		t.Errorf("expected defaults, got limit=%d offset=%d", limit, offset)
+	}
+}
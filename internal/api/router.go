@@ -20,20 +20,81 @@ func SetupRoutes() *mux.Router {
 	// Metrics endpoints
 	apiRouter.HandleFunc("/metrics", GetMetricsHandler).Methods("GET")
 	apiRouter.HandleFunc("/metrics", PostMetricHandler).Methods("POST")
+	apiRouter.HandleFunc("/metrics/aggregate", GetMetricsAggregateHandler).Methods("GET")
+	apiRouter.HandleFunc("/metrics/series", GetMetricsSeriesHandler).Methods("GET")
+	apiRouter.HandleFunc("/metrics/stream", StreamMetricsHandler).Methods("GET")
 
 	// Logs endpoints
 	apiRouter.HandleFunc("/logs", GetLogsHandler).Methods("GET")
 	apiRouter.HandleFunc("/logs", PostLogHandler).Methods("POST")
 	apiRouter.HandleFunc("/logs/bulk", PostLogsBulkHandler).Methods("POST")
+	apiRouter.HandleFunc("/logs/query", GetLogsQueryHandler).Methods("GET")
+	apiRouter.HandleFunc("/logs/patterns", GetLogPatternsHandler).Methods("GET")
+	apiRouter.HandleFunc("/logs/tail", TailLogsHandler).Methods("GET")
+
+	// Error groups endpoints
+	apiRouter.HandleFunc("/error-groups", GetErrorGroupsHandler).Methods("GET")
+	apiRouter.HandleFunc("/error-groups/{fingerprint}", GetErrorGroupHandler).Methods("GET")
+	apiRouter.HandleFunc("/error-groups/{fingerprint}", UpdateErrorGroupHandler).Methods("PATCH")
+
+	// Alerting endpoints
+	apiRouter.HandleFunc("/alert-rules", GetAlertRulesHandler).Methods("GET")
+	apiRouter.HandleFunc("/alert-rules", CreateAlertRuleHandler).Methods("POST")
+	apiRouter.HandleFunc("/alert-rules/{id}", GetAlertRuleHandler).Methods("GET")
+	apiRouter.HandleFunc("/alert-rules/{id}", UpdateAlertRuleHandler).Methods("PUT")
+	apiRouter.HandleFunc("/alert-rules/{id}", DeleteAlertRuleHandler).Methods("DELETE")
+	apiRouter.HandleFunc("/alerts", GetAlertsHandler).Methods("GET")
+	apiRouter.HandleFunc("/alerts/history", GetAlertHistoryHandler).Methods("GET")
+	apiRouter.HandleFunc("/notification-channels", GetNotificationChannelsHandler).Methods("GET")
+	apiRouter.HandleFunc("/notification-channels", CreateNotificationChannelHandler).Methods("POST")
+	apiRouter.HandleFunc("/notification-channels/{id}", GetNotificationChannelHandler).Methods("GET")
+	apiRouter.HandleFunc("/notification-channels/{id}", UpdateNotificationChannelHandler).Methods("PUT")
+	apiRouter.HandleFunc("/notification-channels/{id}", DeleteNotificationChannelHandler).Methods("DELETE")
+	apiRouter.HandleFunc("/notification-channels/{id}/test", TestNotificationChannelHandler).Methods("POST")
+	apiRouter.HandleFunc("/notification-deliveries", GetNotificationDeliveriesHandler).Methods("GET")
+	apiRouter.HandleFunc("/silences", GetSilencesHandler).Methods("GET")
+	apiRouter.HandleFunc("/silences", CreateSilenceHandler).Methods("POST")
+	apiRouter.HandleFunc("/silences/{id}", GetSilenceHandler).Methods("GET")
+	apiRouter.HandleFunc("/silences/{id}", UpdateSilenceHandler).Methods("PUT")
+	apiRouter.HandleFunc("/silences/{id}", ExpireSilenceHandler).Methods("DELETE")
+	apiRouter.HandleFunc("/inhibit-rules", GetInhibitRulesHandler).Methods("GET")
+	apiRouter.HandleFunc("/inhibit-rules", CreateInhibitRuleHandler).Methods("POST")
+	apiRouter.HandleFunc("/inhibit-rules/{id}", GetInhibitRuleHandler).Methods("GET")
+	apiRouter.HandleFunc("/inhibit-rules/{id}", UpdateInhibitRuleHandler).Methods("PUT")
+	apiRouter.HandleFunc("/inhibit-rules/{id}", DeleteInhibitRuleHandler).Methods("DELETE")
+	apiRouter.HandleFunc("/slos", GetSLOsHandler).Methods("GET")
+	apiRouter.HandleFunc("/slos", CreateSLOHandler).Methods("POST")
+	apiRouter.HandleFunc("/slos/{id}", GetSLOHandler).Methods("GET")
+	apiRouter.HandleFunc("/slos/{id}", UpdateSLOHandler).Methods("PUT")
+	apiRouter.HandleFunc("/slos/{id}", DeleteSLOHandler).Methods("DELETE")
+	apiRouter.HandleFunc("/slos/{id}/status", GetSLOStatusHandler).Methods("GET")
+	apiRouter.HandleFunc("/anomalies", GetAnomaliesHandler).Methods("GET")
+	apiRouter.HandleFunc("/api-keys", GetAPIKeysHandler).Methods("GET")
+	apiRouter.HandleFunc("/api-keys", CreateAPIKeyHandler).Methods("POST")
+	apiRouter.HandleFunc("/api-keys/{id}", GetAPIKeyHandler).Methods("GET")
+	apiRouter.HandleFunc("/api-keys/{id}", RevokeAPIKeyHandler).Methods("DELETE")
 
 	// Traces endpoints
 	apiRouter.HandleFunc("/traces", GetTracesHandler).Methods("GET")
 	apiRouter.HandleFunc("/traces", CreateTraceHandler).Methods("POST")
+	apiRouter.HandleFunc("/traces/stream", StreamTracesHandler).Methods("GET")
+	apiRouter.HandleFunc("/traces/{traceId}", GetTraceHandler).Methods("GET")
 	apiRouter.HandleFunc("/traces/{traceId}/end", EndTraceHandler).Methods("POST")
 	apiRouter.HandleFunc("/traces/{traceId}/spans", GetSpansHandler).Methods("GET")
+	apiRouter.HandleFunc("/traces/{traceId}/analysis", GetTraceAnalysisHandler).Methods("GET")
+	apiRouter.HandleFunc("/traces/{traceId}/logs", GetTraceLogsHandler).Methods("GET")
+	apiRouter.HandleFunc("/dependencies", GetDependenciesHandler).Methods("GET")
 	apiRouter.HandleFunc("/spans", CreateSpanHandler).Methods("POST")
+	apiRouter.HandleFunc("/spans/metrics/aggregate", GetSpanMetricsAggregateHandler).Methods("GET")
+	apiRouter.HandleFunc("/spans/metrics/series", GetSpanMetricsSeriesHandler).Methods("GET")
 	apiRouter.HandleFunc("/spans/{spanId}/end", EndSpanHandler).Methods("POST")
 
+	// Dashboard login
+	router.HandleFunc("/auth/login", LoginHandler).Methods("GET")
+	router.HandleFunc("/auth/callback", LoginCallbackHandler).Methods("GET")
+	router.HandleFunc("/auth/logout", LogoutHandler).Methods("POST")
+	router.HandleFunc("/auth/session", SessionHandler).Methods("GET")
+
 	// Serve Next.js static files LAST (catches everything else)
 	webDir := "./web"
 	if _, err := os.Stat(webDir); err == nil {
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const silenceColumns = "id, matchers, starts_at, ends_at, created_by, comment, created_at, updated_at"
+
+var validSilenceStatuses = map[string]bool{
+	models.SilencePending: true,
+	models.SilenceActive:  true,
+	models.SilenceExpired: true,
+}
+
+// validateSilence fills in the start time and checks that the silence is
+// bounded and does not match every alert.
+func validateSilence(silence *models.Silence, now time.Time) error {
+	if len(silence.Matchers) == 0 {
+		return errors.New("at least one matcher is required")
+	}
+	matchers, err := alerting.CompileMatchers(silence.Matchers)
+	if err != nil {
+		return err
+	}
+	if matchers.MatchesEmpty() {
+		return errors.New("matchers must not match every alert")
+	}
+
+	if silence.CreatedBy == "" {
+		return errors.New("created_by is required")
+	}
+	if silence.Comment == "" {
+		return errors.New("comment is required")
+	}
+
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if !silence.EndsAt.After(now) {
+		return errors.New("ends_at must be in the future")
+	}
+
+	return nil
+}
+
+// silenceStatusClause renders the condition selecting silences with status
+// at the time in placeholder $1. An empty status selects all silences.
+func silenceStatusClause(status string) string {
+	switch status {
+	case models.SilencePending:
+		return " WHERE starts_at > $1"
+	case models.SilenceActive:
+		return " WHERE starts_at <= $1 AND ends_at > $1"
+	case models.SilenceExpired:
+		return " WHERE ends_at <= $1"
+	default:
+		return ""
+	}
+}
+
+// GetSilences returns the silences with status at now, or all silences for
+// an empty status, ending last first.
+func GetSilences(ctx context.Context, status string, now time.Time) ([]models.Silence, error) {
+	where := silenceStatusClause(status)
+	query := "SELECT " + silenceColumns + " FROM silences" + where + " ORDER BY ends_at DESC, id DESC"
+
+	var params []any
+	if where != "" {
+		params = append(params, now)
+	}
+
+	rows, err := db.DB.QueryContext(ctx, query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching silences:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	silences := make([]models.Silence, 0)
+	for rows.Next() {
+		silence, err := scanSilence(rows, now)
+		if err != nil {
+			log.Println("❌ Error scanning silence row:", err)
+			continue
+		}
+		silences = append(silences, silence)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+// GetSilence returns a single silence, or sql.ErrNoRows.
+func GetSilence(id int, now time.Time) (models.Silence, error) {
+	query := "SELECT " + silenceColumns + " FROM silences WHERE id = $1"
+	return scanSilence(db.DB.QueryRowContext(context.Background(), query, id), now)
+}
+
+// CreateSilence stores a validated silence, setting its ID and timestamps.
+func CreateSilence(silence *models.Silence, now time.Time) error {
+	matchers, err := json.Marshal(silence.Matchers)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO silences (matchers, starts_at, ends_at, created_by, comment)
+              VALUES ($1, $2, $3, $4, $5)
+              RETURNING ` + silenceColumns
+
+	created, err := scanSilence(db.DB.QueryRowContext(context.Background(), query,
+		matchers, silence.StartsAt, silence.EndsAt, silence.CreatedBy, silence.Comment), now)
+	if err != nil {
+		return err
+	}
+	*silence = created
+	return nil
+}
+
+// UpdateSilence replaces a validated silence that has not expired, returning
+// sql.ErrNoRows when there is no such silence.
+func UpdateSilence(silence *models.Silence, now time.Time) error {
+	matchers, err := json.Marshal(silence.Matchers)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE silences SET
+                matchers = $1, starts_at = $2, ends_at = $3, created_by = $4, comment = $5, updated_at = NOW()
+              WHERE id = $6 AND ends_at > $7
+              RETURNING ` + silenceColumns
+
+	updated, err := scanSilence(db.DB.QueryRowContext(context.Background(), query,
+		matchers, silence.StartsAt, silence.EndsAt, silence.CreatedBy, silence.Comment, silence.ID, now), now)
+	if err != nil {
+		return err
+	}
+	*silence = updated
+	return nil
+}
+
+// ExpireSilence ends a silence at now, keeping it for reference. Expiring a
+// silence that has already expired changes nothing. It returns
+// sql.ErrNoRows when the silence does not exist.
+func ExpireSilence(id int, now time.Time) error {
+	result, err := db.DB.ExecContext(context.Background(),
+		`UPDATE silences SET
+           starts_at = LEAST(starts_at, $2), ends_at = LEAST(ends_at, $2), updated_at = NOW()
+         WHERE id = $1`, id, now)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanSilence(row rowScanner, now time.Time) (models.Silence, error) {
+	var silence models.Silence
+	var matchers []byte
+
+	err := row.Scan(
+		&silence.ID,
+		&matchers,
+		&silence.StartsAt,
+		&silence.EndsAt,
+		&silence.CreatedBy,
+		&silence.Comment,
+		&silence.CreatedAt,
+		&silence.UpdatedAt,
+	)
+	if err != nil {
+		return models.Silence{}, err
+	}
+
+	if err := json.Unmarshal(matchers, &silence.Matchers); err != nil {
+		return models.Silence{}, err
+	}
+	silence.Status = alerting.SilenceStatus(silence, now)
+
+	return silence, nil
+}
+
+// decodeSilence reads a silence from the request body. Silences start
+// immediately unless starts_at is given.
+func decodeSilence(r *http.Request, now time.Time) (models.Silence, error) {
+	var silence models.Silence
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&silence); err != nil {
+		return silence, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return silence, validateSilence(&silence, now)
+}
+
+func GetSilencesHandler(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && !validSilenceStatuses[status] {
+		http.Error(w, fmt.Sprintf("invalid status: %s", status), http.StatusBadRequest)
+		return
+	}
+
+	silences, err := GetSilences(r.Context(), status, time.Now())
+	if err != nil {
+		http.Error(w, "Failed to fetch silences", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(silences)
+}
+
+func CreateSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	silence, err := decodeSilence(r, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := CreateSilence(&silence, now); err != nil {
+		log.Printf("❌ Error creating silence: %v", err)
+		http.Error(w, "Failed to create silence", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(silence)
+}
+
+func GetSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	silence, err := GetSilence(id, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Silence not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching silence: %v", err)
+		http.Error(w, "Failed to fetch silence", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(silence)
+}
+
+func UpdateSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	silence, err := decodeSilence(r, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	silence.ID = id
+
+	err = UpdateSilence(&silence, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Silence not found or expired", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error updating silence: %v", err)
+		http.Error(w, "Failed to update silence", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(silence)
+}
+
+func ExpireSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = ExpireSilence(id, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Silence not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error expiring silence: %v", err)
+		http.Error(w, "Failed to expire silence", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestDecodeSilenceDefaultsStart(t *testing.T) {
+	now := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	body := `{"matchers": [{"name": "service", "op": "=", "value": "api"}],
+		"ends_at": "2025-05-26T12:00:00Z", "created_by": "alice", "comment": "Deploying api"}`
+	req := httptest.NewRequest(http.MethodPost, "/silences", strings.NewReader(body))
+
+	silence, err := decodeSilence(req, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !silence.StartsAt.Equal(now) {
+		t.Errorf("expected silence to start now, got %s", silence.StartsAt)
+	}
+}
+
+func TestValidateSilenceErrors(t *testing.T) {
+	now := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	valid := func() models.Silence {
+		return models.Silence{
+			Matchers:  []models.LabelMatcher{{Name: "service", Op: models.MatchEqual, Value: "api"}},
+			EndsAt:    now.Add(time.Hour),
+			CreatedBy: "alice",
+			Comment:   "Deploying api",
+		}
+	}
+
+	cases := map[string]func(*models.Silence){
+		"no matchers":  func(s *models.Silence) { s.Matchers = nil },
+		"bad operator": func(s *models.Silence) { s.Matchers[0].Op = "~" },
+		"matches all": func(s *models.Silence) {
+			s.Matchers[0] = models.LabelMatcher{Name: "service", Op: models.MatchRegexp, Value: ".*"}
+		},
+		"missing creator":   func(s *models.Silence) { s.CreatedBy = "" },
+		"missing comment":   func(s *models.Silence) { s.Comment = "" },
+		"ends before start": func(s *models.Silence) { s.StartsAt = now.Add(2 * time.Hour) },
+		"already ended": func(s *models.Silence) {
+			s.StartsAt = now.Add(-2 * time.Hour)
+			s.EndsAt = now.Add(-time.Hour)
+		},
+	}
+
+	for name, mutate := range cases {
+		silence := valid()
+		mutate(&silence)
+		if err := validateSilence(&silence, now); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSilenceStatusClause(t *testing.T) {
+	if clause := silenceStatusClause(models.SilenceActive); clause != " WHERE starts_at <= $1 AND ends_at > $1" {
+		t.Errorf("unexpected clause %q", clause)
+	}
+	if clause := silenceStatusClause(""); clause != "" {
+		t.Errorf("expected no clause without status, got %q", clause)
+	}
+}
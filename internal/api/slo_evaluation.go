@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/alerting"
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/slo"
+)
+
+// buildSLOQuery renders one query counting the SLO's total and good
+// requests in each window ending at now. It selects the total and good
+// count of every window in order.
+func buildSLOQuery(s models.SLO, windows []time.Duration, now time.Time) (string, []any) {
+	longest := windows[0]
+	for _, window := range windows {
+		longest = max(longest, window)
+	}
+
+	params := []any{s.Service, now.Add(-longest), now}
+	paramCount := 4
+
+	good := "status_code < 500"
+	if s.LatencyThreshold > 0 {
+		good += fmt.Sprintf(" AND duration < $%d", paramCount)
+		params = append(params, float64(time.Duration(s.LatencyThreshold))/float64(time.Millisecond))
+		paramCount++
+	}
+
+	selects := make([]string, 0, 2*len(windows))
+	for _, window := range windows {
+		selects = append(selects,
+			fmt.Sprintf("COUNT(*) FILTER (WHERE timestamp > $%d)", paramCount),
+			fmt.Sprintf("COUNT(*) FILTER (WHERE timestamp > $%d AND %s)", paramCount, good))
+		params = append(params, now.Add(-window))
+		paramCount++
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM metrics WHERE service_name = $1 AND timestamp > $2 AND timestamp <= $3",
+		strings.Join(selects, ", "))
+
+	if s.Path != "" {
+		query += fmt.Sprintf(" AND path = $%d", paramCount)
+		params = append(params, s.Path)
+		paramCount++
+	}
+	if s.Method != "" {
+		query += fmt.Sprintf(" AND method = $%d", paramCount)
+		params = append(params, s.Method)
+	}
+
+	return query, params
+}
+
+// querySLOCounts returns the SLO's request counts keyed by the lengths of
+// its window and of its burn windows.
+func querySLOCounts(ctx context.Context, s models.SLO, now time.Time) (map[time.Duration]slo.Counts, error) {
+	windows := slo.Windows(time.Duration(s.Window), slo.DefaultBurnWindows)
+	query, params := buildSLOQuery(s, windows, now)
+
+	values := make([]int64, 2*len(windows))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := db.DB.QueryRowContext(ctx, query, params...).Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[time.Duration]slo.Counts, len(windows))
+	for i, window := range windows {
+		counts[window] = slo.Counts{Total: values[2*i], Good: values[2*i+1]}
+	}
+	return counts, nil
+}
+
+// sloBurnResults evaluates the default burn windows against counts.
+func sloBurnResults(s models.SLO, counts map[time.Duration]slo.Counts) []slo.BurnResult {
+	return slo.Evaluate(counts, s.Objective, time.Duration(s.Window), slo.DefaultBurnWindows)
+}
+
+// sloStatus summarizes counts as the SLO's status at now, without alerts.
+func sloStatus(s models.SLO, counts map[time.Duration]slo.Counts, now time.Time) models.SLOStatus {
+	total := counts[time.Duration(s.Window)]
+	budget := slo.ErrorBudget(total, s.Objective)
+
+	status := models.SLOStatus{
+		SLOID:       s.ID,
+		TotalEvents: total.Total,
+		GoodEvents:  total.Good,
+		SLI:         total.SLI(),
+		Objective:   s.Objective,
+		ErrorBudget: models.ErrorBudget{
+			Allowed:   budget.Allowed,
+			Consumed:  budget.Consumed,
+			Remaining: budget.Remaining,
+		},
+		BurnRates:   make([]models.SLOBurnRate, 0, len(slo.DefaultBurnWindows)),
+		Alerts:      []models.SLOAlert{},
+		EvaluatedAt: now,
+	}
+
+	for _, result := range sloBurnResults(s, counts) {
+		status.BurnRates = append(status.BurnRates, models.SLOBurnRate{
+			BurnWindow:    result.Window.Name(),
+			Severity:      result.Window.Severity,
+			LongWindow:    models.Duration(result.Window.Long),
+			ShortWindow:   models.Duration(result.Window.Short),
+			Threshold:     result.Threshold,
+			LongBurnRate:  result.LongBurnRate,
+			ShortBurnRate: result.ShortBurnRate,
+			Breached:      result.Breached(),
+		})
+	}
+
+	return status
+}
+
+// sloAlertUpdate is an SLO alert after an evaluation together with the
+// state it was in before.
+type sloAlertUpdate struct {
+	Alert     models.SLOAlert
+	FromState string
+}
+
+// Changed reports whether the evaluation moved the alert to another state.
+func (u sloAlertUpdate) Changed() bool {
+	return u.FromState != u.Alert.State
+}
+
+// evaluateSLOAlerts advances the SLO's alerts, one per burn window, with the
+// burn rates of an evaluation at now. Burn-rate alerts fire as soon as both
+// windows are breached; the windows already require the burn to last.
+func evaluateSLOAlerts(s models.SLO, current []models.SLOAlert, results []slo.BurnResult, now time.Time) []sloAlertUpdate {
+	byWindow := make(map[string]models.SLOAlert, len(current))
+	for _, alert := range current {
+		byWindow[alert.BurnWindow] = alert
+	}
+
+	updates := make([]sloAlertUpdate, 0, len(results))
+	for _, result := range results {
+		name := result.Window.Name()
+		alert, ok := byWindow[name]
+		if !ok {
+			alert = models.SLOAlert{SLOID: s.ID, BurnWindow: name, State: models.AlertStateInactive}
+		}
+		from := alert.State
+
+		var activeSince time.Time
+		if alert.ActiveSince != nil {
+			activeSince = *alert.ActiveSince
+		}
+		state, since := alerting.Next(from, activeSince, result.Breached(), now, 0)
+
+		alert.Severity = result.Window.Severity
+		alert.State = state
+		alert.Threshold = result.Threshold
+		alert.LongBurnRate = result.LongBurnRate
+		alert.ShortBurnRate = result.ShortBurnRate
+		alert.LastEvaluatedAt = now
+		alert.ActiveSince = nil
+		if !since.IsZero() {
+			alert.ActiveSince = &since
+		}
+
+		if state != from {
+			switch state {
+			case models.AlertStateFiring:
+				firedAt := now
+				alert.FiredAt, alert.ResolvedAt = &firedAt, nil
+			case models.AlertStateResolved:
+				resolvedAt := now
+				alert.ResolvedAt = &resolvedAt
+			}
+		}
+
+		updates = append(updates, sloAlertUpdate{Alert: alert, FromState: from})
+	}
+
+	return updates
+}
+
+// GetSLOAlerts returns the burn-rate alerts of an SLO's last evaluation.
+func GetSLOAlerts(ctx context.Context, sloID int) ([]models.SLOAlert, error) {
+	query := `SELECT slo_id, burn_window, severity, state, threshold, long_burn_rate, short_burn_rate,
+                     active_since, fired_at, resolved_at, last_evaluated_at
+              FROM slo_alerts WHERE slo_id = $1 ORDER BY threshold DESC`
+
+	rows, err := db.DB.QueryContext(ctx, query, sloID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]models.SLOAlert, 0)
+	for rows.Next() {
+		var alert models.SLOAlert
+		var activeSince, firedAt, resolvedAt sql.NullTime
+		err := rows.Scan(&alert.SLOID, &alert.BurnWindow, &alert.Severity, &alert.State, &alert.Threshold,
+			&alert.LongBurnRate, &alert.ShortBurnRate, &activeSince, &firedAt, &resolvedAt, &alert.LastEvaluatedAt)
+		if err != nil {
+			return nil, err
+		}
+		alert.ActiveSince = nullTimePtr(activeSince)
+		alert.FiredAt = nullTimePtr(firedAt)
+		alert.ResolvedAt = nullTimePtr(resolvedAt)
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// EvaluateSLO runs one evaluation of the SLO at now and stores the
+// resulting alert states.
+func EvaluateSLO(ctx context.Context, s models.SLO, now time.Time) ([]sloAlertUpdate, error) {
+	counts, err := querySLOCounts(ctx, s, now)
+	if err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+
+	current, err := GetSLOAlerts(ctx, s.ID)
+	if err != nil {
+		return nil, fmt.Errorf("loading alerts: %w", err)
+	}
+
+	updates := evaluateSLOAlerts(s, current, sloBurnResults(s, counts), now)
+	if err := storeSLOAlertUpdates(ctx, s.ID, updates, now); err != nil {
+		return nil, fmt.Errorf("storing alerts: %w", err)
+	}
+
+	return updates, nil
+}
+
+func storeSLOAlertUpdates(ctx context.Context, sloID int, updates []sloAlertUpdate, now time.Time) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, update := range updates {
+		alert := update.Alert
+		_, err = tx.ExecContext(ctx, `INSERT INTO slo_alerts
+                (slo_id, burn_window, severity, state, threshold, long_burn_rate, short_burn_rate,
+                 active_since, fired_at, resolved_at, last_evaluated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+              ON CONFLICT (slo_id, burn_window) DO UPDATE SET
+                severity = EXCLUDED.severity,
+                state = EXCLUDED.state,
+                threshold = EXCLUDED.threshold,
+                long_burn_rate = EXCLUDED.long_burn_rate,
+                short_burn_rate = EXCLUDED.short_burn_rate,
+                active_since = EXCLUDED.active_since,
+                fired_at = EXCLUDED.fired_at,
+                resolved_at = EXCLUDED.resolved_at,
+                last_evaluated_at = EXCLUDED.last_evaluated_at`,
+			sloID, alert.BurnWindow, alert.Severity, alert.State, alert.Threshold, alert.LongBurnRate,
+			alert.ShortBurnRate, alert.ActiveSince, alert.FiredAt, alert.ResolvedAt, alert.LastEvaluatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE slos SET last_evaluated_at = $1 WHERE id = $2", now, sloID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SLOsJob evaluates the burn-rate alerts of every SLO.
+func SLOsJob(interval time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "slos",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			slos, err := GetSLOs(ctx)
+			if err != nil {
+				return err
+			}
+
+			var errs []error
+			for _, s := range slos {
+				updates, err := EvaluateSLO(ctx, s, now)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("SLO %d (%s): %w", s.ID, s.Name, err))
+					continue
+				}
+				for _, update := range updates {
+					if update.Changed() {
+						log.Printf("🔔 SLO %s %s burn rate %s -> %s (%.2f / %.2f, threshold %.2f)",
+							s.Name, update.Alert.BurnWindow, update.FromState, update.Alert.State,
+							update.Alert.LongBurnRate, update.Alert.ShortBurnRate, update.Alert.Threshold)
+					}
+				}
+			}
+
+			return errors.Join(errs...)
+		},
+	}
+}
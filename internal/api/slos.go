@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+const (
+	defaultSLOWindow = 28 * 24 * time.Hour
+	minSLOWindow     = 7 * 24 * time.Hour
+	maxSLOWindow     = 90 * 24 * time.Hour
+)
+
+const sloColumns = `id, name, description, service_name, path, method, objective, latency_threshold_ms,
+	window_seconds, created_at, updated_at, last_evaluated_at`
+
+// validateSLO fills in the default window and checks that the SLO can be
+// evaluated. The window must be longer than the longest burn window.
+func validateSLO(s *models.SLO) error {
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	if s.Service == "" {
+		return errors.New("service is required")
+	}
+
+	if s.Objective <= 0 || s.Objective >= 1 {
+		return errors.New("objective must be between 0 and 1, such as 0.999")
+	}
+	if s.LatencyThreshold < 0 {
+		return errors.New("latency_threshold cannot be negative")
+	}
+
+	if s.Window == 0 {
+		s.Window = models.Duration(defaultSLOWindow)
+	}
+	window := time.Duration(s.Window)
+	if window < minSLOWindow || window > maxSLOWindow || window%time.Second != 0 {
+		return fmt.Errorf("window must be whole seconds between %s and %s", minSLOWindow, maxSLOWindow)
+	}
+
+	return nil
+}
+
+func GetSLOs(ctx context.Context) ([]models.SLO, error) {
+	query := "SELECT " + sloColumns + " FROM slos ORDER BY id"
+
+	rows, err := db.DB.QueryContext(ctx, query)
+	if err != nil {
+		log.Println("❌ Error fetching SLOs:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	slos := make([]models.SLO, 0)
+	for rows.Next() {
+		s, err := scanSLO(rows)
+		if err != nil {
+			log.Println("❌ Error scanning SLO row:", err)
+			continue
+		}
+		slos = append(slos, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return slos, nil
+}
+
+// GetSLO returns a single SLO, or sql.ErrNoRows.
+func GetSLO(id int) (models.SLO, error) {
+	query := "SELECT " + sloColumns + " FROM slos WHERE id = $1"
+	return scanSLO(db.DB.QueryRowContext(context.Background(), query, id))
+}
+
+// CreateSLO stores a validated SLO, setting its ID and timestamps.
+func CreateSLO(s *models.SLO) error {
+	query := `INSERT INTO slos
+                (name, description, service_name, path, method, objective, latency_threshold_ms, window_seconds)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              RETURNING ` + sloColumns
+
+	created, err := scanSLO(db.DB.QueryRowContext(context.Background(), query, sloParams(*s)...))
+	if err != nil {
+		return err
+	}
+	*s = created
+	return nil
+}
+
+// UpdateSLO replaces a validated SLO, returning sql.ErrNoRows when it does
+// not exist. Its burn-rate alerts keep their state and are re-evaluated
+// against the new definition by the next job run.
+func UpdateSLO(s *models.SLO) error {
+	query := `UPDATE slos SET
+                name = $1, description = $2, service_name = $3, path = $4, method = $5, objective = $6,
+                latency_threshold_ms = $7, window_seconds = $8, updated_at = NOW()
+              WHERE id = $9
+              RETURNING ` + sloColumns
+
+	params := append(sloParams(*s), s.ID)
+	updated, err := scanSLO(db.DB.QueryRowContext(context.Background(), query, params...))
+	if err != nil {
+		return err
+	}
+	*s = updated
+	return nil
+}
+
+// DeleteSLO removes an SLO with its alerts, returning sql.ErrNoRows when it
+// does not exist.
+func DeleteSLO(id int) error {
+	result, err := db.DB.ExecContext(context.Background(), "DELETE FROM slos WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func sloParams(s models.SLO) []any {
+	return []any{
+		s.Name,
+		s.Description,
+		s.Service,
+		s.Path,
+		s.Method,
+		s.Objective,
+		float64(time.Duration(s.LatencyThreshold)) / float64(time.Millisecond),
+		int(time.Duration(s.Window) / time.Second),
+	}
+}
+
+func scanSLO(row rowScanner) (models.SLO, error) {
+	var s models.SLO
+	var latencyThresholdMs float64
+	var windowSeconds int
+	var lastEvaluatedAt sql.NullTime
+
+	err := row.Scan(
+		&s.ID,
+		&s.Name,
+		&s.Description,
+		&s.Service,
+		&s.Path,
+		&s.Method,
+		&s.Objective,
+		&latencyThresholdMs,
+		&windowSeconds,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+		&lastEvaluatedAt,
+	)
+	if err != nil {
+		return models.SLO{}, err
+	}
+
+	s.LatencyThreshold = models.Duration(time.Duration(latencyThresholdMs * float64(time.Millisecond)))
+	s.Window = models.Duration(time.Duration(windowSeconds) * time.Second)
+	s.LastEvaluatedAt = nullTimePtr(lastEvaluatedAt)
+
+	return s, nil
+}
+
+func decodeSLO(r *http.Request) (models.SLO, error) {
+	var s models.SLO
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		return s, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return s, validateSLO(&s)
+}
+
+func GetSLOsHandler(w http.ResponseWriter, r *http.Request) {
+	slos, err := GetSLOs(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch SLOs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(slos)
+}
+
+func CreateSLOHandler(w http.ResponseWriter, r *http.Request) {
+	s, err := decodeSLO(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := CreateSLO(&s); err != nil {
+		log.Printf("❌ Error creating SLO: %v", err)
+		http.Error(w, "Failed to create SLO", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s)
+}
+
+func GetSLOHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := GetSLO(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "SLO not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching SLO: %v", err)
+		http.Error(w, "Failed to fetch SLO", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+func UpdateSLOHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := decodeSLO(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.ID = id
+
+	err = UpdateSLO(&s)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "SLO not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error updating SLO: %v", err)
+		http.Error(w, "Failed to update SLO", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+func DeleteSLOHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = DeleteSLO(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "SLO not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error deleting SLO: %v", err)
+		http.Error(w, "Failed to delete SLO", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSLOStatusHandler computes the SLO's error budget and burn rates at the
+// time of the request, together with the alert states of the last
+// evaluation.
+func GetSLOStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := GetSLO(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "SLO not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Error fetching SLO: %v", err)
+		http.Error(w, "Failed to fetch SLO", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	counts, err := querySLOCounts(r.Context(), s, now)
+	if err != nil {
+		log.Printf("❌ Error computing SLO status: %v", err)
+		http.Error(w, "Failed to compute SLO status", http.StatusInternalServerError)
+		return
+	}
+
+	alerts, err := GetSLOAlerts(r.Context(), s.ID)
+	if err != nil {
+		log.Printf("❌ Error fetching SLO alerts: %v", err)
+		http.Error(w, "Failed to fetch SLO alerts", http.StatusInternalServerError)
+		return
+	}
+
+	status := sloStatus(s, counts, now)
+	status.Alerts = alerts
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/slo"
+)
+
+func TestValidateSLO(t *testing.T) {
+	valid := models.SLO{Name: "checkout availability", Service: "checkout", Objective: 0.999}
+	if err := validateSLO(&valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Duration(valid.Window) != defaultSLOWindow {
+		t.Errorf("expected the default window, got %s", time.Duration(valid.Window))
+	}
+
+	cases := map[string]func(*models.SLO){
+		"missing name":      func(s *models.SLO) { s.Name = "" },
+		"missing service":   func(s *models.SLO) { s.Service = "" },
+		"objective of 1":    func(s *models.SLO) { s.Objective = 1 },
+		"objective of 0":    func(s *models.SLO) { s.Objective = 0 },
+		"negative latency":  func(s *models.SLO) { s.LatencyThreshold = models.Duration(-time.Millisecond) },
+		"short window":      func(s *models.SLO) { s.Window = models.Duration(24 * time.Hour) },
+		"long window":       func(s *models.SLO) { s.Window = models.Duration(365 * 24 * time.Hour) },
+		"fractional window": func(s *models.SLO) { s.Window = models.Duration(defaultSLOWindow + time.Millisecond) },
+	}
+
+	for name, mutate := range cases {
+		s := valid
+		mutate(&s)
+		if err := validateSLO(&s); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDecodeSLO(t *testing.T) {
+	body := `{"name": "checkout latency", "service": "checkout", "path": "/api/orders",
+		"objective": 0.99, "latency_threshold": "300ms"}`
+	req := httptest.NewRequest(http.MethodPost, "/slos", strings.NewReader(body))
+
+	s, err := decodeSLO(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Duration(s.LatencyThreshold) != 300*time.Millisecond || time.Duration(s.Window) != defaultSLOWindow {
+		t.Errorf("unexpected SLO %+v", s)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/slos", strings.NewReader(`{"name": "x", "target": 0.99}`))
+	if _, err := decodeSLO(req); err == nil {
+		t.Error("expected unknown fields to be rejected")
+	}
+}
+
+func TestBuildSLOQuery(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := models.SLO{
+		Service:          "checkout",
+		Method:           "POST",
+		LatencyThreshold: models.Duration(300 * time.Millisecond),
+	}
+
+	query, params := buildSLOQuery(s, []time.Duration{7 * 24 * time.Hour, time.Hour}, now)
+
+	want := "SELECT COUNT(*) FILTER (WHERE timestamp > $5), " +
+		"COUNT(*) FILTER (WHERE timestamp > $5 AND status_code < 500 AND duration < $4), " +
+		"COUNT(*) FILTER (WHERE timestamp > $6), " +
+		"COUNT(*) FILTER (WHERE timestamp > $6 AND status_code < 500 AND duration < $4) " +
+		"FROM metrics WHERE service_name = $1 AND timestamp > $2 AND timestamp <= $3 AND method = $7"
+	if query != want {
+		t.Errorf("unexpected query:\n%s", query)
+	}
+
+	wantParams := []any{"checkout", now.Add(-7 * 24 * time.Hour), now, 300.0,
+		now.Add(-7 * 24 * time.Hour), now.Add(-time.Hour), "POST"}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	query, _ = buildSLOQuery(models.SLO{Service: "checkout"}, []time.Duration{time.Hour}, now)
+	if strings.Contains(query, "duration") {
+		t.Errorf("expected an availability-only query, got %s", query)
+	}
+}
+
+func TestSLOStatus(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := models.SLO{ID: 4, Objective: 0.999, Window: models.Duration(defaultSLOWindow)}
+	counts := map[time.Duration]slo.Counts{
+		defaultSLOWindow: {Total: 1000000, Good: 999500},
+		time.Hour:        {Total: 1000, Good: 900},
+		5 * time.Minute:  {Total: 100, Good: 80},
+	}
+
+	status := sloStatus(s, counts, now)
+	if status.SLOID != 4 || status.TotalEvents != 1000000 || status.ErrorBudget.Allowed < 999 ||
+		status.ErrorBudget.Remaining < 0.49 || status.ErrorBudget.Remaining > 0.51 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if len(status.BurnRates) != len(slo.DefaultBurnWindows) {
+		t.Fatalf("expected a burn rate per window, got %+v", status.BurnRates)
+	}
+	if rate := status.BurnRates[0]; rate.BurnWindow != "1h/5m" || !rate.Breached {
+		t.Errorf("expected the 1h/5m window to breach, got %+v", rate)
+	}
+	if status.BurnRates[1].Breached {
+		t.Errorf("expected the 6h/30m window not to breach, got %+v", status.BurnRates[1])
+	}
+}
+
+func TestEvaluateSLOAlerts(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := models.SLO{ID: 4, Objective: 0.999, Window: models.Duration(defaultSLOWindow)}
+	window := slo.DefaultBurnWindows[0]
+	breached := []slo.BurnResult{{Window: window, Threshold: 13.44, LongBurnRate: 20, ShortBurnRate: 30}}
+	recovered := []slo.BurnResult{{Window: window, Threshold: 13.44, LongBurnRate: 20, ShortBurnRate: 2}}
+
+	updates := evaluateSLOAlerts(s, nil, breached, now)
+	if len(updates) != 1 || !updates[0].Changed() || updates[0].Alert.State != models.AlertStateFiring ||
+		updates[0].Alert.FiredAt == nil || updates[0].Alert.Severity != slo.SeverityPage {
+		t.Fatalf("expected the alert to fire at once, got %+v", updates)
+	}
+
+	current := []models.SLOAlert{updates[0].Alert}
+	if updates := evaluateSLOAlerts(s, current, breached, now.Add(time.Minute)); updates[0].Changed() {
+		t.Errorf("expected the alert to keep firing, got %+v", updates[0])
+	}
+
+	updates = evaluateSLOAlerts(s, current, recovered, now.Add(time.Minute))
+	if updates[0].Alert.State != models.AlertStateResolved || updates[0].Alert.ResolvedAt == nil {
+		t.Errorf("expected the alert to resolve once the short window recovers, got %+v", updates[0])
+	}
+
+	if updates := evaluateSLOAlerts(s, nil, recovered, now); updates[0].Alert.State != models.AlertStateInactive {
+		t.Errorf("expected an inactive alert, got %+v", updates[0])
+	}
+}
@@ -0,0 +1,444 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/jobs"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+// spanMetricGroupColumns maps the group_by names accepted by the span
+// metrics endpoints to their columns.
+var spanMetricGroupColumns = map[string]string{
+	"service":   "service",
+	"operation": "operation",
+}
+
+const (
+	// spanMetricsLookback is how far back each materialization run
+	// recomputes span metrics, covering slow inserts of ended spans.
+	spanMetricsLookback = 5 * time.Minute
+
+	// minSpanMetricsStep is the resolution span metrics are stored at.
+	minSpanMetricsStep = time.Minute
+)
+
+// SpanMetricFilter holds the optional filters for span metric queries.
+type SpanMetricFilter struct {
+	Service   string
+	Operation string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+func (f SpanMetricFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
+	var params []any
+
+	if f.Service != "" {
+		clause += fmt.Sprintf(" AND service = $%d", paramCount)
+		params = append(params, f.Service)
+		paramCount++
+	}
+
+	if f.Operation != "" {
+		clause += fmt.Sprintf(" AND operation = $%d", paramCount)
+		params = append(params, f.Operation)
+		paramCount++
+	}
+
+	if !f.StartTime.IsZero() {
+		clause += fmt.Sprintf(" AND bucket >= date_trunc('minute', $%d::timestamp)", paramCount)
+		params = append(params, f.StartTime)
+		paramCount++
+	}
+
+	if !f.EndTime.IsZero() {
+		clause += fmt.Sprintf(" AND bucket <= $%d", paramCount)
+		params = append(params, f.EndTime)
+		paramCount++
+	}
+
+	return clause, params, paramCount
+}
+
+func parseSpanMetricFilter(r *http.Request) (SpanMetricFilter, error) {
+	q := r.URL.Query()
+	filter := SpanMetricFilter{
+		Service:   q.Get("service"),
+		Operation: q.Get("operation"),
+	}
+
+	if raw := q.Get("start_time"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start_time: %s", raw)
+		}
+		filter.StartTime = parsed
+	}
+
+	if raw := q.Get("end_time"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end_time: %s", raw)
+		}
+		filter.EndTime = parsed
+	}
+
+	return filter, nil
+}
+
+// parseSpanMetricGroupBy validates a comma separated group_by list against
+// spanMetricGroupColumns, dropping duplicates while preserving order.
+func parseSpanMetricGroupBy(raw string) ([]string, error) {
+	var groupBy []string
+	seen := make(map[string]bool)
+
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if _, ok := spanMetricGroupColumns[field]; !ok {
+			return nil, fmt.Errorf("invalid group_by field: %s", field)
+		}
+		seen[field] = true
+		groupBy = append(groupBy, field)
+	}
+
+	return groupBy, nil
+}
+
+// spanMetricTotals selects the summed counters followed by the summed
+// histogram buckets.
+func spanMetricTotals() string {
+	cols := []string{
+		"COALESCE(SUM(call_count), 0)",
+		"COALESCE(SUM(error_count), 0)",
+		"COALESCE(SUM(duration_sum_ms), 0)",
+		"COALESCE(MAX(duration_max_ms), 0)",
+	}
+	for i := 1; i <= len(models.SpanDurationBounds)+1; i++ {
+		cols = append(cols, fmt.Sprintf("COALESCE(SUM(duration_buckets[%d]), 0)", i))
+	}
+	return strings.Join(cols, ", ")
+}
+
+func buildSpanMetricAggregateQuery(filter SpanMetricFilter, groupBy []string) (string, []any) {
+	var groupCols []string
+	for _, field := range groupBy {
+		groupCols = append(groupCols, spanMetricGroupColumns[field])
+	}
+
+	query := "SELECT "
+	for _, col := range groupCols {
+		query += col + ", "
+	}
+	query += spanMetricTotals() + " FROM span_metrics WHERE 1=1"
+
+	where, params, _ := filter.whereClause(1)
+	query += where
+
+	if len(groupCols) > 0 {
+		query += " GROUP BY " + strings.Join(groupCols, ", ")
+		query += " ORDER BY SUM(call_count) DESC"
+	}
+
+	return query, params
+}
+
+// buildSpanMetricSeriesQuery buckets span metrics by step and joins them
+// against generate_series so that empty buckets are still returned. The
+// filter's StartTime and EndTime must be set.
+func buildSpanMetricSeriesQuery(filter SpanMetricFilter, step time.Duration) (string, []any) {
+	params := []any{step.Seconds(), filter.StartTime, filter.EndTime}
+	where, filterParams, _ := filter.whereClause(4)
+	params = append(params, filterParams...)
+
+	zeros := make([]string, len(models.SpanDurationBounds)+1)
+	for i := range zeros {
+		zeros[i] = fmt.Sprintf("COALESCE(d.h%d, 0)", i+1)
+	}
+	var histogram []string
+	for i := 1; i <= len(models.SpanDurationBounds)+1; i++ {
+		histogram = append(histogram, fmt.Sprintf("SUM(duration_buckets[%d]) AS h%d", i, i))
+	}
+
+	query := `WITH buckets AS (
+                  SELECT generate_series(
+                      date_bin($1 * INTERVAL '1 second', $2::timestamp, TIMESTAMP '2000-01-01'),
+                      $3::timestamp,
+                      $1 * INTERVAL '1 second'
+                  ) AS bucket
+              ), data AS (
+                  SELECT date_bin($1 * INTERVAL '1 second', bucket, TIMESTAMP '2000-01-01') AS bucket,
+                         SUM(call_count) AS calls,
+                         SUM(error_count) AS errors,
+                         SUM(duration_sum_ms) AS duration_sum,
+                         MAX(duration_max_ms) AS duration_max,
+                         ` + strings.Join(histogram, ", ") + `
+                  FROM span_metrics WHERE 1=1` + where + `
+                  GROUP BY 1
+              )
+              SELECT b.bucket, COALESCE(d.calls, 0), COALESCE(d.errors, 0),
+                     COALESCE(d.duration_sum, 0), COALESCE(d.duration_max, 0),
+                     ` + strings.Join(zeros, ", ") + `
+              FROM buckets b LEFT JOIN data d ON d.bucket = b.bucket
+              ORDER BY b.bucket`
+
+	return query, params
+}
+
+// spanMetricRow holds the summed counters of a span metrics query row.
+type spanMetricRow struct {
+	calls       int64
+	errors      int64
+	durationSum float64
+	durationMax float64
+	histogram   []int64
+}
+
+func (row *spanMetricRow) dest() []any {
+	row.histogram = make([]int64, len(models.SpanDurationBounds)+1)
+	dest := []any{&row.calls, &row.errors, &row.durationSum, &row.durationMax}
+	for i := range row.histogram {
+		dest = append(dest, &row.histogram[i])
+	}
+	return dest
+}
+
+func (row spanMetricRow) errorRate() float64 {
+	if row.calls == 0 {
+		return 0
+	}
+	return float64(row.errors) / float64(row.calls)
+}
+
+func (row spanMetricRow) quantile(q float64) float64 {
+	return histogramQuantile(models.SpanDurationBounds, row.histogram, row.durationMax, q)
+}
+
+// histogramQuantile estimates the q-quantile from non-cumulative bucket
+// counts by linear interpolation within the bucket holding the quantile.
+// The overflow bucket is interpolated up to max, the slowest observation.
+func histogramQuantile(bounds []float64, counts []int64, max, q float64) float64 {
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var seen int64
+	lower := 0.0
+	for i, count := range counts {
+		upper := max
+		if i < len(bounds) {
+			upper = bounds[i]
+		}
+		if count > 0 && float64(seen+count) >= rank {
+			if upper < lower {
+				upper = lower
+			}
+			fraction := (rank - float64(seen)) / float64(count)
+			if value := lower + (upper-lower)*fraction; value < max {
+				return value
+			}
+			return max
+		}
+		seen += count
+		if i < len(bounds) {
+			lower = bounds[i]
+		}
+	}
+
+	return max
+}
+
+// GetSpanMetricAggregates computes call count, error rate and latency
+// percentiles from span metrics, grouped by the given fields. Percentiles are
+// estimated from the stored duration histograms.
+func GetSpanMetricAggregates(filter SpanMetricFilter, groupBy []string) ([]models.MetricAggregate, error) {
+	query, params := buildSpanMetricAggregateQuery(filter, groupBy)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error aggregating span metrics:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := make([]models.MetricAggregate, 0)
+
+	for rows.Next() {
+		var row spanMetricRow
+		groupValues := make([]sql.NullString, len(groupBy))
+
+		dest := make([]any, 0, len(groupBy)+len(models.SpanDurationBounds)+5)
+		for i := range groupValues {
+			dest = append(dest, &groupValues[i])
+		}
+		dest = append(dest, row.dest()...)
+
+		if err := rows.Scan(dest...); err != nil {
+			log.Println("❌ Error scanning span metric aggregate row:", err)
+			continue
+		}
+
+		agg := spanMetricAggregate(row)
+		agg.Group = make(map[string]string, len(groupBy))
+		for i, field := range groupBy {
+			agg.Group[field] = groupValues[i].String
+		}
+
+		aggregates = append(aggregates, agg)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return aggregates, nil
+}
+
+func spanMetricAggregate(row spanMetricRow) models.MetricAggregate {
+	agg := models.MetricAggregate{
+		Count:     row.calls,
+		ErrorRate: row.errorRate(),
+		P50Ms:     row.quantile(0.5),
+		P90Ms:     row.quantile(0.9),
+		P95Ms:     row.quantile(0.95),
+		P99Ms:     row.quantile(0.99),
+	}
+	if row.calls > 0 {
+		agg.AvgMs = row.durationSum / float64(row.calls)
+	}
+	return agg
+}
+
+// GetSpanMetricSeries returns one point per step between the filter's start
+// and end time. Buckets without data are reported with zero values.
+func GetSpanMetricSeries(filter SpanMetricFilter, step time.Duration) ([]models.MetricSeriesPoint, error) {
+	query, params := buildSpanMetricSeriesQuery(filter, step)
+
+	rows, err := db.DB.QueryContext(context.Background(), query, params...)
+	if err != nil {
+		log.Println("❌ Error fetching span metric series:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]models.MetricSeriesPoint, 0)
+
+	for rows.Next() {
+		var row spanMetricRow
+		var timestamp time.Time
+
+		if err := rows.Scan(append([]any{&timestamp}, row.dest()...)...); err != nil {
+			log.Println("❌ Error scanning span metric series row:", err)
+			continue
+		}
+
+		points = append(points, models.MetricSeriesPoint{
+			Timestamp:   timestamp,
+			Count:       row.calls,
+			RequestRate: float64(row.calls) / step.Seconds(),
+			ErrorRate:   row.errorRate(),
+			P50Ms:       row.quantile(0.5),
+			P90Ms:       row.quantile(0.9),
+			P95Ms:       row.quantile(0.95),
+			P99Ms:       row.quantile(0.99),
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("❌ Row iteration error: %v", err)
+		return nil, err
+	}
+
+	return points, nil
+}
+
+func GetSpanMetricsAggregateHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSpanMetricFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.StartTime.IsZero() {
+		filter.StartTime = time.Now().Add(-defaultAggregateWindow)
+	}
+
+	groupBy, err := parseSpanMetricGroupBy(r.URL.Query().Get("group_by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	aggregates, err := GetSpanMetricAggregates(filter, groupBy)
+	if err != nil {
+		http.Error(w, "Failed to aggregate span metrics", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aggregates)
+}
+
+func GetSpanMetricsSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	step, err := parseStep(r.URL.Query().Get("step"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if step < minSpanMetricsStep || step%minSpanMetricsStep != 0 {
+		http.Error(w, fmt.Sprintf("step must be a multiple of %s", minSpanMetricsStep), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseSpanMetricFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rangeFilter := MetricFilter{StartTime: filter.StartTime, EndTime: filter.EndTime}
+	if err := resolveSeriesRange(&rangeFilter, step, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.StartTime, filter.EndTime = rangeFilter.StartTime, rangeFilter.EndTime
+
+	points, err := GetSpanMetricSeries(filter, step)
+	if err != nil {
+		http.Error(w, "Failed to fetch span metric series", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(points)
+}
+
+// SpanMetricsJob materializes RED metrics from recently completed spans.
+func SpanMetricsJob(interval time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "span-metrics",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			_, err := db.MaterializeSpanMetrics(ctx, now.Add(-spanMetricsLookback), now)
+			return err
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	bounds := []float64{10, 100}
+
+	cases := []struct {
+		name   string
+		counts []int64
+		max    float64
+		q      float64
+		want   float64
+	}{
+		{"empty", []int64{0, 0, 0}, 0, 0.5, 0},
+		{"first bucket", []int64{10, 0, 0}, 8, 0.5, 5},
+		{"capped at max", []int64{10, 0, 0}, 4, 0.99, 4},
+		{"second bucket", []int64{50, 50, 0}, 90, 0.75, 55},
+		{"overflow bucket", []int64{0, 0, 4}, 500, 0.5, 300},
+	}
+
+	for _, tc := range cases {
+		got := histogramQuantile(bounds, tc.counts, tc.max, tc.q)
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBuildSpanMetricAggregateQuery(t *testing.T) {
+	filter := SpanMetricFilter{Service: "users", StartTime: time.Unix(0, 0)}
+
+	query, params := buildSpanMetricAggregateQuery(filter, []string{"operation"})
+
+	for _, want := range []string{
+		"SELECT operation, COALESCE(SUM(call_count), 0)",
+		"COALESCE(SUM(duration_buckets[12]), 0)",
+		"FROM span_metrics WHERE 1=1 AND service = $1 AND bucket >= date_trunc('minute', $2::timestamp)",
+		"GROUP BY operation",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query %q missing %q", query, want)
+		}
+	}
+	if len(params) != 2 {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestBuildSpanMetricSeriesQuery(t *testing.T) {
+	filter := SpanMetricFilter{Operation: "query_users", StartTime: time.Unix(0, 0), EndTime: time.Unix(3600, 0)}
+
+	query, params := buildSpanMetricSeriesQuery(filter, 5*time.Minute)
+
+	if !strings.Contains(query, "operation = $4") || !strings.Contains(query, "COALESCE(d.h12, 0)") {
+		t.Errorf("unexpected query %q", query)
+	}
+	if len(params) != 6 || params[0] != 300.0 {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestParseSpanMetricGroupBy(t *testing.T) {
+	groupBy, err := parseSpanMetricGroupBy("service, operation,service")
+	if err != nil || len(groupBy) != 2 {
+		t.Errorf("unexpected group_by %v (err %v)", groupBy, err)
+	}
+
+	if _, err := parseSpanMetricGroupBy("path"); err == nil {
+		t.Errorf("expected error for unsupported field")
+	}
+}
+
+func TestGetSpanMetricsSeriesHandlerRejectsSubMinuteStep(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/spans/metrics/series?step=30s", nil)
+	rec := httptest.NewRecorder()
+
+	GetSpanMetricsSeriesHandler(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
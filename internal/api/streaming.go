@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/stream"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamBufferSize is how many events a live client may fall behind
+	// before it is disconnected.
+	streamBufferSize = 256
+	// streamHeartbeat is the interval of keep-alive comments (SSE) and pings
+	// (WebSocket) on idle streams.
+	streamHeartbeat = 15 * time.Second
+	// streamWriteTimeout bounds a single WebSocket write.
+	streamWriteTimeout = 10 * time.Second
+)
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// streamMessage is the WebSocket envelope of an event. Server-Sent Events
+// carry the name in the event field instead.
+type streamMessage struct {
+	Event string `json:"event"`
+	Data  any    `json:"data"`
+}
+
+// serveStream writes the subscription's events to the client until either
+// side goes away, as a WebSocket when the request asks for an upgrade and as
+// Server-Sent Events otherwise. The subscription is closed on return.
+func serveStream[T any](w http.ResponseWriter, r *http.Request, sub *stream.Subscription[T], event string) {
+	defer sub.Close()
+
+	if websocket.IsWebSocketUpgrade(r) {
+		serveWebSocketStream(w, r, sub, event)
+		return
+	}
+	serveSSEStream(w, r, sub, event)
+}
+
+func serveSSEStream[T any](w http.ResponseWriter, r *http.Request, sub *stream.Subscription[T], event string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case payload, ok := <-sub.C:
+			if !ok {
+				if err := sub.Err(); err != nil {
+					data, _ := json.Marshal(map[string]string{"error": err.Error()})
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+					flusher.Flush()
+				}
+				return
+			}
+			data, err := json.Marshal(payload)
+			if err != nil {
+				log.Printf("❌ Error encoding %s event: %v", event, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		}
+		flusher.Flush()
+	}
+}
+
+func serveWebSocketStream[T any](w http.ResponseWriter, r *http.Request, sub *stream.Subscription[T], event string) {
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client.
+		log.Printf("❌ WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// Clients only send control frames; reading processes them and notices
+	// when the connection goes away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			deadline := time.Now().Add(streamWriteTimeout)
+			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case payload, ok := <-sub.C:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if !ok {
+				code, reason := websocket.CloseNormalClosure, ""
+				if err := sub.Err(); err != nil {
+					code, reason = websocket.CloseTryAgainLater, err.Error()
+				}
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+				return
+			}
+			if err := conn.WriteJSON(streamMessage{Event: event, Data: payload}); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// GetTraceAnalysisHandler returns the critical path of a trace.
+func GetTraceAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	traceID := mux.Vars(r)["traceId"]
+	if traceID == "" {
+		http.Error(w, "Trace ID is required", http.StatusBadRequest)
+		return
+	}
+
+	spans, err := db.FetchSpans(traceID)
+	if err != nil {
+		log.Printf("❌ Error fetching spans: %v", err)
+		http.Error(w, "Error fetching spans", http.StatusInternalServerError)
+		return
+	}
+
+	if len(spans) == 0 {
+		http.Error(w, "No spans found for trace", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analyzeTrace(traceID, spans))
+}
+
+// analyzeTrace computes the critical path: walking back from the end of the
+// trace, at each point in time the span on the path is the deepest one whose
+// completion the caller was waiting for. Among concurrent children the one
+// finishing last is the one the parent waited on. Spans that have not ended
+// are ignored.
+func analyzeTrace(traceID string, spans []models.Span) models.TraceAnalysis {
+	tree := buildTraceTree(models.Trace{ID: traceID}, spans)
+
+	analysis := models.TraceAnalysis{
+		TraceID:      traceID,
+		CriticalPath: []models.CriticalPathSpan{},
+		Segments:     []models.CriticalPathSegment{},
+	}
+
+	var first, last time.Time
+	for _, span := range spans {
+		start, end, ok := spanInterval(span)
+		if !ok {
+			continue
+		}
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if end.After(last) {
+			last = end
+		}
+	}
+	if first.IsZero() {
+		return analysis
+	}
+	analysis.Duration = msBetween(first, last)
+
+	// The roots are walked as children of a virtual span covering the whole
+	// trace, so gaps between disconnected roots are left off the path.
+	walker := &criticalPathWalker{}
+	walker.walk(nil, tree.Roots, first, last)
+
+	segments := walker.segments
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	analysis.Segments = append(analysis.Segments, segments...)
+
+	index := map[string]int{}
+	nodes := map[string]*models.SpanNode{}
+	collectSpanNodes(tree.Roots, nodes)
+	for _, segment := range segments {
+		i, ok := index[segment.SpanID]
+		if !ok {
+			node := nodes[segment.SpanID]
+			i = len(analysis.CriticalPath)
+			index[segment.SpanID] = i
+			analysis.CriticalPath = append(analysis.CriticalPath, models.CriticalPathSpan{
+				SpanID:    node.ID,
+				Service:   node.Service,
+				Operation: node.Operation,
+			})
+		}
+		analysis.CriticalPath[i].Contribution += segment.Duration
+	}
+
+	for i := range analysis.CriticalPath {
+		if analysis.Duration > 0 {
+			analysis.CriticalPath[i].Percent = analysis.CriticalPath[i].Contribution / analysis.Duration * 100
+		}
+	}
+
+	return analysis
+}
+
+// criticalPathWalker collects critical path segments latest first.
+type criticalPathWalker struct {
+	segments []models.CriticalPathSegment
+}
+
+// walk attributes the window [lo, hi] of owner to owner and its children.
+// A nil owner is the virtual trace root whose own time is not recorded.
+func (w *criticalPathWalker) walk(owner *models.SpanNode, children []*models.SpanNode, lo, hi time.Time) {
+	cursor := hi
+	used := make([]bool, len(children))
+
+	for cursor.After(lo) {
+		best := -1
+		var bestEnd time.Time
+		for i, child := range children {
+			if used[i] {
+				continue
+			}
+			start, end, ok := spanInterval(child.Span)
+			if !ok || !start.Before(cursor) {
+				continue
+			}
+			if end.After(cursor) {
+				end = cursor
+			}
+			if !end.After(lo) {
+				continue
+			}
+			if best == -1 || end.After(bestEnd) {
+				best, bestEnd = i, end
+			}
+		}
+		if best == -1 {
+			break
+		}
+		used[best] = true
+
+		child := children[best]
+		childStart := child.StartTime
+		if childStart.Before(lo) {
+			childStart = lo
+		}
+
+		w.emit(owner, bestEnd, cursor)
+		w.walk(child, child.Children, childStart, bestEnd)
+		cursor = childStart
+	}
+
+	w.emit(owner, lo, cursor)
+}
+
+func (w *criticalPathWalker) emit(owner *models.SpanNode, start, end time.Time) {
+	if owner == nil || !end.After(start) {
+		return
+	}
+	w.segments = append(w.segments, models.CriticalPathSegment{
+		SpanID:    owner.ID,
+		StartTime: start,
+		EndTime:   end,
+		Duration:  msBetween(start, end),
+	})
+}
+
+func collectSpanNodes(nodes []*models.SpanNode, into map[string]*models.SpanNode) {
+	for _, node := range nodes {
+		if _, seen := into[node.ID]; seen {
+			continue
+		}
+		into[node.ID] = node
+		collectSpanNodes(node.Children, into)
+	}
+}
+
+func msBetween(start, end time.Time) float64 {
+	return float64(end.Sub(start)) / float64(time.Millisecond)
+}
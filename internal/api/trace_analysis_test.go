@@ -0,0 +1,75 @@
+package api
+
+import (
+	"math"
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestAnalyzeTraceCriticalPath(t *testing.T) {
+	spans := []models.Span{
+		testSpan("root", "", "gateway", 0, 100),
+		testSpan("auth", "root", "auth", 10, 30),
+		testSpan("db", "root", "users", 20, 50),
+		testSpan("query", "db", "postgres", 25, 20),
+	}
+
+	analysis := analyzeTrace("trace", spans)
+
+	if analysis.Duration != 100 {
+		t.Errorf("duration = %v, want 100", analysis.Duration)
+	}
+
+	// auth runs concurrently with db until db takes over at 20ms, so only
+	// its first 10ms are on the critical path.
+	want := []struct {
+		id           string
+		contribution float64
+	}{
+		{"root", 40},
+		{"auth", 10},
+		{"db", 30},
+		{"query", 20},
+	}
+	if len(analysis.CriticalPath) != len(want) {
+		t.Fatalf("critical path = %+v", analysis.CriticalPath)
+	}
+	for i, w := range want {
+		got := analysis.CriticalPath[i]
+		if got.SpanID != w.id || math.Abs(got.Contribution-w.contribution) > 1e-6 {
+			t.Errorf("path[%d] = %s %.3fms, want %s %.3fms", i, got.SpanID, got.Contribution, w.id, w.contribution)
+		}
+		if math.Abs(got.Percent-w.contribution) > 1e-6 {
+			t.Errorf("path[%d] percent = %v", i, got.Percent)
+		}
+	}
+
+	var total float64
+	for i, segment := range analysis.Segments {
+		total += segment.Duration
+		if i > 0 && segment.StartTime.Before(analysis.Segments[i-1].EndTime) {
+			t.Errorf("segments out of order at %d", i)
+		}
+	}
+	if math.Abs(total-100) > 1e-6 {
+		t.Errorf("segments cover %vms, want 100", total)
+	}
+}
+
+func TestAnalyzeTraceDisconnectedRoots(t *testing.T) {
+	spans := []models.Span{
+		testSpan("first", "", "gateway", 0, 30),
+		testSpan("orphan", "missing", "worker", 50, 20),
+		testSpan("open", "first", "gateway", 5, 0),
+	}
+
+	analysis := analyzeTrace("trace", spans)
+
+	if analysis.Duration != 70 || len(analysis.CriticalPath) != 2 {
+		t.Fatalf("unexpected analysis: %+v", analysis)
+	}
+	if analysis.CriticalPath[0].SpanID != "first" || analysis.CriticalPath[1].SpanID != "orphan" {
+		t.Errorf("unexpected path: %+v", analysis.CriticalPath)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultTraceLogsLimit = 1000
+	maxTraceLogsLimit     = 10000
+)
+
+// GetTraceLogs returns up to limit logs recorded for a trace, oldest first.
+// Log trace IDs are UUIDs, so traces with other IDs have no logs.
+func GetTraceLogs(traceID string, limit int) ([]models.Log, error) {
+	parsed, err := uuid.Parse(traceID)
+	if err != nil {
+		return []models.Log{}, nil
+	}
+
+	query := `SELECT id, service_name, log_level, message, timestamp, trace_id, span_id, metadata
+              FROM logs WHERE trace_id = $1
+              ORDER BY timestamp ASC, id ASC
+              LIMIT $2`
+
+	rows, err := db.DB.QueryContext(context.Background(), query, parsed.String(), limit)
+	if err != nil {
+		log.Println("❌ Error fetching trace logs:", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLogs(rows)
+}
+
+// groupLogsBySpan groups time ordered logs by span, ordering the groups by
+// their first log. Logs without a span ID are returned as unattributed.
+func groupLogsBySpan(traceID string, logs []models.Log) models.TraceLogs {
+	result := models.TraceLogs{
+		TraceID:      traceID,
+		Count:        len(logs),
+		Spans:        []models.SpanLogs{},
+		Unattributed: []models.Log{},
+	}
+
+	index := map[string]int{}
+	for _, entry := range logs {
+		if !entry.SpanID.Valid || entry.SpanID.String == "" {
+			result.Unattributed = append(result.Unattributed, entry)
+			continue
+		}
+
+		i, ok := index[entry.SpanID.String]
+		if !ok {
+			i = len(result.Spans)
+			index[entry.SpanID.String] = i
+			result.Spans = append(result.Spans, models.SpanLogs{SpanID: entry.SpanID.String})
+		}
+		result.Spans[i].Logs = append(result.Spans[i].Logs, entry)
+	}
+
+	return result
+}
+
+// attachLogsToTree embeds each log in the node of its span. Logs without a
+// span, or whose span is not part of the tree, are kept on the tree itself.
+func attachLogsToTree(tree *models.TraceTree, logs []models.Log) {
+	nodes := map[string]*models.SpanNode{}
+	collectSpanNodes(tree.Roots, nodes)
+
+	tree.UnattributedLogs = []models.Log{}
+	for _, entry := range logs {
+		if node, ok := nodes[entry.SpanID.String]; ok && entry.SpanID.Valid {
+			node.Logs = append(node.Logs, entry)
+			continue
+		}
+		tree.UnattributedLogs = append(tree.UnattributedLogs, entry)
+	}
+}
+
+// parseTraceLogsLimit reads the limit parameter, capped at maxTraceLogsLimit.
+func parseTraceLogsLimit(r *http.Request) int {
+	limit, _ := parseLimitOffset(r)
+	if !r.URL.Query().Has("limit") {
+		limit = defaultTraceLogsLimit
+	}
+	if limit > maxTraceLogsLimit {
+		limit = maxTraceLogsLimit
+	}
+	return limit
+}
+
+func GetTraceLogsHandler(w http.ResponseWriter, r *http.Request) {
+	traceID := mux.Vars(r)["traceId"]
+	if traceID == "" {
+		http.Error(w, "Trace ID is required", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := GetTraceLogs(traceID, parseTraceLogsLimit(r))
+	if err != nil {
+		http.Error(w, "Error fetching trace logs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groupLogsBySpan(traceID, logs))
+}
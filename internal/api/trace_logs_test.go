@@ -0,0 +1,99 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func traceLog(id int, spanID string) models.Log {
+	return models.Log{
+		ID:      id,
+		TraceID: sql.NullString{String: "trace", Valid: true},
+		SpanID:  sql.NullString{String: spanID, Valid: spanID != ""},
+	}
+}
+
+func TestGroupLogsBySpan(t *testing.T) {
+	logs := []models.Log{
+		traceLog(1, "db"),
+		traceLog(2, ""),
+		traceLog(3, "root"),
+		traceLog(4, "db"),
+	}
+
+	grouped := groupLogsBySpan("trace", logs)
+
+	if grouped.Count != 4 || len(grouped.Spans) != 2 || len(grouped.Unattributed) != 1 {
+		t.Fatalf("unexpected grouping: %+v", grouped)
+	}
+	if grouped.Spans[0].SpanID != "db" || len(grouped.Spans[0].Logs) != 2 || grouped.Spans[0].Logs[1].ID != 4 {
+		t.Errorf("unexpected first group: %+v", grouped.Spans[0])
+	}
+	if grouped.Spans[1].SpanID != "root" {
+		t.Errorf("expected groups ordered by first log, got %+v", grouped.Spans)
+	}
+}
+
+func TestAttachLogsToTree(t *testing.T) {
+	tree := buildTraceTree(models.Trace{ID: "trace"}, []models.Span{
+		testSpan("root", "", "gateway", 0, 100),
+		testSpan("db", "root", "users", 10, 50),
+	})
+
+	attachLogsToTree(&tree, []models.Log{
+		traceLog(1, "db"),
+		traceLog(2, "root"),
+		traceLog(3, "unknown"),
+		traceLog(4, ""),
+	})
+
+	root := tree.Roots[0]
+	if len(root.Logs) != 1 || root.Logs[0].ID != 2 {
+		t.Errorf("unexpected root logs: %+v", root.Logs)
+	}
+	if len(root.Children[0].Logs) != 1 || root.Children[0].Logs[0].ID != 1 {
+		t.Errorf("unexpected db logs: %+v", root.Children[0].Logs)
+	}
+	if len(tree.UnattributedLogs) != 2 {
+		t.Errorf("expected 2 unattributed logs, got %+v", tree.UnattributedLogs)
+	}
+}
+
+func TestParseTraceLogsLimit(t *testing.T) {
+	cases := map[string]int{
+		"":             defaultTraceLogsLimit,
+		"?limit=50":    50,
+		"?limit=50000": maxTraceLogsLimit,
+	}
+	for rawQuery, want := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/traces/trace/logs"+rawQuery, nil)
+		if got := parseTraceLogsLimit(req); got != want {
+			t.Errorf("parseTraceLogsLimit(%q) = %d, want %d", rawQuery, got, want)
+		}
+	}
+}
+
+func TestGetTraceLogsHandlerNonUUIDTrace(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/traces/t-ended/logs", nil)
+	req = mux.SetURLVars(req, map[string]string{"traceId": "t-ended"})
+	rec := httptest.NewRecorder()
+
+	GetTraceLogsHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var got models.TraceLogs
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.TraceID != "t-ended" || got.Count != 0 || len(got.Spans) != 0 {
+		t.Errorf("expected no logs, got %+v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/NathanSanchezDev/go-insight/internal/stream"
+)
+
+// traceHub fans trace and span start and end events out to live clients.
+var traceHub = stream.NewHub[models.TraceEvent]()
+
+func publishTraceEvent(eventType string, trace models.Trace) {
+	event := models.TraceEvent{
+		Type:      eventType,
+		TraceID:   trace.ID,
+		Service:   trace.ServiceName,
+		Timestamp: trace.StartTime,
+	}
+	if eventType == models.TraceEventTraceEnd {
+		event.Timestamp = trace.EndTime.Time
+		event.Duration = trace.Duration.Float64
+	}
+	traceHub.Publish(event)
+}
+
+func publishSpanEvent(eventType string, span models.Span) {
+	event := models.TraceEvent{
+		Type:      eventType,
+		TraceID:   span.TraceID,
+		SpanID:    span.ID,
+		ParentID:  span.ParentID,
+		Service:   span.Service,
+		Operation: span.Operation,
+		Status:    span.Status,
+		Timestamp: span.StartTime,
+	}
+	if eventType == models.TraceEventSpanEnd {
+		event.Timestamp = span.EndTime
+		event.Duration = span.Duration
+	}
+	traceHub.Publish(event)
+}
+
+// StreamTracesHandler streams trace and span start and end events,
+// optionally limited to one service or trace.
+func StreamTracesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	service, traceID := q.Get("service"), q.Get("trace_id")
+
+	sub := traceHub.Subscribe(streamBufferSize, func(event models.TraceEvent) bool {
+		return (service == "" || event.Service == service) &&
+			(traceID == "" || event.TraceID == traceID)
+	})
+	serveStream(w, r, sub, "trace")
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+func TestStreamTracesHandlerFiltersByTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(StreamTracesHandler))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?trace_id=t1"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	waitForSubscribers(t, traceHub, 1)
+
+	start := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	publishSpanEvent(models.TraceEventSpanStart, models.Span{ID: "s0", TraceID: "t0", Service: "api", StartTime: start})
+	publishSpanEvent(models.TraceEventSpanEnd, models.Span{
+		ID:        "s1",
+		TraceID:   "t1",
+		Service:   "api",
+		Operation: "GET /users",
+		StartTime: start,
+		EndTime:   start.Add(40 * time.Millisecond),
+		Duration:  40,
+	})
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg struct {
+		Event string            `json:"event"`
+		Data  models.TraceEvent `json:"data"`
+	}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+
+	got := msg.Data
+	if msg.Event != "trace" || got.Type != models.TraceEventSpanEnd || got.SpanID != "s1" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if got.Duration != 40 || !got.Timestamp.Equal(start.Add(40*time.Millisecond)) {
+		t.Errorf("expected end timestamp and duration, got %+v", got)
+	}
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// GetTraceHandler returns a single trace with its spans assembled into a tree.
+// With include=logs, the trace's logs are embedded in the nodes of their
+// spans.
+func GetTraceHandler(w http.ResponseWriter, r *http.Request) {
+	traceID := mux.Vars(r)["traceId"]
+	if traceID == "" {
+		http.Error(w, "Trace ID is required", http.StatusBadRequest)
+		return
+	}
+
+	trace, err := GetTraceByID(traceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Trace not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error fetching trace", http.StatusInternalServerError)
+		return
+	}
+
+	spans, err := db.FetchSpans(traceID)
+	if err != nil {
+		log.Printf("❌ Error fetching spans: %v", err)
+		http.Error(w, "Error fetching spans", http.StatusInternalServerError)
+		return
+	}
+
+	tree := buildTraceTree(*trace, spans)
+
+	if r.URL.Query().Get("include") == "logs" {
+		logs, err := GetTraceLogs(traceID, parseTraceLogsLimit(r))
+		if err != nil {
+			http.Error(w, "Error fetching trace logs", http.StatusInternalServerError)
+			return
+		}
+		attachLogsToTree(&tree, logs)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// buildTraceTree links spans to their parents. Spans whose parent is absent,
+// or that are only reachable through a parent cycle, become roots so that no
+// span is dropped from the response.
+func buildTraceTree(trace models.Trace, spans []models.Span) models.TraceTree {
+	nodes := make(map[string]*models.SpanNode, len(spans))
+	ordered := make([]*models.SpanNode, 0, len(spans))
+	for _, span := range spans {
+		if _, dup := nodes[span.ID]; dup {
+			continue
+		}
+		node := &models.SpanNode{Span: span, Children: []*models.SpanNode{}}
+		nodes[span.ID] = node
+		ordered = append(ordered, node)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].StartTime.Before(ordered[j].StartTime)
+	})
+
+	tree := models.TraceTree{
+		Trace:     trace,
+		Services:  []string{},
+		SpanCount: len(ordered),
+		Roots:     []*models.SpanNode{},
+	}
+
+	for _, node := range ordered {
+		if node.ParentID == "" || node.ParentID == node.ID {
+			tree.Roots = append(tree.Roots, node)
+			continue
+		}
+		parent, ok := nodes[node.ParentID]
+		if !ok {
+			node.MissingParent = true
+			tree.Roots = append(tree.Roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	visited := make(map[string]bool, len(ordered))
+	for _, root := range tree.Roots {
+		annotateSpanNode(root, 0, visited)
+	}
+
+	// Whatever is left unvisited hangs off a parent cycle; break the cycle at
+	// its earliest span.
+	for _, node := range ordered {
+		if visited[node.ID] {
+			continue
+		}
+		if parent := nodes[node.ParentID]; parent != nil {
+			parent.Children = removeSpanNode(parent.Children, node)
+		}
+		node.MissingParent = true
+		tree.Roots = append(tree.Roots, node)
+		annotateSpanNode(node, 0, visited)
+	}
+
+	seen := map[string]bool{}
+	for _, node := range ordered {
+		if node.Service != "" && !seen[node.Service] {
+			seen[node.Service] = true
+			tree.Services = append(tree.Services, node.Service)
+		}
+	}
+	sort.Strings(tree.Services)
+
+	return tree
+}
+
+func annotateSpanNode(node *models.SpanNode, depth int, visited map[string]bool) {
+	visited[node.ID] = true
+	node.Depth = depth
+	node.SelfTime = spanSelfTime(node)
+	for _, child := range node.Children {
+		if !visited[child.ID] {
+			annotateSpanNode(child, depth+1, visited)
+		}
+	}
+}
+
+func removeSpanNode(nodes []*models.SpanNode, target *models.SpanNode) []*models.SpanNode {
+	for i, node := range nodes {
+		if node == target {
+			return append(nodes[:i], nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
+
+// spanSelfTime is the span's duration minus the time covered by at least one
+// child, so overlapping children are not subtracted twice and children
+// running past the parent's end only count up to it.
+func spanSelfTime(node *models.SpanNode) float64 {
+	start, end, ok := spanInterval(node.Span)
+	if !ok {
+		return 0
+	}
+
+	type interval struct{ start, end time.Time }
+	var covered []interval
+	for _, child := range node.Children {
+		childStart, childEnd, ok := spanInterval(child.Span)
+		if !ok {
+			continue
+		}
+		if childStart.Before(start) {
+			childStart = start
+		}
+		if childEnd.After(end) {
+			childEnd = end
+		}
+		if childEnd.After(childStart) {
+			covered = append(covered, interval{childStart, childEnd})
+		}
+	}
+
+	sort.Slice(covered, func(i, j int) bool { return covered[i].start.Before(covered[j].start) })
+
+	var busy time.Duration
+	var cursor time.Time
+	for _, iv := range covered {
+		if iv.start.Before(cursor) {
+			iv.start = cursor
+		}
+		if iv.end.After(iv.start) {
+			busy += iv.end.Sub(iv.start)
+			cursor = iv.end
+		}
+	}
+
+	self := node.Duration - float64(busy)/float64(time.Millisecond)
+	if self < 0 {
+		return 0
+	}
+	return self
+}
+
+// spanInterval returns the span's start and end, derived from its duration.
+// Spans that have not ended report ok=false.
+func spanInterval(span models.Span) (time.Time, time.Time, bool) {
+	if span.Duration <= 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	end := span.StartTime.Add(time.Duration(span.Duration * float64(time.Millisecond)))
+	return span.StartTime, end, true
+}
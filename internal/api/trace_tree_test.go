@@ -0,0 +1,110 @@
+package api
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func testSpan(id, parent, service string, startMs, durationMs float64) models.Span {
+	base := time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	start := base.Add(time.Duration(startMs * float64(time.Millisecond)))
+	return models.Span{
+		ID:        id,
+		TraceID:   "trace",
+		ParentID:  parent,
+		Service:   service,
+		Operation: id,
+		StartTime: start,
+		EndTime:   start.Add(time.Duration(durationMs * float64(time.Millisecond))),
+		Duration:  durationMs,
+	}
+}
+
+func TestBuildTraceTree(t *testing.T) {
+	spans := []models.Span{
+		testSpan("root", "", "gateway", 0, 100),
+		testSpan("auth", "root", "auth", 10, 30),
+		testSpan("db", "root", "users", 20, 50),
+		testSpan("query", "db", "postgres", 25, 20),
+	}
+
+	tree := buildTraceTree(models.Trace{ID: "trace"}, spans)
+
+	if tree.SpanCount != 4 || len(tree.Roots) != 1 {
+		t.Fatalf("unexpected tree: %+v", tree)
+	}
+	if want := []string{"auth", "gateway", "postgres", "users"}; !reflect.DeepEqual(tree.Services, want) {
+		t.Errorf("services = %v, want %v", tree.Services, want)
+	}
+
+	root := tree.Roots[0]
+	if len(root.Children) != 2 || root.Children[0].ID != "auth" || root.Children[1].ID != "db" {
+		t.Fatalf("unexpected root children: %+v", root.Children)
+	}
+
+	// Children overlap between 10ms and 70ms, leaving 40ms of root self-time.
+	if math.Abs(root.SelfTime-40) > 1e-6 {
+		t.Errorf("root self time = %v, want 40", root.SelfTime)
+	}
+
+	db := root.Children[1]
+	if db.Depth != 1 || math.Abs(db.SelfTime-30) > 1e-6 {
+		t.Errorf("db depth %d self time %v", db.Depth, db.SelfTime)
+	}
+	if query := db.Children[0]; query.Depth != 2 || math.Abs(query.SelfTime-20) > 1e-6 {
+		t.Errorf("query depth %d self time %v", query.Depth, query.SelfTime)
+	}
+}
+
+func TestBuildTraceTreeMissingParent(t *testing.T) {
+	spans := []models.Span{
+		testSpan("root", "", "gateway", 0, 100),
+		testSpan("orphan", "lost", "worker", 10, 20),
+		testSpan("child", "orphan", "worker", 12, 5),
+	}
+
+	tree := buildTraceTree(models.Trace{ID: "trace"}, spans)
+
+	if len(tree.Roots) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(tree.Roots))
+	}
+	orphan := tree.Roots[1]
+	if !orphan.MissingParent || orphan.Depth != 0 || len(orphan.Children) != 1 {
+		t.Errorf("unexpected orphan node: %+v", orphan)
+	}
+	if orphan.Children[0].Depth != 1 {
+		t.Errorf("orphan child depth = %d, want 1", orphan.Children[0].Depth)
+	}
+}
+
+func TestBuildTraceTreeCycle(t *testing.T) {
+	spans := []models.Span{
+		testSpan("a", "b", "svc", 0, 10),
+		testSpan("b", "a", "svc", 1, 5),
+	}
+
+	tree := buildTraceTree(models.Trace{ID: "trace"}, spans)
+
+	if len(tree.Roots) != 1 || tree.Roots[0].ID != "a" || !tree.Roots[0].MissingParent {
+		t.Fatalf("unexpected roots: %+v", tree.Roots)
+	}
+	if len(tree.Roots[0].Children) != 1 || tree.Roots[0].Children[0].ID != "b" {
+		t.Errorf("expected b under a, got %+v", tree.Roots[0].Children)
+	}
+}
+
+func TestSpanSelfTimeUnfinished(t *testing.T) {
+	parent := &models.SpanNode{Span: testSpan("p", "", "svc", 0, 50)}
+	parent.Children = []*models.SpanNode{{Span: testSpan("c", "p", "svc", 10, 0)}}
+
+	if got := spanSelfTime(parent); got != 50 {
+		t.Errorf("self time = %v, want 50", got)
+	}
+	if got := spanSelfTime(parent.Children[0]); got != 0 {
+		t.Errorf("unfinished span self time = %v, want 0", got)
+	}
+}
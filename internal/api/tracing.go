@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NathanSanchezDev/go-insight/internal/db"
@@ -17,39 +19,187 @@ import (
 	"github.com/gorilla/mux"
 )
 
-func GetTracesHandler(w http.ResponseWriter, r *http.Request) {
-	serviceName := r.URL.Query().Get("service")
+// TraceFilter holds the optional filters for trace queries. The span
+// filters select traces containing at least one span that matches all of
+// them.
+type TraceFilter struct {
+	ServiceName string
+	StartTime   time.Time
+	EndTime     time.Time
+	MinDuration float64
+	MaxDuration float64
+
+	Operation       string
+	SpanService     string
+	SpanStatus      string
+	MinSpanDuration float64
+	Attributes      []metadataCondition
+}
 
-	var startTime, endTime time.Time
-	if startTimeStr := r.URL.Query().Get("start_time"); startTimeStr != "" {
-		parsedTime, err := time.Parse(time.RFC3339, startTimeStr)
-		if err == nil {
-			startTime = parsedTime
-		}
+func (f TraceFilter) hasSpanFilter() bool {
+	return f.Operation != "" || f.SpanService != "" || f.SpanStatus != "" ||
+		f.MinSpanDuration > 0 || len(f.Attributes) > 0
+}
+
+// Columns are qualified because span filters run as a correlated subquery.
+func (f TraceFilter) whereClause(paramCount int) (string, []any, int) {
+	var clause string
+	var params []any
+
+	if f.ServiceName != "" {
+		clause += fmt.Sprintf(" AND traces.service_name = $%d", paramCount)
+		params = append(params, f.ServiceName)
+		paramCount++
 	}
 
-	if endTimeStr := r.URL.Query().Get("end_time"); endTimeStr != "" {
-		parsedTime, err := time.Parse(time.RFC3339, endTimeStr)
-		if err == nil {
-			endTime = parsedTime
-		}
+	if !f.StartTime.IsZero() {
+		clause += fmt.Sprintf(" AND traces.start_time >= $%d", paramCount)
+		params = append(params, f.StartTime)
+		paramCount++
+	}
+
+	if !f.EndTime.IsZero() {
+		clause += fmt.Sprintf(" AND traces.start_time <= $%d", paramCount)
+		params = append(params, f.EndTime)
+		paramCount++
+	}
+
+	if f.MinDuration > 0 {
+		clause += fmt.Sprintf(" AND traces.duration_ms >= $%d", paramCount)
+		params = append(params, f.MinDuration)
+		paramCount++
+	}
+
+	if f.MaxDuration > 0 {
+		clause += fmt.Sprintf(" AND traces.duration_ms <= $%d", paramCount)
+		params = append(params, f.MaxDuration)
+		paramCount++
+	}
+
+	if !f.hasSpanFilter() {
+		return clause, params, paramCount
 	}
 
-	limit := 100
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
+	clause += " AND EXISTS (SELECT 1 FROM spans WHERE spans.trace_id = traces.id"
+
+	if f.Operation != "" {
+		clause += fmt.Sprintf(" AND spans.operation = $%d", paramCount)
+		params = append(params, f.Operation)
+		paramCount++
+	}
+
+	if f.SpanService != "" {
+		clause += fmt.Sprintf(" AND spans.service = $%d", paramCount)
+		params = append(params, f.SpanService)
+		paramCount++
+	}
+
+	if f.SpanStatus != "" {
+		clause += fmt.Sprintf(" AND spans.status = $%d", paramCount)
+		params = append(params, f.SpanStatus)
+		paramCount++
+	}
+
+	if f.MinSpanDuration > 0 {
+		clause += fmt.Sprintf(" AND spans.duration_ms >= $%d", paramCount)
+		params = append(params, f.MinSpanDuration)
+		paramCount++
+	}
+
+	for _, cond := range f.Attributes {
+		var condClause string
+		var condParams []any
+		condClause, condParams, paramCount = cond.whereClause("spans.attributes", paramCount)
+		clause += condClause
+		params = append(params, condParams...)
+	}
+
+	clause += ")"
+
+	return clause, params, paramCount
+}
+
+// parseTraceFilter reads the trace filters from the query string. Malformed
+// times and numbers are ignored; an unknown status or invalid attribute
+// filter is an error.
+func parseTraceFilter(r *http.Request) (TraceFilter, error) {
+	q := r.URL.Query()
+	filter := TraceFilter{
+		ServiceName: q.Get("service"),
+		Operation:   q.Get("operation"),
+		SpanService: q.Get("span_service"),
+	}
+
+	if status := strings.ToUpper(q.Get("status")); status != "" {
+		if !validSpanStatuses[status] {
+			return filter, fmt.Errorf("invalid status: %s", q.Get("status"))
 		}
+		filter.SpanStatus = status
+	}
+
+	attributes, err := parseMetadataFilters(q, attributeParamPrefix)
+	if err != nil {
+		return filter, err
+	}
+	filter.Attributes = attributes
+
+	if parsed, err := time.Parse(time.RFC3339, q.Get("start_time")); err == nil {
+		filter.StartTime = parsed
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, q.Get("end_time")); err == nil {
+		filter.EndTime = parsed
+	}
+
+	if parsed, err := strconv.ParseFloat(q.Get("min_duration"), 64); err == nil {
+		filter.MinDuration = parsed
+	}
+
+	if parsed, err := strconv.ParseFloat(q.Get("max_duration"), 64); err == nil {
+		filter.MaxDuration = parsed
 	}
 
-	offset := 0
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
+	if parsed, err := strconv.ParseFloat(q.Get("min_span_duration"), 64); err == nil {
+		filter.MinSpanDuration = parsed
+	}
+
+	return filter, nil
+}
+
+func GetTracesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTraceFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, offset := parseLimitOffset(r)
+
+	if r.URL.Query().Has("cursor") {
+		cursor, err := decodeCursor(r.URL.Query().Get("cursor"), false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		traces, err := GetTraces(filter, cursor, limit+1, 0)
+		if err != nil {
+			http.Error(w, "Error fetching traces", http.StatusInternalServerError)
+			return
+		}
+
+		page := buildPage(traces, limit, func(t models.Trace) pageCursor {
+			return pageCursor{Timestamp: t.StartTime, ID: t.ID}
+		})
+		if page.TotalEstimate, err = EstimateTraces(filter); err != nil {
+			log.Printf("⚠️ Trace count estimate failed: %v", err)
 		}
+
+		writePage(w, page)
+		return
 	}
 
-	traces, err := GetTraces(serviceName, startTime, endTime, limit, offset)
+	traces, err := GetTraces(filter, nil, limit, offset)
 	if err != nil {
 		http.Error(w, "Error fetching traces", http.StatusInternalServerError)
 		return
@@ -59,39 +209,30 @@ func GetTracesHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(traces)
 }
 
-func GetTraces(serviceName string, startTime, endTime time.Time, limit, offset int) ([]models.Trace, error) {
+// GetTraces returns traces matching the filter, most recently started first.
+// When cursor is set only traces strictly after the cursor position are
+// returned and offset is ignored.
+func GetTraces(filter TraceFilter, cursor *pageCursor, limit, offset int) ([]models.Trace, error) {
 	query := `SELECT id, service_name, start_time, end_time, duration_ms 
               FROM traces WHERE 1=1`
 
-	var params []interface{}
-	paramCount := 1
+	where, params, paramCount := filter.whereClause(1)
+	query += where
 
-	if serviceName != "" {
-		query += fmt.Sprintf(" AND service_name = $%d", paramCount)
-		params = append(params, serviceName)
-		paramCount++
+	if cursor != nil {
+		query += fmt.Sprintf(" AND (traces.start_time, traces.id) < ($%d, $%d)", paramCount, paramCount+1)
+		params = append(params, cursor.Timestamp, cursor.ID)
+		paramCount += 2
 	}
 
-	if !startTime.IsZero() {
-		query += fmt.Sprintf(" AND start_time >= $%d", paramCount)
-		params = append(params, startTime)
-		paramCount++
-	}
-
-	if !endTime.IsZero() {
-		query += fmt.Sprintf(" AND start_time <= $%d", paramCount)
-		params = append(params, endTime)
-		paramCount++
-	}
-
-	query += " ORDER BY start_time DESC"
+	query += " ORDER BY traces.start_time DESC, traces.id DESC"
 
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", paramCount)
 		params = append(params, limit)
 		paramCount++
 
-		if offset > 0 {
+		if offset > 0 && cursor == nil {
 			query += fmt.Sprintf(" OFFSET $%d", paramCount)
 			params = append(params, offset)
 		}
@@ -126,6 +267,12 @@ func GetTraces(serviceName string, startTime, endTime time.Time, limit, offset i
 	return traces, nil
 }
 
+// EstimateTraces returns the planner's row estimate for the filter.
+func EstimateTraces(filter TraceFilter) (int64, error) {
+	where, params, _ := filter.whereClause(1)
+	return db.EstimateRows("SELECT 1 FROM traces WHERE 1=1"+where, params...)
+}
+
 func GetTraceByID(traceID string) (*models.Trace, error) {
 	query := `SELECT id, service_name, start_time, end_time, duration_ms 
 	          FROM traces WHERE id = $1`
@@ -187,6 +334,9 @@ func CreateTraceHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !checkIngestService(w, r, trace.ServiceName) {
+		return
+	}
 
 	if trace.ID == "" {
 		trace.ID = observability.GenerateUUID()
@@ -203,11 +353,19 @@ func CreateTraceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	publishTraceEvent(models.TraceEventTraceStart, trace)
+	if trace.EndTime.Valid {
+		publishTraceEvent(models.TraceEventTraceEnd, trace)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(trace)
 }
 
+// storeSpanFunc allows tests to mock span storage.
+var storeSpanFunc = db.StoreSpan
+
 func CreateSpanHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -224,26 +382,38 @@ func CreateSpanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if span.StartTime.IsZero() {
+		span.StartTime = time.Now()
+	}
+
 	if err := validateSpan(&span); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !checkIngestService(w, r, span.Service) {
+		return
+	}
 
 	if span.ID == "" {
 		span.ID = observability.GenerateUUID()
 	}
 
-	if span.StartTime.IsZero() {
-		span.StartTime = time.Now()
+	if !span.EndTime.IsZero() && span.Duration == 0 {
+		span.Duration = span.EndTime.Sub(span.StartTime).Seconds() * 1000
 	}
 
-	err = db.StoreSpan(span)
+	err = storeSpanFunc(span)
 	if err != nil {
 		log.Printf("❌ Error storing span: %v", err)
 		http.Error(w, "Failed to store span", http.StatusInternalServerError)
 		return
 	}
 
+	publishSpanEvent(models.TraceEventSpanStart, span)
+	if !span.EndTime.IsZero() {
+		publishSpanEvent(models.TraceEventSpanEnd, span)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(span)
@@ -263,6 +433,9 @@ func EndTraceHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Trace not found", http.StatusNotFound)
 		return
 	}
+	if !checkIngestService(w, r, trace.ServiceName) {
+		return
+	}
 
 	now := time.Now()
 	trace.EndTime = sql.NullTime{Time: now, Valid: true}
@@ -277,6 +450,7 @@ func EndTraceHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update trace", http.StatusInternalServerError)
 		return
 	}
+	publishTraceEvent(models.TraceEventTraceEnd, *trace)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(trace)
@@ -296,6 +470,21 @@ func EndSpanHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Span not found", http.StatusNotFound)
 		return
 	}
+	if !checkIngestService(w, r, span.Service) {
+		return
+	}
+
+	var req endSpanRequest
+	if err := decodeOptionalBody(r, &req); err != nil {
+		log.Printf("❌ Error decoding end span JSON: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.apply(&span); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	span.EndTime = time.Now()
 	span.Duration = span.EndTime.Sub(span.StartTime).Seconds() * 1000
@@ -306,11 +495,73 @@ func EndSpanHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to update span", http.StatusInternalServerError)
 		return
 	}
+	publishSpanEvent(models.TraceEventSpanEnd, span)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(span)
 }
 
+// endSpanRequest is the optional body accepted when ending a span, letting
+// instrumentation record the outcome once it is known.
+type endSpanRequest struct {
+	Status     string                     `json:"status,omitempty"`
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
+}
+
+// apply sets the span status and merges the attributes into the ones
+// recorded at span start, with the new values winning.
+func (req endSpanRequest) apply(span *models.Span) error {
+	if req.Status != "" {
+		if !validSpanStatuses[req.Status] {
+			return fmt.Errorf("invalid span status: %s", req.Status)
+		}
+		span.Status = req.Status
+	}
+
+	if len(req.Attributes) == 0 {
+		return nil
+	}
+
+	merged := map[string]json.RawMessage{}
+	if span.Attributes != nil {
+		if err := json.Unmarshal(*span.Attributes, &merged); err != nil {
+			merged = map[string]json.RawMessage{}
+		}
+	}
+	for key, value := range req.Attributes {
+		merged[key] = value
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	attributes := json.RawMessage(raw)
+	span.Attributes = &attributes
+	return nil
+}
+
+// decodeOptionalBody decodes a JSON body into v, treating an empty body as
+// no input.
+func decodeOptionalBody(r *http.Request, v any) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+var validSpanStatuses = map[string]bool{
+	models.SpanStatusUnset: true,
+	models.SpanStatusOK:    true,
+	models.SpanStatusError: true,
+}
+
 func validateTrace(trace *models.Trace) error {
 	if trace.ServiceName == "" {
 		return errors.New("service name is required")
@@ -338,6 +589,10 @@ func validateSpan(span *models.Span) error {
 		return errors.New("operation is required")
 	}
 
+	if span.Status != "" && !validSpanStatuses[span.Status] {
+		return fmt.Errorf("invalid span status: %s", span.Status)
+	}
+
 	if !span.EndTime.IsZero() && !span.StartTime.IsZero() {
 		if span.EndTime.Before(span.StartTime) {
 			return errors.New("end time cannot be before start time")
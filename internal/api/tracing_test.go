@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/db"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+func TestParseTraceFilterSpanConditions(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/traces?service=api&min_duration=500&operation=GET+%2Fusers&status=error&min_span_duration=200&attr.http.status_code%3E%3D500", nil)
+	filter, err := parseTraceFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.SpanStatus != models.SpanStatusError || filter.Operation != "GET /users" || filter.MinDuration != 500 || filter.MinSpanDuration != 200 {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+	if len(filter.Attributes) != 1 {
+		t.Fatalf("expected one attribute condition, got %+v", filter.Attributes)
+	}
+
+	clause, params, next := filter.whereClause(1)
+	for _, want := range []string{
+		"traces.service_name = $1",
+		"traces.duration_ms >= $2",
+		"EXISTS (SELECT 1 FROM spans WHERE spans.trace_id = traces.id",
+		"spans.operation = $3",
+		"spans.status = $4",
+		"spans.duration_ms >= $5",
+		"spans.attributes @@ $6",
+	} {
+		if !strings.Contains(clause, want) {
+			t.Errorf("clause %q missing %q", clause, want)
+		}
+	}
+	if len(params) != 6 || next != 7 {
+		t.Errorf("unexpected params %v (next %d)", params, next)
+	}
+}
+
+func TestTraceFilterWithoutSpanConditions(t *testing.T) {
+	clause, params, _ := TraceFilter{ServiceName: "api"}.whereClause(1)
+	if strings.Contains(clause, "EXISTS") || len(params) != 1 {
+		t.Errorf("unexpected clause %q with params %v", clause, params)
+	}
+}
+
+func TestParseTraceFilterErrors(t *testing.T) {
+	for _, rawQuery := range []string{"status=broken", "attr.bad%20key=1"} {
+		req := httptest.NewRequest(http.MethodGet, "/traces?"+rawQuery, nil)
+		if _, err := parseTraceFilter(req); err == nil {
+			t.Errorf("expected error for %q", rawQuery)
+		}
+	}
+}
+
+func TestEndSpanRequestApply(t *testing.T) {
+	initial := json.RawMessage(`{"http.method":"GET","http.status_code":200}`)
+	span := models.Span{Attributes: &initial}
+
+	req := endSpanRequest{
+		Status:     models.SpanStatusError,
+		Attributes: map[string]json.RawMessage{"http.status_code": json.RawMessage(`503`)},
+	}
+	if err := req.apply(&span); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if span.Status != models.SpanStatusError {
+		t.Errorf("expected status ERROR, got %q", span.Status)
+	}
+
+	var attributes map[string]any
+	if err := json.Unmarshal(*span.Attributes, &attributes); err != nil {
+		t.Fatalf("invalid attributes: %v", err)
+	}
+	if attributes["http.method"] != "GET" || attributes["http.status_code"] != float64(503) {
+		t.Errorf("unexpected attributes: %v", attributes)
+	}
+
+	if err := (endSpanRequest{Status: "DONE"}).apply(&span); err == nil {
+		t.Errorf("expected error for invalid status")
+	}
+}
+
+func TestCreateSpanHandlerStoresEndedSpans(t *testing.T) {
+	var stored models.Span
+	storeSpanFunc = func(span models.Span) error {
+		stored = span
+		return nil
+	}
+	defer func() { storeSpanFunc = db.StoreSpan }()
+
+	sub := traceHub.Subscribe(4, func(event models.TraceEvent) bool { return event.TraceID == "t-ended" })
+	defer sub.Close()
+
+	body := `{"trace_id": "t-ended", "service": "api", "operation": "GET /users",
+		"start_time": "2025-05-26T10:00:00Z", "end_time": "2025-05-26T10:00:00.040Z"}`
+	rec := httptest.NewRecorder()
+	CreateSpanHandler(rec, httptest.NewRequest(http.MethodPost, "/spans", strings.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	if stored.EndTime.IsZero() || stored.Duration != 40 {
+		t.Errorf("expected the end time and duration to be stored, got %+v", stored)
+	}
+
+	var types []string
+	for len(types) < 2 {
+		select {
+		case event := <-sub.C:
+			types = append(types, event.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("expected start and end events, got %v", types)
+		}
+	}
+	if types[0] != models.TraceEventSpanStart || types[1] != models.TraceEventSpanEnd {
+		t.Errorf("unexpected events %v", types)
+	}
+}
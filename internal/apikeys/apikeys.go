@@ -0,0 +1,133 @@
+// Package apikeys generates and verifies API keys and decides what their
+// scopes allow.
+//
+// A key looks like gi_<prefix>_<secret>. The prefix is stored in clear to
+// find the key, and only a SHA-256 hash of the whole key is kept. The secret
+// is 256 random bits, so a fast hash is enough: there is nothing to
+// brute-force that a slow password hash would protect.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	keyPrefix    = "gi_"
+	prefixLength = 12
+	secretBytes  = 32
+)
+
+// Scopes. Read covers GET requests and ingest all other requests to the
+// signal endpoints; both can be narrowed to a signal, as in logs:read.
+const (
+	ScopeRead   = "read"
+	ScopeIngest = "ingest"
+)
+
+// Signals scopes can be narrowed to.
+const (
+	SignalLogs    = "logs"
+	SignalMetrics = "metrics"
+	SignalTraces  = "traces"
+)
+
+// signalPaths maps the API paths of each signal, including everything below
+// them, to the signal.
+var signalPaths = map[string]string{
+	"/api/logs":         SignalLogs,
+	"/api/error-groups": SignalLogs,
+	"/api/metrics":      SignalMetrics,
+	"/api/traces":       SignalTraces,
+	"/api/spans":        SignalTraces,
+	"/api/dependencies": SignalTraces,
+}
+
+// Generate returns a new key and its prefix.
+func Generate() (key, prefix string, err error) {
+	random := make([]byte, prefixLength/2+secretBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+
+	prefix = hex.EncodeToString(random[:prefixLength/2])
+	secret := base64.RawURLEncoding.EncodeToString(random[prefixLength/2:])
+	return keyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+// Prefix returns the prefix of a key, or false when key is not shaped like
+// one.
+func Prefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, keyPrefix)
+	if !ok || len(rest) <= prefixLength+1 || rest[prefixLength] != '_' {
+		return "", false
+	}
+	return rest[:prefixLength], true
+}
+
+// Hash returns the hex SHA-256 hash a key is stored as.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether key matches the stored hash.
+func Verify(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hash)) == 1
+}
+
+// ValidScope reports whether scope is read, ingest or one of them narrowed
+// to a signal.
+func ValidScope(scope string) bool {
+	signal, access, narrowed := strings.Cut(scope, ":")
+	if !narrowed {
+		return scope == ScopeRead || scope == ScopeIngest
+	}
+	switch signal {
+	case SignalLogs, SignalMetrics, SignalTraces:
+		return access == ScopeRead || access == ScopeIngest
+	}
+	return false
+}
+
+// Signal returns the signal an API path belongs to, or "" for paths such as
+// alert rules that belong to none.
+func Signal(path string) string {
+	for base, signal := range signalPaths {
+		if path == base || strings.HasPrefix(path, base+"/") {
+			return signal
+		}
+	}
+	return ""
+}
+
+// Allows reports whether a key with scopes may send a method request to
+// path. Keys without scopes are only limited by their role. Requests other
+// than GET to paths without a signal, such as managing alert rules, need a
+// key without scopes.
+func Allows(scopes []string, method, path string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+
+	access := ScopeIngest
+	if method == http.MethodGet || method == http.MethodHead {
+		access = ScopeRead
+	}
+	signal := Signal(path)
+
+	for _, scope := range scopes {
+		if scope == access && (access == ScopeRead || signal != "") {
+			return true
+		}
+		if signal != "" && scope == signal+":"+access {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package apikeys
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, err := Generate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(key, "gi_"+prefix+"_") || len(prefix) != prefixLength {
+		t.Errorf("unexpected key %s with prefix %s", key, prefix)
+	}
+
+	if parsed, ok := Prefix(key); !ok || parsed != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, parsed)
+	}
+
+	other, _, _ := Generate()
+	if other == key {
+		t.Error("expected distinct keys")
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	for _, key := range []string{"", "your-secure-api-key", "gi_short", "gi_0123456789ab", "gi_0123456789abXsecret"} {
+		if _, ok := Prefix(key); ok {
+			t.Errorf("%q: expected not to be a key", key)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, _, _ := Generate()
+	hash := Hash(key)
+
+	if !Verify(key, hash) {
+		t.Error("expected the key to match its hash")
+	}
+	if Verify(key+"x", hash) {
+		t.Error("expected a different key not to match")
+	}
+	if strings.Contains(hash, key) || len(hash) != 64 {
+		t.Errorf("unexpected hash %s", hash)
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	for _, scope := range []string{"read", "ingest", "logs:read", "metrics:ingest", "traces:read"} {
+		if !ValidScope(scope) {
+			t.Errorf("%s: expected valid", scope)
+		}
+	}
+	for _, scope := range []string{"", "write", "admin", "logs", "logs:write", "alerts:read", "read:logs"} {
+		if ValidScope(scope) {
+			t.Errorf("%s: expected invalid", scope)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	cases := []struct {
+		scopes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{nil, http.MethodDelete, "/api/alert-rules/1", true},
+		{[]string{"ingest"}, http.MethodPost, "/api/logs/bulk", true},
+		{[]string{"ingest"}, http.MethodPost, "/api/spans/abc/end", true},
+		{[]string{"ingest"}, http.MethodGet, "/api/logs", false},
+		{[]string{"ingest"}, http.MethodPost, "/api/alert-rules", false},
+		{[]string{"read"}, http.MethodGet, "/api/alerts", true},
+		{[]string{"read"}, http.MethodPost, "/api/metrics", false},
+		{[]string{"logs:ingest"}, http.MethodPost, "/api/logs", true},
+		{[]string{"logs:ingest"}, http.MethodPost, "/api/metrics", false},
+		{[]string{"traces:read"}, http.MethodGet, "/api/dependencies", true},
+		{[]string{"traces:read"}, http.MethodGet, "/api/slos", false},
+		{[]string{"metrics:read", "logs:ingest"}, http.MethodGet, "/api/metrics/series", true},
+		{[]string{"metrics:read"}, http.MethodGet, "/api/metricsx", false},
+	}
+
+	for _, c := range cases {
+		if got := Allows(c.scopes, c.method, c.path); got != c.want {
+			t.Errorf("%v %s %s: expected %v, got %v", c.scopes, c.method, c.path, c.want, got)
+		}
+	}
+}
@@ -26,6 +26,16 @@ type Config struct {
 		JWTAuthEnabled    bool `yaml:"jwt_auth_enabled"`
 	} `yaml:"features"`
 
+	Jobs struct {
+		DependencyGraphInterval string `yaml:"dependency_graph_interval"`
+		SpanMetricsInterval     string `yaml:"span_metrics_interval"`
+		LogPatternsInterval     string `yaml:"log_patterns_interval"`
+		AlertRulesInterval      string `yaml:"alert_rules_interval"`
+		NotificationsInterval   string `yaml:"notifications_interval"`
+		SLOInterval             string `yaml:"slo_interval"`
+		AnomaliesInterval       string `yaml:"anomalies_interval"`
+	} `yaml:"jobs"`
+
 	Monitoring struct {
 		Prometheus struct {
 			Path           string `yaml:"path"`
@@ -128,6 +128,23 @@ func runMigrations() {
 		"internal/db/migrations/002_create_metrics_table.sql",
 		"internal/db/migrations/003_create_spans_and_traces_table.sql",
 		"internal/db/migrations/004_add_performance_indexes.sql",
+		"internal/db/migrations/005_add_metrics_filter_indexes.sql",
+		"internal/db/migrations/006_add_keyset_pagination_indexes.sql",
+		"internal/db/migrations/007_add_logs_full_text_search.sql",
+		"internal/db/migrations/008_add_logs_metadata_index.sql",
+		"internal/db/migrations/009_add_span_status_and_attributes.sql",
+		"internal/db/migrations/010_create_service_dependencies_table.sql",
+		"internal/db/migrations/011_create_span_metrics_table.sql",
+		"internal/db/migrations/012_create_error_groups_table.sql",
+		"internal/db/migrations/013_create_log_patterns_tables.sql",
+		"internal/db/migrations/014_create_alerting_tables.sql",
+		"internal/db/migrations/015_create_notification_tables.sql",
+		"internal/db/migrations/016_create_silences_and_notification_groups.sql",
+		"internal/db/migrations/017_create_slo_tables.sql",
+		"internal/db/migrations/018_create_anomalies_table.sql",
+		"internal/db/migrations/019_create_api_keys_table.sql",
+		"internal/db/migrations/020_create_sessions_table.sql",
+		"internal/db/migrations/021_add_log_message_json_function.sql",
 	}
 
 	successCount := 0
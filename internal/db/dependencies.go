@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// MaterializeDependencies recomputes the per-minute service dependency edges
+// for child spans starting in [from, to). Buckets are rewritten in full, so
+// re-running over the same window picks up spans that ended since the last
+// run without double counting.
+func MaterializeDependencies(ctx context.Context, from, to time.Time) (int64, error) {
+	query := `
+		INSERT INTO service_dependencies (
+			bucket, caller, callee, call_count, error_count,
+			duration_sum_ms, duration_count, duration_max_ms, updated_at
+		)
+		SELECT
+			date_trunc('minute', child.start_time),
+			parent.service,
+			child.service,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE child.status = 'ERROR'),
+			COALESCE(SUM(child.duration_ms), 0),
+			COUNT(child.duration_ms),
+			COALESCE(MAX(child.duration_ms), 0),
+			NOW()
+		FROM spans child
+		JOIN spans parent ON parent.id = child.parent_id AND parent.trace_id = child.trace_id
+		WHERE child.start_time >= date_trunc('minute', $1::timestamp)
+		  AND child.start_time < $2
+		  AND parent.service <> child.service
+		GROUP BY 1, 2, 3
+		ON CONFLICT (bucket, caller, callee) DO UPDATE SET
+			call_count = EXCLUDED.call_count,
+			error_count = EXCLUDED.error_count,
+			duration_sum_ms = EXCLUDED.duration_sum_ms,
+			duration_count = EXCLUDED.duration_count,
+			duration_max_ms = EXCLUDED.duration_max_ms,
+			updated_at = EXCLUDED.updated_at`
+
+	result, err := DB.ExecContext(ctx, query, from, to)
+	if err != nil {
+		log.Println("Failed to materialize service dependencies:", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EstimateRows asks the query planner how many rows query would return
+// without executing it. The estimate is cheap and usually within an order of
+// magnitude, which is enough for pagination totals on large tables.
+func EstimateRows(query string, params ...any) (int64, error) {
+	var plan []byte
+	if err := DB.QueryRow("EXPLAIN (FORMAT JSON) "+query, params...).Scan(&plan); err != nil {
+		return 0, err
+	}
+
+	var explained []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil {
+		return 0, err
+	}
+
+	if len(explained) == 0 {
+		return 0, fmt.Errorf("empty query plan")
+	}
+
+	return int64(explained[0].Plan.PlanRows), nil
+}
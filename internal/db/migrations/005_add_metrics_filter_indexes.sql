@@ -0,0 +1,11 @@
+-- Index for prefix path matching (LIKE 'prefix%')
+CREATE INDEX IF NOT EXISTS idx_metrics_path_pattern 
+ON metrics(path text_pattern_ops);
+
+-- Index for environment-scoped metrics queries
+CREATE INDEX IF NOT EXISTS idx_metrics_environment_timestamp 
+ON metrics(environment, timestamp DESC) WHERE environment IS NOT NULL;
+
+-- Index for request correlation
+CREATE INDEX IF NOT EXISTS idx_metrics_request_id 
+ON metrics(request_id) WHERE request_id IS NOT NULL AND request_id <> '';
@@ -0,0 +1,9 @@
+-- Indexes backing cursor pagination (ORDER BY time DESC, id DESC)
+CREATE INDEX IF NOT EXISTS idx_logs_timestamp_id 
+ON logs(timestamp DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_timestamp_id 
+ON metrics(timestamp DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_traces_start_time_id 
+ON traces(start_time DESC, id DESC);
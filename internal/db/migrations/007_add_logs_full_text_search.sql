@@ -0,0 +1,8 @@
+-- Full-text search over log messages. The 'simple' configuration keeps
+-- identifiers intact (no stemming or stop words), which suits log text.
+ALTER TABLE logs 
+ADD COLUMN IF NOT EXISTS message_tsv tsvector 
+GENERATED ALWAYS AS (to_tsvector('simple', message)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_logs_message_tsv 
+ON logs USING GIN (message_tsv);
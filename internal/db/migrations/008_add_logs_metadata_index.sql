@@ -0,0 +1,3 @@
+-- Index for structured metadata filters (@>, @? and @@ operators)
+CREATE INDEX IF NOT EXISTS idx_logs_metadata 
+ON logs USING GIN (metadata jsonb_path_ops);
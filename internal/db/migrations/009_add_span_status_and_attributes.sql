@@ -0,0 +1,22 @@
+ALTER TABLE spans 
+ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'UNSET' 
+CHECK (status IN ('UNSET', 'OK', 'ERROR'));
+
+ALTER TABLE spans 
+ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+-- Index for trace search by operation and span duration
+CREATE INDEX IF NOT EXISTS idx_spans_operation_duration 
+ON spans(operation, duration_ms);
+
+-- Index for trace search by span duration alone
+CREATE INDEX IF NOT EXISTS idx_spans_duration 
+ON spans(duration_ms) WHERE duration_ms IS NOT NULL;
+
+-- Index for finding traces containing failed spans
+CREATE INDEX IF NOT EXISTS idx_spans_error_trace_id 
+ON spans(trace_id) WHERE status = 'ERROR';
+
+-- Index for span attribute filters
+CREATE INDEX IF NOT EXISTS idx_spans_attributes 
+ON spans USING GIN (attributes jsonb_path_ops);
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- Caller -> callee edges between services, materialized per minute from
+-- parent/child spans by the dependency graph job
+CREATE TABLE IF NOT EXISTS service_dependencies (
+    bucket TIMESTAMP NOT NULL,
+    caller TEXT NOT NULL,
+    callee TEXT NOT NULL,
+    call_count BIGINT NOT NULL,
+    error_count BIGINT NOT NULL,
+    duration_sum_ms DOUBLE PRECISION NOT NULL,
+    duration_count BIGINT NOT NULL,
+    duration_max_ms DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (bucket, caller, callee)
+);
+
+-- Index for dependency queries scoped to one service
+CREATE INDEX IF NOT EXISTS idx_service_dependencies_caller_bucket 
+ON service_dependencies(caller, bucket);
+
+CREATE INDEX IF NOT EXISTS idx_service_dependencies_callee_bucket 
+ON service_dependencies(callee, bucket);
+
+-- Index for scanning recent spans when materializing edges
+CREATE INDEX IF NOT EXISTS idx_spans_start_time 
+ON spans(start_time);
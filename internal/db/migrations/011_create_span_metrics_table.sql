@@ -0,0 +1,24 @@
+-- Rate, errors and duration per service and operation, materialized per
+-- minute from completed spans by the span metrics job. duration_buckets
+-- holds non-cumulative counts for models.SpanDurationBounds plus an
+-- overflow bucket.
+CREATE TABLE IF NOT EXISTS span_metrics (
+    bucket TIMESTAMP NOT NULL,
+    service TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    call_count BIGINT NOT NULL,
+    error_count BIGINT NOT NULL,
+    duration_sum_ms DOUBLE PRECISION NOT NULL,
+    duration_max_ms DOUBLE PRECISION NOT NULL,
+    duration_buckets BIGINT[] NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (bucket, service, operation)
+);
+
+-- Index for span metric queries scoped to one service
+CREATE INDEX IF NOT EXISTS idx_span_metrics_service_bucket 
+ON span_metrics(service, bucket);
+
+-- Index for scanning recently completed spans
+CREATE INDEX IF NOT EXISTS idx_spans_end_time 
+ON spans(end_time) WHERE end_time IS NOT NULL;
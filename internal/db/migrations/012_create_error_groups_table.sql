@@ -0,0 +1,23 @@
+-- ERROR and FATAL logs grouped by normalized message and stack trace
+CREATE TABLE IF NOT EXISTS error_groups (
+    fingerprint TEXT PRIMARY KEY,
+    template TEXT NOT NULL,
+    stack TEXT NOT NULL DEFAULT '',
+    log_level TEXT NOT NULL,
+    first_seen TIMESTAMP NOT NULL,
+    last_seen TIMESTAMP NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    services TEXT[] NOT NULL DEFAULT '{}',
+    sample_log_ids INTEGER[] NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'open'
+    CHECK (status IN ('open', 'resolved', 'ignored')),
+    status_changed_at TIMESTAMP
+);
+
+-- Index for listing groups by recency within a status
+CREATE INDEX IF NOT EXISTS idx_error_groups_status_last_seen 
+ON error_groups(status, last_seen DESC);
+
+-- Index for filtering groups by affected service
+CREATE INDEX IF NOT EXISTS idx_error_groups_services 
+ON error_groups USING GIN (services);
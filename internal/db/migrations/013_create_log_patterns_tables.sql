@@ -0,0 +1,26 @@
+-- Message templates mined from logs per service
+CREATE TABLE IF NOT EXISTS log_patterns (
+    id SERIAL PRIMARY KEY,
+    service_name TEXT NOT NULL,
+    template TEXT NOT NULL,
+    sample TEXT NOT NULL,
+    first_seen TIMESTAMP NOT NULL,
+    last_seen TIMESTAMP NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0
+);
+
+-- Templates can exceed the btree entry size, so uniqueness is on a hash
+CREATE UNIQUE INDEX IF NOT EXISTS idx_log_patterns_service_template 
+ON log_patterns(service_name, md5(template));
+
+-- Per-minute message counts for each template
+CREATE TABLE IF NOT EXISTS log_pattern_counts (
+    pattern_id INTEGER NOT NULL REFERENCES log_patterns(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (pattern_id, bucket)
+);
+
+-- Index for summing template counts over a time window
+CREATE INDEX IF NOT EXISTS idx_log_pattern_counts_bucket 
+ON log_pattern_counts(bucket, pattern_id);
@@ -0,0 +1,56 @@
+-- Alert rules evaluated by the alert scheduler
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    query JSONB NOT NULL,
+    comparison TEXT NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    interval_seconds INTEGER NOT NULL,
+    for_seconds INTEGER NOT NULL DEFAULT 0,
+    labels JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_evaluated_at TIMESTAMP
+);
+
+-- Current state per rule and label set
+CREATE TABLE IF NOT EXISTS alerts (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}',
+    state TEXT NOT NULL
+    CHECK (state IN ('pending', 'firing', 'resolved')),
+    value DOUBLE PRECISION NOT NULL,
+    active_since TIMESTAMP,
+    fired_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    last_evaluated_at TIMESTAMP NOT NULL,
+    UNIQUE (rule_id, fingerprint)
+);
+
+-- Index for listing alerts by state
+CREATE INDEX IF NOT EXISTS idx_alerts_state
+ON alerts(state);
+
+-- State transitions of alerts
+CREATE TABLE IF NOT EXISTS alert_history (
+    id BIGSERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}',
+    from_state TEXT NOT NULL,
+    to_state TEXT NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Index for a rule's recent history
+CREATE INDEX IF NOT EXISTS idx_alert_history_rule_created
+ON alert_history(rule_id, created_at DESC);
+
+-- Index for recent history across rules
+CREATE INDEX IF NOT EXISTS idx_alert_history_created
+ON alert_history(created_at DESC);
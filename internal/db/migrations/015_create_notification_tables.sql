@@ -0,0 +1,40 @@
+-- Notification channels alerts are delivered to
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL
+    CHECK (type IN ('webhook', 'slack', 'teams', 'email')),
+    config JSONB NOT NULL DEFAULT '{}',
+    title_template TEXT NOT NULL DEFAULT '',
+    body_template TEXT NOT NULL DEFAULT '',
+    send_resolved BOOLEAN NOT NULL DEFAULT TRUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Channels notified when a rule's alerts fire or resolve
+ALTER TABLE alert_rules
+ADD COLUMN IF NOT EXISTS channel_ids INTEGER[] NOT NULL DEFAULT '{}';
+
+-- Delivery log of notifications sent to channels
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    channel_id INTEGER NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    rule_id INTEGER REFERENCES alert_rules(id) ON DELETE SET NULL,
+    status TEXT NOT NULL,
+    fingerprints TEXT[] NOT NULL DEFAULT '{}',
+    title TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    attempts INTEGER NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Index for a channel's recent deliveries
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_channel_created
+ON notification_deliveries(channel_id, created_at DESC);
+
+-- Index for recent deliveries across channels
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_created
+ON notification_deliveries(created_at DESC);
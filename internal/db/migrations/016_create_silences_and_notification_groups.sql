@@ -0,0 +1,48 @@
+-- Silences muting notifications for matching alerts
+CREATE TABLE IF NOT EXISTS silences (
+    id SERIAL PRIMARY KEY,
+    matchers JSONB NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_by TEXT NOT NULL,
+    comment TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Index for finding silences that have not expired
+CREATE INDEX IF NOT EXISTS idx_silences_ends_at
+ON silences(ends_at);
+
+-- Inhibition rules muting target alerts while a source alert fires
+CREATE TABLE IF NOT EXISTS inhibit_rules (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    source_matchers JSONB NOT NULL,
+    target_matchers JSONB NOT NULL,
+    equal_labels TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Grouping and timing of notifications per channel
+ALTER TABLE notification_channels
+ADD COLUMN IF NOT EXISTS group_by TEXT[] NOT NULL DEFAULT '{alertname}',
+ADD COLUMN IF NOT EXISTS group_wait_seconds INTEGER NOT NULL DEFAULT 30,
+ADD COLUMN IF NOT EXISTS group_interval_seconds INTEGER NOT NULL DEFAULT 300,
+ADD COLUMN IF NOT EXISTS repeat_interval_seconds INTEGER NOT NULL DEFAULT 14400;
+
+-- Last notification sent per channel and alert group
+CREATE TABLE IF NOT EXISTS notification_groups (
+    channel_id INTEGER NOT NULL REFERENCES notification_channels(id) ON DELETE CASCADE,
+    group_key TEXT NOT NULL,
+    group_labels JSONB NOT NULL DEFAULT '{}',
+    fingerprints TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    last_notified_at TIMESTAMP,
+    PRIMARY KEY (channel_id, group_key)
+);
+
+-- Notification group of each delivery
+ALTER TABLE notification_deliveries
+ADD COLUMN IF NOT EXISTS group_key TEXT NOT NULL DEFAULT '';
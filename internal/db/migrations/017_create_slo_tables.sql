@@ -0,0 +1,33 @@
+-- Service level objectives over request metrics
+CREATE TABLE IF NOT EXISTS slos (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    service_name TEXT NOT NULL,
+    path TEXT NOT NULL DEFAULT '',
+    method TEXT NOT NULL DEFAULT '',
+    objective DOUBLE PRECISION NOT NULL,
+    latency_threshold_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    window_seconds INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_evaluated_at TIMESTAMP
+);
+
+-- Burn-rate alert state per SLO and burn window
+CREATE TABLE IF NOT EXISTS slo_alerts (
+    slo_id INTEGER NOT NULL REFERENCES slos(id) ON DELETE CASCADE,
+    burn_window TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    state TEXT NOT NULL
+    CHECK (state IN ('inactive', 'firing', 'resolved')),
+    threshold DOUBLE PRECISION NOT NULL,
+    long_burn_rate DOUBLE PRECISION NOT NULL,
+    short_burn_rate DOUBLE PRECISION NOT NULL,
+    active_since TIMESTAMP,
+    fired_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    last_evaluated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (slo_id, burn_window)
+);
+
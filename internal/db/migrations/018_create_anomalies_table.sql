@@ -0,0 +1,24 @@
+-- Buckets in which a service's signal deviated from its seasonal baseline
+CREATE TABLE IF NOT EXISTS anomalies (
+    id BIGSERIAL PRIMARY KEY,
+    service_name TEXT NOT NULL,
+    signal TEXT NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    baseline DOUBLE PRECISION NOT NULL,
+    spread DOUBLE PRECISION NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    direction TEXT NOT NULL
+    CHECK (direction IN ('high', 'low')),
+    samples INTEGER NOT NULL,
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (service_name, signal, bucket)
+);
+
+-- Index for recent anomalies
+CREATE INDEX IF NOT EXISTS idx_anomalies_bucket
+ON anomalies(bucket DESC);
+
+-- Index for alert rules counting recently detected anomalies
+CREATE INDEX IF NOT EXISTS idx_anomalies_detected_at
+ON anomalies(detected_at);
@@ -0,0 +1,14 @@
+-- API keys, stored as SHA-256 hashes and found by their prefix
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL,
+    role TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    service_name TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP
+);
@@ -0,0 +1,21 @@
+-- Parses a log message that is a JSON object for the LogQL json stage,
+-- returning NULL for anything else. Messages are HTML-escaped on ingest, so
+-- the escapes are undone before parsing.
+CREATE OR REPLACE FUNCTION log_message_json(message TEXT) RETURNS JSONB AS $$
+DECLARE
+    doc JSONB;
+BEGIN
+    IF message IS NULL OR message !~ '^\s*\{' THEN
+        RETURN NULL;
+    END IF;
+
+    doc := replace(replace(replace(replace(replace(message,
+        '&#34;', '"'), '&#39;', ''''), '&lt;', '<'), '&gt;', '>'), '&amp;', '&')::jsonb;
+    IF jsonb_typeof(doc) <> 'object' THEN
+        RETURN NULL;
+    END IF;
+    RETURN doc;
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+// MaterializeSpanMetrics recomputes the per-minute span metrics for spans
+// that ended in [from, to). Spans are counted in the minute they ended, and
+// each bucket is rewritten in full so runs over overlapping windows are
+// idempotent.
+func MaterializeSpanMetrics(ctx context.Context, from, to time.Time) (int64, error) {
+	query := `
+		INSERT INTO span_metrics (
+			bucket, service, operation, call_count, error_count,
+			duration_sum_ms, duration_max_ms, duration_buckets, updated_at
+		)
+		SELECT
+			date_trunc('minute', end_time),
+			service,
+			operation,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'ERROR'),
+			COALESCE(SUM(duration_ms), 0),
+			COALESCE(MAX(duration_ms), 0),
+			` + durationHistogramExpr(models.SpanDurationBounds) + `,
+			NOW()
+		FROM spans
+		WHERE end_time >= date_trunc('minute', $1::timestamp)
+		  AND end_time < $2
+		GROUP BY 1, 2, 3
+		ON CONFLICT (bucket, service, operation) DO UPDATE SET
+			call_count = EXCLUDED.call_count,
+			error_count = EXCLUDED.error_count,
+			duration_sum_ms = EXCLUDED.duration_sum_ms,
+			duration_max_ms = EXCLUDED.duration_max_ms,
+			duration_buckets = EXCLUDED.duration_buckets,
+			updated_at = EXCLUDED.updated_at`
+
+	result, err := DB.ExecContext(ctx, query, from, to)
+	if err != nil {
+		log.Println("Failed to materialize span metrics:", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// durationHistogramExpr builds an ARRAY of per-bucket span counts for the
+// given upper bounds, followed by an overflow bucket.
+func durationHistogramExpr(bounds []float64) string {
+	var counts []string
+	lower := "duration_ms >= 0"
+	for _, bound := range bounds {
+		upper := fmt.Sprintf("duration_ms <= %g", bound)
+		counts = append(counts, fmt.Sprintf("COUNT(*) FILTER (WHERE %s AND %s)", lower, upper))
+		lower = fmt.Sprintf("duration_ms > %g", bound)
+	}
+	counts = append(counts, fmt.Sprintf("COUNT(*) FILTER (WHERE %s)", lower))
+
+	return "ARRAY[" + strings.Join(counts, ", ") + "]::BIGINT[]"
+}
@@ -1,14 +1,27 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"log"
 
 	"github.com/NathanSanchezDev/go-insight/internal/models"
 )
 
+const spanColumns = `id, trace_id, COALESCE(parent_id, ''), service, operation, start_time, end_time, duration_ms, status, attributes`
+
+// StoreSpan inserts a span. Spans reported with an end time are stored as
+// ended, with their duration.
 func StoreSpan(span models.Span) error {
-	query := `INSERT INTO spans (id, trace_id, parent_id, service, operation, start_time) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := DB.Exec(query, span.ID, span.TraceID, span.ParentID, span.Service, span.Operation, span.StartTime)
+	var endTime sql.NullTime
+	var duration sql.NullFloat64
+	if !span.EndTime.IsZero() {
+		endTime = sql.NullTime{Time: span.EndTime, Valid: true}
+		duration = sql.NullFloat64{Float64: span.Duration, Valid: true}
+	}
+
+	query := `INSERT INTO spans (id, trace_id, parent_id, service, operation, start_time, end_time, duration_ms, status, attributes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := DB.Exec(query, span.ID, span.TraceID, span.ParentID, span.Service, span.Operation, span.StartTime, endTime, duration, spanStatus(span), spanAttributes(span))
 	if err != nil {
 		log.Println("Failed to store span:", err)
 	}
@@ -16,8 +29,8 @@ func StoreSpan(span models.Span) error {
 }
 
 func UpdateSpan(span *models.Span) error {
-	query := `UPDATE spans SET end_time = $1, duration_ms = $2 WHERE id = $3`
-	_, err := DB.Exec(query, span.EndTime, span.Duration, span.ID)
+	query := `UPDATE spans SET end_time = $1, duration_ms = $2, status = $3, attributes = $4 WHERE id = $5`
+	_, err := DB.Exec(query, span.EndTime, span.Duration, spanStatus(*span), spanAttributes(*span), span.ID)
 	if err != nil {
 		log.Println("Failed to update span:", err)
 	}
@@ -25,7 +38,7 @@ func UpdateSpan(span *models.Span) error {
 }
 
 func FetchSpans(traceID string) ([]models.Span, error) {
-	query := `SELECT id, trace_id, parent_id, service, operation, start_time, end_time, duration_ms FROM spans WHERE trace_id = $1 ORDER BY start_time`
+	query := `SELECT ` + spanColumns + ` FROM spans WHERE trace_id = $1 ORDER BY start_time`
 	rows, err := DB.Query(query, traceID)
 	if err != nil {
 		log.Println("Failed to fetch spans:", err)
@@ -35,8 +48,7 @@ func FetchSpans(traceID string) ([]models.Span, error) {
 
 	var spans []models.Span
 	for rows.Next() {
-		var span models.Span
-		err := rows.Scan(&span.ID, &span.TraceID, &span.ParentID, &span.Service, &span.Operation, &span.StartTime, &span.EndTime, &span.Duration)
+		span, err := scanSpan(rows)
 		if err != nil {
 			log.Println("Error scanning span row:", err)
 			continue
@@ -47,24 +59,65 @@ func FetchSpans(traceID string) ([]models.Span, error) {
 }
 
 func FetchSpanByID(spanID string) (models.Span, error) {
-	query := `SELECT id, trace_id, parent_id, service, operation, start_time, end_time, duration_ms FROM spans WHERE id = $1`
+	query := `SELECT ` + spanColumns + ` FROM spans WHERE id = $1`
+
+	span, err := scanSpan(DB.QueryRow(query, spanID))
+	if err != nil {
+		log.Println("Failed to fetch span:", err)
+		return models.Span{}, err
+	}
+
+	return span, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+// scanSpan reads a row selected with spanColumns. Spans that have not ended
+// yet have a zero EndTime and Duration.
+func scanSpan(row rowScanner) (models.Span, error) {
 	var span models.Span
-	err := DB.QueryRow(query, spanID).Scan(
+	var endTime sql.NullTime
+	var duration sql.NullFloat64
+	var attributes []byte
+
+	err := row.Scan(
 		&span.ID,
 		&span.TraceID,
 		&span.ParentID,
 		&span.Service,
 		&span.Operation,
 		&span.StartTime,
-		&span.EndTime,
-		&span.Duration,
+		&endTime,
+		&duration,
+		&span.Status,
+		&attributes,
 	)
-
 	if err != nil {
-		log.Println("Failed to fetch span:", err)
 		return models.Span{}, err
 	}
 
+	span.EndTime = endTime.Time
+	span.Duration = duration.Float64
+	if len(attributes) > 0 {
+		raw := json.RawMessage(attributes)
+		span.Attributes = &raw
+	}
+
 	return span, nil
 }
+
+func spanStatus(span models.Span) string {
+	if span.Status == "" {
+		return models.SpanStatusUnset
+	}
+	return span.Status
+}
+
+func spanAttributes(span models.Span) []byte {
+	if span.Attributes == nil || len(*span.Attributes) == 0 {
+		return []byte("{}")
+	}
+	return *span.Attributes
+}
@@ -0,0 +1,142 @@
+// Package errorgroups normalizes error log messages and stack traces into
+// fingerprints, so that repeats of the same failure with varying IDs,
+// numbers and values are counted as one group.
+package errorgroups
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// maxStackFrames is how many normalized frames from the top of a stack
+// trace contribute to the fingerprint. Deeper frames mostly describe the
+// caller and would split one failure into many groups.
+const maxStackFrames = 5
+
+// stackKeys are the metadata keys checked, in order, for a stack trace.
+// Nested keys are given as dotted paths.
+var stackKeys = []string{
+	"stack",
+	"stack_trace",
+	"stacktrace",
+	"error.stack",
+	"exception.stacktrace",
+}
+
+var (
+	quotedPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`[^`]*`")
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`)
+	hexWord       = regexp.MustCompile(`(?i)\b[0-9a-f]{6,}\b`)
+	numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	whitespace    = regexp.MustCompile(`\s+`)
+)
+
+// Signature identifies an error group.
+type Signature struct {
+	Fingerprint string
+	Template    string
+	Stack       string
+}
+
+// Normalize replaces the variable parts of a message with placeholders:
+// quoted strings, UUIDs, hex values and numbers, in that order.
+func Normalize(message string) string {
+	normalized := quotedPattern.ReplaceAllString(message, "<str>")
+	normalized = uuidPattern.ReplaceAllString(normalized, "<uuid>")
+	normalized = hexPattern.ReplaceAllString(normalized, "<hex>")
+	normalized = hexWord.ReplaceAllStringFunc(normalized, func(word string) string {
+		// Bare hex is only recognized when it mixes digits and letters, so
+		// plain words and plain numbers are left to the other rules.
+		if strings.ContainsAny(word, "0123456789") && strings.Trim(word, "0123456789") != "" {
+			return "<hex>"
+		}
+		return word
+	})
+	normalized = numberPattern.ReplaceAllString(normalized, "<num>")
+	return strings.TrimSpace(whitespace.ReplaceAllString(normalized, " "))
+}
+
+// Compute returns the signature of an error log from its message and the
+// stack trace in its metadata, if any.
+func Compute(message string, metadata []byte) Signature {
+	sig := Signature{
+		Template: Normalize(message),
+		Stack:    normalizeStack(stackTrace(metadata)),
+	}
+
+	sum := sha256.Sum256([]byte(sig.Template + "\n" + sig.Stack))
+	sig.Fingerprint = hex.EncodeToString(sum[:16])
+	return sig
+}
+
+// stackTrace extracts the first stack trace found under stackKeys. Stacks
+// may be a single string or an array of frame strings.
+func stackTrace(metadata []byte) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(metadata, &fields); err != nil {
+		return ""
+	}
+
+	for _, key := range stackKeys {
+		value := lookup(fields, strings.Split(key, "."))
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case []any:
+			var frames []string
+			for _, frame := range v {
+				if s, ok := frame.(string); ok {
+					frames = append(frames, s)
+				}
+			}
+			if len(frames) > 0 {
+				return strings.Join(frames, "\n")
+			}
+		}
+	}
+
+	return ""
+}
+
+// lookup resolves path against fields, accepting both nested objects and
+// flat keys containing dots.
+func lookup(fields map[string]any, path []string) any {
+	if value, ok := fields[strings.Join(path, ".")]; ok {
+		return value
+	}
+	if len(path) < 2 {
+		return nil
+	}
+	nested, ok := fields[path[0]].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return lookup(nested, path[1:])
+}
+
+// normalizeStack keeps the top maxStackFrames non-empty frames with line
+// numbers, addresses and other variable parts normalized.
+func normalizeStack(stack string) string {
+	var frames []string
+	for _, line := range strings.Split(stack, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		frames = append(frames, Normalize(line))
+		if len(frames) == maxStackFrames {
+			break
+		}
+	}
+	return strings.Join(frames, "\n")
+}
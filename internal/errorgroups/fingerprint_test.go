@@ -0,0 +1,68 @@
+package errorgroups
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"user 42 not found": "user <num> not found",
+		"order 550e8400-e29b-41d4-a716-446655440000 failed": "order <uuid> failed",
+		"segfault at 0x7ffd5e8c and retry in 1.5s":          "segfault at <hex> and retry in <num>s",
+		`key "user:42" missing in 'cache-01'`:               "key <str> missing in <str>",
+		"commit 3f2a9c1b deployed to shard-07":              "commit <hex> deployed to shard-<num>",
+		"connection   refused\tby   upstream":               "connection refused by upstream",
+		"decoded facade payload":                            "decoded facade payload",
+	}
+
+	for input, want := range cases {
+		if got := Normalize(input); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestComputeGroupsVaryingValues(t *testing.T) {
+	a := Compute("payment 1001 declined for user 550e8400-e29b-41d4-a716-446655440000", nil)
+	b := Compute("payment 2002 declined for user 6ba7b810-9dad-11d1-80b4-00c04fd430c8", nil)
+	c := Compute("payment 1001 refunded", nil)
+
+	if a.Fingerprint != b.Fingerprint {
+		t.Errorf("expected same fingerprint, got %s and %s", a.Fingerprint, b.Fingerprint)
+	}
+	if a.Fingerprint == c.Fingerprint {
+		t.Errorf("expected different fingerprints for different messages")
+	}
+	if a.Template != "payment <num> declined for user <uuid>" {
+		t.Errorf("unexpected template %q", a.Template)
+	}
+}
+
+func TestComputeUsesStackTrace(t *testing.T) {
+	stackA := []byte(`{"stack": "panic: nil map\ngoroutine 17 [running]:\nmain.handler(0xc000123)\n\t/app/main.go:42 +0x1d"}`)
+	stackB := []byte(`{"stack": "panic: nil map\ngoroutine 99 [running]:\nmain.handler(0xc000999)\n\t/app/main.go:42 +0x1d"}`)
+	stackC := []byte(`{"error": {"stack": ["at db.query (db.js:10)", "at handler (app.js:5)"]}}`)
+
+	a := Compute("request failed", stackA)
+	b := Compute("request failed", stackB)
+	c := Compute("request failed", stackC)
+	plain := Compute("request failed", []byte(`{"user_id": 42}`))
+
+	if a.Fingerprint != b.Fingerprint {
+		t.Errorf("expected normalized stacks to match:\n%s\n%s", a.Stack, b.Stack)
+	}
+	if a.Fingerprint == c.Fingerprint || a.Fingerprint == plain.Fingerprint {
+		t.Errorf("expected stacks to split groups")
+	}
+	if c.Stack != "at db.query (db.js:<num>)\nat handler (app.js:<num>)" {
+		t.Errorf("unexpected nested stack %q", c.Stack)
+	}
+	if plain.Stack != "" {
+		t.Errorf("expected no stack, got %q", plain.Stack)
+	}
+}
+
+func TestNormalizeStackKeepsTopFrames(t *testing.T) {
+	stack := "f1\nf2\n\nf3\nf4\nf5\nf6\nf7"
+	if got := normalizeStack(stack); got != "f<num>\nf<num>\nf<num>\nf<num>\nf<num>" {
+		t.Errorf("unexpected stack %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+// Package jobs runs periodic background work, such as materializing
+// aggregates from raw telemetry, alongside the HTTP server.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a unit of work run on a fixed interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start runs the job once immediately and then every Interval until ctx is
+// cancelled. Errors are logged and do not stop the job. The returned channel
+// is closed once the job has stopped.
+func Start(ctx context.Context, job Job) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(job.Interval)
+		defer ticker.Stop()
+
+		for {
+			runOnce(ctx, job)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	log.Printf("⏱️  Started background job %s (every %s)", job.Name, job.Interval)
+	return done
+}
+
+func runOnce(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Background job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("❌ Background job %s failed after %s: %v", job.Name, time.Since(start), err)
+	}
+}
+
+// ParseInterval parses a duration from config, falling back when the value
+// is empty, malformed or not positive.
+func ParseInterval(raw string, fallback time.Duration) time.Duration {
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		return fallback
+	}
+	return interval
+}
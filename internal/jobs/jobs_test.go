@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartRunsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs atomic.Int32
+	done := Start(ctx, Job{
+		Name:     "test",
+		Interval: time.Millisecond,
+		Run: func(context.Context) error {
+			if runs.Add(1) == 3 {
+				cancel()
+			}
+			return errors.New("keeps going")
+		},
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop after cancel")
+	}
+
+	if got := runs.Load(); got < 3 {
+		t.Errorf("expected at least 3 runs, got %d", got)
+	}
+}
+
+func TestStartRecoversFromPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	done := Start(ctx, Job{
+		Name:     "panics",
+		Interval: time.Millisecond,
+		Run: func(context.Context) error {
+			if runs.Add(1) == 2 {
+				cancel()
+			}
+			panic("boom")
+		},
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not survive a panic")
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30s":   30 * time.Second,
+		"":      time.Minute,
+		"bogus": time.Minute,
+		"-5s":   time.Minute,
+	}
+	for raw, want := range cases {
+		if got := ParseInterval(raw, time.Minute); got != want {
+			t.Errorf("ParseInterval(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// keySetTTL is how long fetched keys are used before they are fetched
+	// again.
+	keySetTTL = time.Hour
+	// keySetMinRefresh limits how often a token with an unknown key ID,
+	// as after a key rotation, makes the keys be fetched again.
+	keySetMinRefresh = time.Minute
+	// maxDocumentSize limits the size of JWKS and discovery documents.
+	maxDocumentSize = 1 << 20
+)
+
+// KeySet fetches and caches the public keys of a JWKS URL. Keys are fetched
+// again once an hour, and sooner when a token names a key ID the set does not
+// have, so keys the provider rotates in are picked up. Fetches, failed ones
+// included, happen at most once a minute and one at a time.
+type KeySet struct {
+	url    string
+	client *http.Client
+
+	mu         sync.Mutex
+	keys       []publicKey
+	fetched    time.Time
+	attempted  time.Time
+	err        error
+	refreshing chan struct{}
+}
+
+type publicKey struct {
+	id     string
+	alg    string
+	public crypto.PublicKey
+}
+
+// fits reports whether the key may verify tokens signed with alg.
+func (k publicKey) fits(alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+	switch k.public.(type) {
+	case *rsa.PublicKey:
+		return alg == RS256
+	case *ecdsa.PublicKey:
+		return alg == ES256
+	case ed25519.PublicKey:
+		return alg == EdDSA
+	}
+	return false
+}
+
+// NewKeySet returns a key set for the JWKS at url. A nil client uses
+// http.DefaultClient.
+func NewKeySet(url string, client *http.Client) *KeySet {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &KeySet{url: url, client: client}
+}
+
+// lookup returns the keys with ID kid, or all keys when kid is empty. The
+// keys are fetched without holding the lock, and concurrent lookups wait for
+// the fetch in progress instead of starting their own.
+func (s *KeySet) lookup(ctx context.Context, kid string, now time.Time) ([]publicKey, error) {
+	s.mu.Lock()
+	if wait := s.refreshing; wait != nil {
+		s.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		s.mu.Lock()
+	} else if s.refreshDue(kid, now) {
+		done := make(chan struct{})
+		s.refreshing = done
+		s.mu.Unlock()
+
+		keys, err := fetchKeys(ctx, s.client, s.url)
+
+		s.mu.Lock()
+		s.attempted = now
+		s.err = err
+		if err == nil {
+			s.keys = keys
+			s.fetched = now
+		} else if !s.fetched.IsZero() {
+			// Keep using the previous keys while the provider is unavailable.
+			log.Printf("❌ Error refreshing JWKS %s: %v", s.url, err)
+		}
+		s.refreshing = nil
+		close(done)
+	}
+	defer s.mu.Unlock()
+
+	if s.fetched.IsZero() {
+		return nil, s.err
+	}
+	return s.match(kid), nil
+}
+
+// refreshDue reports whether the keys should be fetched for a token with key
+// ID kid. s.mu must be held.
+func (s *KeySet) refreshDue(kid string, now time.Time) bool {
+	if !s.attempted.IsZero() && now.Sub(s.attempted) < keySetMinRefresh {
+		return false
+	}
+	return now.Sub(s.fetched) >= keySetTTL || len(s.match(kid)) == 0
+}
+
+func (s *KeySet) match(kid string) []publicKey {
+	var matches []publicKey
+	for _, k := range s.keys {
+		if kid == "" || k.id == kid {
+			matches = append(matches, k)
+		}
+	}
+	return matches
+}
+
+// jwk is a JSON Web Key with the members of RSA, EC and OKP keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func fetchKeys(ctx context.Context, client *http.Client, url string) ([]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, client, url, &set); err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+
+	keys := make([]publicKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		public, err := k.publicKey()
+		if err != nil {
+			// Skip keys of unsupported types instead of failing the set.
+			log.Printf("⚠️  Skipping JWKS key %q: %v", k.Kid, err)
+			continue
+		}
+		keys = append(keys, publicKey{id: k.Kid, alg: k.Alg, public: public})
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pub.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return pub, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// ProviderMetadata is the part of an OpenID Connect discovery document
+// needed to verify tokens and to log users in.
+type ProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	JWKSURI               string `json:"jwks_uri"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// Discover fetches the discovery document at url, usually the issuer
+// followed by /.well-known/openid-configuration.
+func Discover(ctx context.Context, client *http.Client, url string) (ProviderMetadata, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var metadata ProviderMetadata
+	if err := getJSON(ctx, client, url, &metadata); err != nil {
+		return ProviderMetadata{}, fmt.Errorf("fetching discovery document: %w", err)
+	}
+	if metadata.Issuer == "" || metadata.JWKSURI == "" {
+		return ProviderMetadata{}, errors.New("discovery document has no issuer or jwks_uri")
+	}
+	return metadata, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(v)
+}
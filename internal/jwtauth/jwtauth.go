@@ -0,0 +1,246 @@
+// Package jwtauth verifies JSON Web Tokens signed with a shared secret
+// (HS256) or with the keys of an identity provider (RS256, ES256 and EdDSA)
+// published as a JWKS, and validates their registered claims.
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Claims are the decoded payload of a verified token.
+type Claims map[string]any
+
+// Verifier checks the signature and claims of tokens. Tokens signed with
+// HS256 need Secret and all others Keys; the algorithm in a token's header
+// must fit the key it is verified with, so a public key is never used as an
+// HMAC secret. Empty Issuer and Audiences are not checked.
+type Verifier struct {
+	Secret    []byte
+	Keys      *KeySet
+	Issuer    string
+	Audiences []string
+	// Leeway allows for clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+type header struct {
+	Alg  string   `json:"alg"`
+	Kid  string   `json:"kid"`
+	Crit []string `json:"crit"`
+}
+
+// Verify returns the claims of token if it is correctly signed, valid at
+// now, and issued by and for the configured issuer and audiences.
+func (v *Verifier) Verify(ctx context.Context, token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid token")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if len(h.Crit) > 0 {
+		return nil, fmt.Errorf("unsupported critical header parameters %v", h.Crit)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid signature")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	if err := v.verifySignature(ctx, h, signed, sig, now); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	if err := v.validate(claims, now); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, h header, signed, sig []byte, now time.Time) error {
+	switch h.Alg {
+	case HS256:
+		if len(v.Secret) == 0 {
+			return errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, v.Secret)
+		mac.Write(signed)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+
+	case RS256, ES256, EdDSA:
+		if v.Keys == nil {
+			return fmt.Errorf("%s tokens are not accepted", h.Alg)
+		}
+		keys, err := v.Keys.lookup(ctx, h.Kid, now)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if k.fits(h.Alg) && verifyWithKey(h.Alg, k.public, signed, sig) {
+				return nil
+			}
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("no key with id %q", h.Kid)
+		}
+		return errors.New("signature mismatch")
+
+	default:
+		return fmt.Errorf("unsupported algorithm %q", h.Alg)
+	}
+}
+
+// verifyWithKey checks sig with a public key of the type alg needs.
+func verifyWithKey(alg string, public crypto.PublicKey, signed, sig []byte) bool {
+	digest := sha256.Sum256(signed)
+
+	switch alg {
+	case RS256:
+		pub, ok := public.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	case ES256:
+		// The signature is r and s as 32-byte big-endian integers.
+		pub, ok := public.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	case EdDSA:
+		pub, ok := public.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, sig)
+	}
+	return false
+}
+
+// validate checks the time, issuer and audience claims.
+func (v *Verifier) validate(claims Claims, now time.Time) error {
+	if exp, ok, err := claims.time("exp"); err != nil {
+		return err
+	} else if ok && !now.Add(-v.Leeway).Before(exp) {
+		return errors.New("token expired")
+	}
+	if nbf, ok, err := claims.time("nbf"); err != nil {
+		return err
+	} else if ok && now.Add(v.Leeway).Before(nbf) {
+		return errors.New("token not valid yet")
+	}
+
+	if v.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.Issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+
+	if len(v.Audiences) > 0 {
+		audiences := claims.Strings("aud")
+		if !slices.ContainsFunc(audiences, func(aud string) bool { return slices.Contains(v.Audiences, aud) }) {
+			return fmt.Errorf("unexpected audience %v", audiences)
+		}
+	}
+
+	return nil
+}
+
+// time returns a NumericDate claim, reporting whether it is present.
+func (c Claims) time(name string) (time.Time, bool, error) {
+	value, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("invalid %s claim", name)
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s claim", name)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true, nil
+}
+
+// Strings returns a claim that is a string or an array of strings. A claim
+// name containing dots, such as realm_access.roles, is also looked up as a
+// path through nested objects when there is no claim of that name.
+func (c Claims) Strings(name string) []string {
+	value, ok := c[name]
+	if !ok {
+		value, ok = c.path(name)
+	}
+	if !ok {
+		return nil
+	}
+
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func (c Claims) path(name string) (any, bool) {
+	var value any = map[string]any(c)
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,377 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding
+
+// sign returns a token with claims signed by key with alg. key is the HMAC
+// secret for HS256; other algorithms, such as none, get no signature.
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	head, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(head) + "." + b64.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	var err error
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case RS256:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case ES256:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case EdDSA:
+		sig = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+	}
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	return signed + "." + b64.EncodeToString(sig)
+}
+
+func publicJWK(kid string, key any) map[string]string {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "alg": RS256,
+			"n": b64.EncodeToString(key.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes())}
+	case *ecdsa.PrivateKey:
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256",
+			"x": b64.EncodeToString(key.X.FillBytes(make([]byte, 32))), "y": b64.EncodeToString(key.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PrivateKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519",
+			"x": b64.EncodeToString(key.Public().(ed25519.PublicKey))}
+	}
+	return nil
+}
+
+// provider is a local identity provider serving a discovery document and a
+// JWKS whose keys can be replaced.
+type provider struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+}
+
+func newProvider(t *testing.T) *provider {
+	p := &provider{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": p.URL, "jwks_uri": p.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.fetches++
+		json.NewEncoder(w).Encode(map[string]any{"keys": p.keys})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *provider) publish(keys ...map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+}
+
+func TestVerifyAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	p := newProvider(t)
+	p.publish(publicJWK("rsa", rsaKey), publicJWK("ec", ecKey), publicJWK("ed", edKey))
+
+	now := time.Now()
+	v := &Verifier{Secret: []byte("secret"), Keys: NewKeySet(p.URL+"/jwks", nil)}
+	claims := map[string]any{"role": "user", "exp": now.Add(time.Hour).Unix()}
+
+	cases := []struct {
+		alg, kid string
+		key      any
+	}{
+		{HS256, "", []byte("secret")},
+		{RS256, "rsa", rsaKey},
+		{ES256, "ec", ecKey},
+		{EdDSA, "ed", edKey},
+		{EdDSA, "", edKey},
+	}
+	for _, c := range cases {
+		got, err := v.Verify(context.Background(), sign(t, c.alg, c.kid, c.key, claims), now)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.alg, err)
+			continue
+		}
+		if roles := got.Strings("role"); len(roles) != 1 || roles[0] != "user" {
+			t.Errorf("%s: unexpected claims %v", c.alg, got)
+		}
+	}
+	if p.fetches != 1 {
+		t.Errorf("expected the JWKS to be fetched once, got %d", p.fetches)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rejected := map[string]string{
+		"wrong secret":     sign(t, HS256, "", []byte("other"), claims),
+		"wrong key":        sign(t, RS256, "rsa", otherKey, claims),
+		"key of other alg": sign(t, ES256, "rsa", ecKey, claims),
+		"none":             sign(t, "none", "", nil, claims),
+		"malformed":        "abc.def",
+	}
+	for name, token := range rejected {
+		if _, err := v.Verify(context.Background(), token, now); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestVerifyRejectsPublicKeyAsSecret(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p := newProvider(t)
+	p.publish(publicJWK("rsa", rsaKey))
+
+	// An HS256 token signed with the public key must not pass when only
+	// provider keys are configured.
+	jwk := publicJWK("rsa", rsaKey)
+	v := &Verifier{Keys: NewKeySet(p.URL+"/jwks", nil)}
+	token := sign(t, HS256, "rsa", []byte(jwk["n"]), map[string]any{"role": "admin"})
+	if _, err := v.Verify(context.Background(), token, time.Now()); err == nil {
+		t.Error("expected HS256 to be refused without a secret")
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	secret := []byte("secret")
+	v := &Verifier{
+		Secret:    secret,
+		Issuer:    "https://idp.example.com",
+		Audiences: []string{"go-insight", "dashboard"},
+		Leeway:    30 * time.Second,
+	}
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss": "https://idp.example.com",
+			"aud": []string{"other", "go-insight"},
+			"exp": now.Add(time.Minute).Unix(),
+			"nbf": now.Add(-time.Minute).Unix(),
+		}
+	}
+
+	if _, err := v.Verify(context.Background(), sign(t, HS256, "", secret, valid()), now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := map[string]func(map[string]any){
+		"expired":        func(c map[string]any) { c["exp"] = now.Add(-31 * time.Second).Unix() },
+		"not yet valid":  func(c map[string]any) { c["nbf"] = now.Add(31 * time.Second).Unix() },
+		"wrong issuer":   func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c map[string]any) { c["aud"] = "other" },
+		"no audience":    func(c map[string]any) { delete(c, "aud") },
+		"invalid exp":    func(c map[string]any) { c["exp"] = "tomorrow" },
+	}
+	for name, change := range cases {
+		claims := valid()
+		change(claims)
+		if _, err := v.Verify(context.Background(), sign(t, HS256, "", secret, claims), now); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// Within the leeway, expired and future tokens are still accepted.
+	claims := valid()
+	claims["exp"] = now.Add(-20 * time.Second).Unix()
+	claims["nbf"] = now.Add(20 * time.Second).Unix()
+	claims["aud"] = "dashboard"
+	if _, err := v.Verify(context.Background(), sign(t, HS256, "", secret, claims), now); err != nil {
+		t.Errorf("expected the leeway to apply, got %v", err)
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	p := newProvider(t)
+	p.publish(publicJWK("old", oldKey))
+
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	v := &Verifier{Keys: NewKeySet(p.URL+"/jwks", nil)}
+	claims := map[string]any{"sub": "alice"}
+
+	if _, err := v.Verify(context.Background(), sign(t, ES256, "old", oldKey, claims), now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The provider rotates in a new key. Tokens with its ID refresh the set,
+	// but at most once a minute.
+	p.publish(publicJWK("old", oldKey), publicJWK("new", newKey))
+	rotated := sign(t, ES256, "new", newKey, claims)
+	if _, err := v.Verify(context.Background(), rotated, now.Add(10*time.Second)); err == nil {
+		t.Error("expected the new key to be unknown right after a fetch")
+	}
+	if _, err := v.Verify(context.Background(), rotated, now.Add(time.Minute)); err != nil {
+		t.Errorf("expected the new key after a refresh, got %v", err)
+	}
+	if p.fetches != 2 {
+		t.Errorf("expected 2 fetches, got %d", p.fetches)
+	}
+
+	// Cached keys are used while the provider is down.
+	p.Close()
+	if _, err := v.Verify(context.Background(), rotated, now.Add(2*time.Hour)); err != nil {
+		t.Errorf("expected cached keys to be used, got %v", err)
+	}
+}
+
+func TestKeySetRateLimitsFailedFetches(t *testing.T) {
+	var fetches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	set := NewKeySet(srv.URL, nil)
+	for _, at := range []time.Duration{0, time.Second, 30 * time.Second} {
+		if _, err := set.lookup(context.Background(), "k", now.Add(at)); err == nil {
+			t.Fatal("expected error while the provider is down")
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 fetch within a minute of a failure, got %d", fetches)
+	}
+
+	if _, err := set.lookup(context.Background(), "k", now.Add(time.Minute)); err == nil {
+		t.Fatal("expected error while the provider is down")
+	}
+	if fetches != 2 {
+		t.Errorf("expected a retry after a minute, got %d fetches", fetches)
+	}
+}
+
+func TestKeySetFetchesOnceForConcurrentLookups(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var fetches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+		<-release
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{publicJWK("k", key)}})
+	}))
+	defer srv.Close()
+
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	set := NewKeySet(srv.URL, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys, err := set.lookup(context.Background(), "k", now)
+			if err == nil && len(keys) != 1 {
+				err = fmt.Errorf("got %d keys", len(keys))
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 fetch, got %d", fetches)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	p := newProvider(t)
+
+	metadata, err := Discover(context.Background(), nil, p.URL+"/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metadata.Issuer != p.URL || metadata.JWKSURI != p.URL+"/jwks" {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+
+	if _, err := Discover(context.Background(), nil, p.URL+"/missing"); err == nil {
+		t.Error("expected error for a missing document")
+	}
+}
+
+func TestRoleMapper(t *testing.T) {
+	claims := Claims{
+		"role":                       "user",
+		"groups":                     []any{"engineering", "platform-admins"},
+		"realm_access":               map[string]any{"roles": []any{"viewer"}},
+		"https://example.com/groups": []any{"sre"},
+	}
+
+	if roles := (RoleMapper{Claim: "role"}).Roles(claims); len(roles) != 1 || roles[0] != "user" {
+		t.Errorf("unexpected roles %v", roles)
+	}
+	if roles := (RoleMapper{Claim: "realm_access.roles"}).Roles(claims); len(roles) != 1 || roles[0] != "viewer" {
+		t.Errorf("unexpected nested roles %v", roles)
+	}
+	if roles := (RoleMapper{Claim: "https://example.com/groups"}).Roles(claims); len(roles) != 1 || roles[0] != "sre" {
+		t.Errorf("unexpected namespaced roles %v", roles)
+	}
+
+	mapping, err := ParseRoleMapping(" platform-admins=admin, engineering=user ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	roles := RoleMapper{Claim: "groups", Mapping: mapping}.Roles(claims)
+	if len(roles) != 2 || roles[0] != "user" || roles[1] != "admin" {
+		t.Errorf("unexpected mapped roles %v", roles)
+	}
+	if roles := (RoleMapper{Claim: "missing", Mapping: mapping}).Roles(claims); len(roles) != 0 {
+		t.Errorf("expected no roles, got %v", roles)
+	}
+
+	for _, invalid := range []string{"admins", "=admin", "admins="} {
+		if _, err := ParseRoleMapping(invalid); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package jwtauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoleMapper turns the values of a claim, such as the groups a provider
+// puts in its tokens, into roles. Without a mapping the values are the
+// roles; with one, values without an entry are ignored.
+type RoleMapper struct {
+	Claim   string
+	Mapping map[string]string
+}
+
+// ParseRoleMapping parses a mapping written as value=role pairs separated by
+// commas, such as "platform-admins=admin,engineering=user".
+func ParseRoleMapping(s string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		value, role, ok := strings.Cut(pair, "=")
+		value, role = strings.TrimSpace(value), strings.TrimSpace(role)
+		if !ok || value == "" || role == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected value=role", pair)
+		}
+		mapping[value] = role
+	}
+	return mapping, nil
+}
+
+// Roles returns the roles claims grant.
+func (m RoleMapper) Roles(claims Claims) []string {
+	values := claims.Strings(m.Claim)
+	if len(m.Mapping) == 0 {
+		return values
+	}
+
+	var roles []string
+	for _, value := range values {
+		if role, ok := m.Mapping[value]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,97 @@
+// Package logql implements a small LogQL-style query language over the logs
+// table: a label selector, a pipeline of line filters, a json stage and
+// label filters, and range aggregations such as count_over_time and rate.
+//
+//	{service="api", level=~"ERROR|FATAL"} |= "timeout" != "retry"
+//	{service="api"} | json | http.status >= 500
+//	sum by (service) (rate({level="ERROR"}[5m]))
+//
+// Queries are parsed into an AST by Parse and translated to SQL by Plan.
+package logql
+
+import "time"
+
+// Expr is either a *LogExpr or a *MetricExpr.
+type Expr interface {
+	expr()
+}
+
+// MatchOp is a comparison operator used by matchers and filters.
+type MatchOp string
+
+const (
+	OpEq  MatchOp = "="
+	OpNeq MatchOp = "!="
+	OpRe  MatchOp = "=~"
+	OpNre MatchOp = "!~"
+	OpGt  MatchOp = ">"
+	OpGte MatchOp = ">="
+	OpLt  MatchOp = "<"
+	OpLte MatchOp = "<="
+)
+
+// LogExpr selects log lines: a label selector followed by pipeline stages.
+type LogExpr struct {
+	Matchers []Matcher
+	Stages   []Stage
+}
+
+// Matcher is a label selector entry such as service="api".
+type Matcher struct {
+	Label string
+	Op    MatchOp
+	Value string
+}
+
+// Stage is a pipeline stage: *LineFilter, *JSONStage or *LabelFilter.
+type Stage interface {
+	stage()
+}
+
+// LineFilter filters on the log message. Op is OpEq (contains), OpNeq (does
+// not contain), OpRe (matches regex) or OpNre (does not match regex).
+type LineFilter struct {
+	Op    MatchOp
+	Value string
+}
+
+// JSONStage exposes the fields of the log's structured metadata, and of its
+// message when that is a JSON object, as labels for the label filters and
+// groupings that follow it. Metadata fields win when both have a key.
+type JSONStage struct{}
+
+// LabelFilter filters on a label after the pipeline. Numeric is set for the
+// ordering operators and for equality against a number literal.
+type LabelFilter struct {
+	Label   string
+	Op      MatchOp
+	Value   string
+	Numeric bool
+}
+
+// MetricExpr is a range aggregation, optionally wrapped in a sum.
+type MetricExpr struct {
+	// Func is count_over_time or rate.
+	Func  string
+	Log   *LogExpr
+	Range time.Duration
+
+	// Sum is set for sum(...) and sum by (...) (...). Grouping lists the
+	// labels kept; when Sum is false results are grouped by the stream
+	// labels service and level.
+	Sum      bool
+	Grouping []string
+}
+
+func (*LogExpr) expr()    {}
+func (*MetricExpr) expr() {}
+
+func (*LineFilter) stage()  {}
+func (*JSONStage) stage()   {}
+func (*LabelFilter) stage() {}
+
+// Range functions supported in metric queries.
+const (
+	FuncCountOverTime = "count_over_time"
+	FuncRate          = "rate"
+)
@@ -0,0 +1,173 @@
+package logql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokDuration
+	tokLBrace
+	tokRBrace
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+	tokPipe      // |
+	tokPipeEq    // |=
+	tokPipeTilde // |~
+	tokEq        // =
+	tokEqEq      // ==
+	tokNeq       // !=
+	tokRe        // =~
+	tokNre       // !~
+	tokGt        // >
+	tokGte       // >=
+	tokLt        // <
+	tokLte       // <=
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lex splits a query into tokens. Strings may be double quoted (with Go
+// escapes) or backtick quoted (raw, handy for regular expressions).
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+
+		two := ""
+		if i+1 < len(runes) {
+			two = string(runes[i : i+2])
+		}
+
+		switch two {
+		case "|=":
+			tokens = append(tokens, token{kind: tokPipeEq, text: two, pos: start})
+			i += 2
+			continue
+		case "|~":
+			tokens = append(tokens, token{kind: tokPipeTilde, text: two, pos: start})
+			i += 2
+			continue
+		case "==":
+			tokens = append(tokens, token{kind: tokEqEq, text: two, pos: start})
+			i += 2
+			continue
+		case "!=":
+			tokens = append(tokens, token{kind: tokNeq, text: two, pos: start})
+			i += 2
+			continue
+		case "=~":
+			tokens = append(tokens, token{kind: tokRe, text: two, pos: start})
+			i += 2
+			continue
+		case "!~":
+			tokens = append(tokens, token{kind: tokNre, text: two, pos: start})
+			i += 2
+			continue
+		case ">=":
+			tokens = append(tokens, token{kind: tokGte, text: two, pos: start})
+			i += 2
+			continue
+		case "<=":
+			tokens = append(tokens, token{kind: tokLte, text: two, pos: start})
+			i += 2
+			continue
+		}
+
+		single := map[rune]tokenKind{
+			'{': tokLBrace, '}': tokRBrace,
+			'(': tokLParen, ')': tokRParen,
+			'[': tokLBracket, ']': tokRBracket,
+			',': tokComma, '|': tokPipe,
+			'=': tokEq, '>': tokGt, '<': tokLt,
+		}
+		if kind, ok := single[r]; ok {
+			tokens = append(tokens, token{kind: kind, text: string(r), pos: start})
+			i++
+			continue
+		}
+
+		switch {
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			value, err := strconv.Unquote(string(runes[i : end+1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %v", start, err)
+			}
+			tokens = append(tokens, token{kind: tokString, text: value, pos: start})
+			i = end + 1
+		case r == '`':
+			end := i + 1
+			for end < len(runes) && runes[end] != '`' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokString, text: string(runes[i+1 : end]), pos: start})
+			i = end + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			kind := tokNumber
+			for end < len(runes) && unicode.IsLetter(runes[end]) {
+				kind = tokDuration
+				end++
+			}
+			tokens = append(tokens, token{kind: kind, text: string(runes[i:end]), pos: start})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || strings.ContainsRune("_.-", runes[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[i:end]), pos: start})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, start)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
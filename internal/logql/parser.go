@@ -0,0 +1,322 @@
+package logql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Parse parses a query into a *LogExpr or *MetricExpr.
+func Parse(query string) (Expr, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	var expr Expr
+	if p.peek().kind == tokLBrace {
+		expr, err = p.parseLogExpr()
+	} else {
+		expr, err = p.parseMetricExpr()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, p.errorf(tok, "expected %s, got %s", what, tok)
+	}
+	return tok, nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return fmt.Errorf("parse error at position %d: %s", tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseLogExpr() (*LogExpr, error) {
+	if _, err := p.expect(tokLBrace, "'{'"); err != nil {
+		return nil, err
+	}
+
+	expr := &LogExpr{}
+	for {
+		matcher, err := p.parseMatcher()
+		if err != nil {
+			return nil, err
+		}
+		expr.Matchers = append(expr.Matchers, matcher)
+
+		tok := p.next()
+		if tok.kind == tokRBrace {
+			break
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorf(tok, "expected ',' or '}', got %s", tok)
+		}
+	}
+
+	for {
+		stage, err := p.parseStage(expr)
+		if err != nil {
+			return nil, err
+		}
+		if stage == nil {
+			return expr, nil
+		}
+		expr.Stages = append(expr.Stages, stage)
+	}
+}
+
+func (p *parser) parseMatcher() (Matcher, error) {
+	label, err := p.expect(tokIdent, "label name")
+	if err != nil {
+		return Matcher{}, err
+	}
+
+	opTok := p.next()
+	var op MatchOp
+	switch opTok.kind {
+	case tokEq:
+		op = OpEq
+	case tokNeq:
+		op = OpNeq
+	case tokRe:
+		op = OpRe
+	case tokNre:
+		op = OpNre
+	default:
+		return Matcher{}, p.errorf(opTok, "expected matcher operator, got %s", opTok)
+	}
+
+	value, err := p.expect(tokString, "quoted string")
+	if err != nil {
+		return Matcher{}, err
+	}
+
+	if err := validateRegex(op, value); err != nil {
+		return Matcher{}, err
+	}
+
+	return Matcher{Label: label.text, Op: op, Value: value.text}, nil
+}
+
+// parseStage parses the next pipeline stage, returning nil when the
+// pipeline ends.
+func (p *parser) parseStage(expr *LogExpr) (Stage, error) {
+	tok := p.peek()
+
+	switch tok.kind {
+	case tokPipeEq, tokNeq, tokPipeTilde, tokNre:
+		p.next()
+		value, err := p.expect(tokString, "quoted string")
+		if err != nil {
+			return nil, err
+		}
+		op := map[tokenKind]MatchOp{tokPipeEq: OpEq, tokNeq: OpNeq, tokPipeTilde: OpRe, tokNre: OpNre}[tok.kind]
+		if err := validateRegex(op, value); err != nil {
+			return nil, err
+		}
+		return &LineFilter{Op: op, Value: value.text}, nil
+	case tokPipe:
+		p.next()
+		if p.peek().kind == tokIdent && p.peek().text == "json" {
+			p.next()
+			return &JSONStage{}, nil
+		}
+		return p.parseLabelFilter()
+	default:
+		return nil, nil
+	}
+}
+
+func (p *parser) parseLabelFilter() (Stage, error) {
+	label, err := p.expect(tokIdent, "label name or json")
+	if err != nil {
+		return nil, err
+	}
+
+	opTok := p.next()
+	ops := map[tokenKind]MatchOp{
+		tokEq: OpEq, tokEqEq: OpEq, tokNeq: OpNeq, tokRe: OpRe, tokNre: OpNre,
+		tokGt: OpGt, tokGte: OpGte, tokLt: OpLt, tokLte: OpLte,
+	}
+	op, ok := ops[opTok.kind]
+	if !ok {
+		return nil, p.errorf(opTok, "expected comparison operator, got %s", opTok)
+	}
+
+	valueTok := p.next()
+	filter := &LabelFilter{Label: label.text, Op: op, Value: valueTok.text}
+
+	switch valueTok.kind {
+	case tokString:
+		if op != OpEq && op != OpNeq && op != OpRe && op != OpNre {
+			return nil, p.errorf(valueTok, "operator %s requires a number", op)
+		}
+		if err := validateRegex(op, valueTok); err != nil {
+			return nil, err
+		}
+	case tokNumber:
+		if op == OpRe || op == OpNre {
+			return nil, p.errorf(valueTok, "operator %s requires a quoted string", op)
+		}
+		if _, err := strconv.ParseFloat(valueTok.text, 64); err != nil {
+			return nil, p.errorf(valueTok, "invalid number %s", valueTok)
+		}
+		filter.Numeric = true
+	default:
+		return nil, p.errorf(valueTok, "expected string or number, got %s", valueTok)
+	}
+
+	return filter, nil
+}
+
+func (p *parser) parseMetricExpr() (*MetricExpr, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return nil, p.errorf(tok, "expected '{' or function name, got %s", tok)
+	}
+
+	if tok.text != "sum" {
+		return p.parseRangeAggregation(tok)
+	}
+
+	var grouping []string
+	var hasGrouping bool
+	if p.peek().kind == tokIdent && p.peek().text == "by" {
+		p.next()
+		labels, err := p.parseGrouping()
+		if err != nil {
+			return nil, err
+		}
+		grouping, hasGrouping = labels, true
+	}
+
+	if _, err := p.expect(tokLParen, "'('"); err != nil {
+		return nil, err
+	}
+	fn, err := p.expect(tokIdent, "range function")
+	if err != nil {
+		return nil, err
+	}
+	expr, err := p.parseRangeAggregation(fn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRParen, "')'"); err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind == tokIdent && p.peek().text == "by" {
+		if hasGrouping {
+			return nil, p.errorf(p.peek(), "grouping specified twice")
+		}
+		p.next()
+		if grouping, err = p.parseGrouping(); err != nil {
+			return nil, err
+		}
+	}
+
+	expr.Sum = true
+	expr.Grouping = grouping
+	return expr, nil
+}
+
+func (p *parser) parseGrouping() ([]string, error) {
+	if _, err := p.expect(tokLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	var labels []string
+	for {
+		label, err := p.expect(tokIdent, "label name")
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label.text)
+
+		tok := p.next()
+		if tok.kind == tokRParen {
+			return labels, nil
+		}
+		if tok.kind != tokComma {
+			return nil, p.errorf(tok, "expected ',' or ')', got %s", tok)
+		}
+	}
+}
+
+func (p *parser) parseRangeAggregation(fn token) (*MetricExpr, error) {
+	if fn.text != FuncCountOverTime && fn.text != FuncRate {
+		return nil, p.errorf(fn, "unknown function %s", fn)
+	}
+
+	if _, err := p.expect(tokLParen, "'('"); err != nil {
+		return nil, err
+	}
+	logExpr, err := p.parseLogExpr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokLBracket, "'['"); err != nil {
+		return nil, err
+	}
+	durTok, err := p.expect(tokDuration, "range duration")
+	if err != nil {
+		return nil, err
+	}
+	rng, err := time.ParseDuration(durTok.text)
+	if err != nil || rng <= 0 {
+		return nil, p.errorf(durTok, "invalid range duration %s", durTok)
+	}
+	if _, err := p.expect(tokRBracket, "']'"); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRParen, "')'"); err != nil {
+		return nil, err
+	}
+
+	return &MetricExpr{Func: fn.text, Log: logExpr, Range: rng}, nil
+}
+
+// validateRegex rejects regular expressions Go cannot compile before they
+// reach the database. This is only a first check: PostgreSQL runs them with
+// its own syntax, which rejects some expressions Go accepts, such as named
+// groups.
+func validateRegex(op MatchOp, tok token) error {
+	if op != OpRe && op != OpNre {
+		return nil
+	}
+	if _, err := regexp.Compile(tok.text); err != nil {
+		return fmt.Errorf("parse error at position %d: invalid regex: %v", tok.pos, err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package logql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLogExpr(t *testing.T) {
+	expr, err := Parse(`{service="api", level=~"ERROR|FATAL", user.id!="0"} |= "timeout" != "retry" |~ ` + "`conn(ection)? refused`" + ` !~ "health" | json | http.status >= 500 | route == "/login"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &LogExpr{
+		Matchers: []Matcher{
+			{Label: "service", Op: OpEq, Value: "api"},
+			{Label: "level", Op: OpRe, Value: "ERROR|FATAL"},
+			{Label: "user.id", Op: OpNeq, Value: "0"},
+		},
+		Stages: []Stage{
+			&LineFilter{Op: OpEq, Value: "timeout"},
+			&LineFilter{Op: OpNeq, Value: "retry"},
+			&LineFilter{Op: OpRe, Value: "conn(ection)? refused"},
+			&LineFilter{Op: OpNre, Value: "health"},
+			&JSONStage{},
+			&LabelFilter{Label: "http.status", Op: OpGte, Value: "500", Numeric: true},
+			&LabelFilter{Label: "route", Op: OpEq, Value: "/login"},
+		},
+	}
+
+	if !reflect.DeepEqual(expr, want) {
+		t.Errorf("Parse = %#v, want %#v", expr, want)
+	}
+}
+
+func TestParseMetricExpr(t *testing.T) {
+	cases := []struct {
+		query string
+		want  *MetricExpr
+	}{
+		{
+			query: `count_over_time({service="api"}[5m])`,
+			want: &MetricExpr{
+				Func:  FuncCountOverTime,
+				Log:   &LogExpr{Matchers: []Matcher{{Label: "service", Op: OpEq, Value: "api"}}},
+				Range: 5 * time.Minute,
+			},
+		},
+		{
+			query: `sum by (service, level) (rate({level="ERROR"}[1m]))`,
+			want: &MetricExpr{
+				Func:     FuncRate,
+				Log:      &LogExpr{Matchers: []Matcher{{Label: "level", Op: OpEq, Value: "ERROR"}}},
+				Range:    time.Minute,
+				Sum:      true,
+				Grouping: []string{"service", "level"},
+			},
+		},
+		{
+			query: `sum(rate({level="ERROR"} | json [30s])) by (customer)`,
+			want: &MetricExpr{
+				Func: FuncRate,
+				Log: &LogExpr{
+					Matchers: []Matcher{{Label: "level", Op: OpEq, Value: "ERROR"}},
+					Stages:   []Stage{&JSONStage{}},
+				},
+				Range:    30 * time.Second,
+				Sum:      true,
+				Grouping: []string{"customer"},
+			},
+		},
+		{
+			query: `sum(count_over_time({service="api"}[1h]))`,
+			want: &MetricExpr{
+				Func:  FuncCountOverTime,
+				Log:   &LogExpr{Matchers: []Matcher{{Label: "service", Op: OpEq, Value: "api"}}},
+				Range: time.Hour,
+				Sum:   true,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		expr, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("Parse(%q) unexpected error: %v", c.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(expr, c.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", c.query, expr, c.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	queries := []string{
+		``,
+		`{}`,
+		`{service}`,
+		`{service="api"`,
+		`{service=api}`,
+		`{service="api",}`,
+		`{service="api"} |= timeout`,
+		`{service="api"} | status > "high"`,
+		`{service="api"} | status =~ 5`,
+		`{service=~"a("}`,
+		`{service="api"} |~ "(unclosed"`,
+		`{service="api"} extra`,
+		`"unterminated`,
+		`max(rate({service="api"}[5m]))`,
+		`rate({service="api"})`,
+		`rate({service="api"}[5])`,
+		`rate({service="api"}[5x])`,
+		`sum by (service) (rate({service="api"}[5m])) by (level)`,
+		`sum by () (rate({service="api"}[5m]))`,
+		`{service="api"} $`,
+	}
+
+	for _, q := range queries {
+		if expr, err := Parse(q); err == nil {
+			t.Errorf("Parse(%q) expected error, got %#v", q, expr)
+		}
+	}
+}
+
+func TestParseRegexOnlyValidatedForRegexOperators(t *testing.T) {
+	if _, err := Parse(`{service="a("} |= "(" | route = "("`); err != nil {
+		t.Errorf("unexpected error for literal parenthesis: %v", err)
+	}
+}
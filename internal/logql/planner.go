@@ -0,0 +1,327 @@
+package logql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options bound the evaluation of a query.
+type Options struct {
+	Start time.Time
+	End   time.Time
+	// Step is the distance between points of a metric query.
+	Step time.Duration
+	// Limit caps the number of lines returned by a log query.
+	Limit int
+}
+
+// Plan is a query translated to SQL.
+//
+// Log queries return the columns id, service_name, log_level, message,
+// timestamp, trace_id, span_id and metadata, newest first.
+//
+// Metric queries return the evaluation timestamp, one text column per entry
+// in Labels and the sample value, ordered by labels then time.
+type Plan struct {
+	SQL    string
+	Params []any
+	Metric bool
+	Labels []string
+}
+
+// streamLabels are the labels every log line has. Anything else refers to a
+// key in the log's metadata.
+var streamLabels = map[string]string{
+	"service":  "service_name",
+	"level":    "COALESCE(log_level, '')",
+	"trace_id": "COALESCE(trace_id::text, '')",
+	"span_id":  "COALESCE(span_id::text, '')",
+}
+
+// defaultGrouping is used for metric queries without a sum.
+var defaultGrouping = []string{"service", "level"}
+
+// labelPattern restricts metadata label names so their key paths can be
+// embedded in SQL as array literals.
+var labelPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*(\.[A-Za-z0-9_-]+)*$`)
+
+// numericPattern matches text PostgreSQL can cast to numeric.
+const numericPattern = `^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`
+
+// NewPlan translates a parsed query into SQL.
+func NewPlan(expr Expr, opts Options) (*Plan, error) {
+	if opts.Start.IsZero() || opts.End.IsZero() || !opts.Start.Before(opts.End) {
+		return nil, errors.New("query requires a start time before its end time")
+	}
+
+	b := &builder{}
+
+	switch e := expr.(type) {
+	case *LogExpr:
+		if opts.Limit <= 0 {
+			return nil, errors.New("log queries require a positive limit")
+		}
+		return b.planLogs(e, opts)
+	case *MetricExpr:
+		if opts.Step <= 0 {
+			return nil, errors.New("metric queries require a positive step")
+		}
+		return b.planMetric(e, opts)
+	default:
+		return nil, fmt.Errorf("unsupported expression %T", expr)
+	}
+}
+
+type builder struct {
+	params []any
+}
+
+// arg registers a parameter and returns its placeholder.
+func (b *builder) arg(value any) string {
+	b.params = append(b.params, value)
+	return "$" + strconv.Itoa(len(b.params))
+}
+
+func (b *builder) planLogs(e *LogExpr, opts Options) (*Plan, error) {
+	sql := `SELECT id, service_name, log_level, message, timestamp, trace_id, span_id, metadata
+              FROM logs
+              WHERE timestamp >= ` + b.arg(opts.Start) + ` AND timestamp <= ` + b.arg(opts.End)
+
+	conds, err := b.logConditions(e)
+	if err != nil {
+		return nil, err
+	}
+	for _, cond := range conds {
+		sql += " AND " + cond
+	}
+	sql += " ORDER BY timestamp DESC, id DESC LIMIT " + b.arg(opts.Limit)
+
+	return &Plan{SQL: sql, Params: b.params}, nil
+}
+
+func (b *builder) planMetric(e *MetricExpr, opts Options) (*Plan, error) {
+	grouping := e.Grouping
+	if !e.Sum {
+		grouping = defaultGrouping
+	}
+
+	hasJSON := false
+	for _, stage := range e.Log.Stages {
+		if _, ok := stage.(*JSONStage); ok {
+			hasJSON = true
+		}
+	}
+
+	var labelCols []string
+	for _, label := range grouping {
+		col, err := labelExpr(label, hasJSON)
+		if err != nil {
+			return nil, err
+		}
+		labelCols = append(labelCols, col)
+	}
+
+	start := b.arg(opts.Start)
+	end := b.arg(opts.End)
+	step := b.arg(opts.Step.Seconds())
+	rng := b.arg(e.Range.Seconds())
+
+	conds, err := b.logConditions(e.Log)
+	if err != nil {
+		return nil, err
+	}
+
+	value := "COUNT(*)::float8"
+	if e.Func == FuncRate {
+		value = "COUNT(*)::float8 / " + rng
+	}
+
+	sql := `WITH steps AS (
+                  SELECT generate_series(` + start + `::timestamp, ` + end + `::timestamp, ` + step + ` * INTERVAL '1 second') AS ts
+              )
+              SELECT steps.ts`
+	for _, col := range labelCols {
+		sql += ", " + col
+	}
+	sql += ", " + value + `
+              FROM steps
+              JOIN logs ON timestamp > steps.ts - ` + rng + ` * INTERVAL '1 second' AND timestamp <= steps.ts
+              WHERE timestamp > ` + start + `::timestamp - ` + rng + ` * INTERVAL '1 second' AND timestamp <= ` + end + `::timestamp`
+	for _, cond := range conds {
+		sql += " AND " + cond
+	}
+
+	var groupBy []string
+	for i := range labelCols {
+		groupBy = append(groupBy, strconv.Itoa(i+2))
+	}
+	sql += " GROUP BY " + strings.Join(append([]string{"1"}, groupBy...), ", ")
+	sql += " ORDER BY " + strings.Join(append(groupBy, "1"), ", ")
+
+	return &Plan{SQL: sql, Params: b.params, Metric: true, Labels: grouping}, nil
+}
+
+// logConditions renders the selector and pipeline as SQL conditions.
+func (b *builder) logConditions(e *LogExpr) ([]string, error) {
+	var conds []string
+
+	for _, m := range e.Matchers {
+		cond, err := b.matcherCondition(m)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+
+	hasJSON := false
+	for _, stage := range e.Stages {
+		switch s := stage.(type) {
+		case *LineFilter:
+			conds = append(conds, b.lineFilterCondition(s))
+		case *JSONStage:
+			hasJSON = true
+		case *LabelFilter:
+			cond, err := b.labelFilterCondition(s, hasJSON)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+		}
+	}
+
+	return conds, nil
+}
+
+func (b *builder) matcherCondition(m Matcher) (string, error) {
+	// Equality on indexed columns is rendered so PostgreSQL can use the
+	// service, level and metadata indexes.
+	if m.Op == OpEq && m.Value != "" {
+		switch m.Label {
+		case "service":
+			return "service_name = " + b.arg(m.Value), nil
+		case "level":
+			return "log_level = " + b.arg(m.Value), nil
+		}
+		if _, ok := streamLabels[m.Label]; !ok {
+			if !labelPattern.MatchString(m.Label) {
+				return "", fmt.Errorf("invalid label name %q", m.Label)
+			}
+			return b.metadataContainment(m.Label, m.Value), nil
+		}
+	}
+
+	col, ok := streamLabels[m.Label]
+	if !ok {
+		if !labelPattern.MatchString(m.Label) {
+			return "", fmt.Errorf("invalid label name %q", m.Label)
+		}
+		col = metadataExpr(m.Label)
+	}
+	return b.textComparison(col, m.Op, m.Value), nil
+}
+
+// metadataContainment matches a metadata key equal to value, as a string or
+// as a number when value is numeric.
+func (b *builder) metadataContainment(label, value string) string {
+	candidates := []any{value}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		candidates = append(candidates, n)
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		var doc any = candidate
+		path := strings.Split(label, ".")
+		for i := len(path) - 1; i >= 0; i-- {
+			doc = map[string]any{path[i]: doc}
+		}
+		data, _ := json.Marshal(doc)
+		matches = append(matches, "metadata @> "+b.arg(string(data))+"::jsonb")
+	}
+
+	return "(" + strings.Join(matches, " OR ") + ")"
+}
+
+func (b *builder) textComparison(col string, op MatchOp, value string) string {
+	switch op {
+	case OpNeq:
+		return col + " <> " + b.arg(value)
+	case OpRe:
+		return col + " ~ " + b.arg(value)
+	case OpNre:
+		return col + " !~ " + b.arg(value)
+	default:
+		return col + " = " + b.arg(value)
+	}
+}
+
+func (b *builder) lineFilterCondition(f *LineFilter) string {
+	switch f.Op {
+	case OpNeq:
+		return "strpos(message, " + b.arg(f.Value) + ") = 0"
+	case OpRe:
+		return "message ~ " + b.arg(f.Value)
+	case OpNre:
+		return "message !~ " + b.arg(f.Value)
+	default:
+		return "strpos(message, " + b.arg(f.Value) + ") > 0"
+	}
+}
+
+func (b *builder) labelFilterCondition(f *LabelFilter, hasJSON bool) (string, error) {
+	col, err := labelExpr(f.Label, hasJSON)
+	if err != nil {
+		return "", err
+	}
+
+	if !f.Numeric {
+		return b.textComparison(col, f.Op, f.Value), nil
+	}
+
+	if _, ok := streamLabels[f.Label]; ok {
+		return "", fmt.Errorf("label %s is not numeric", f.Label)
+	}
+
+	n, _ := strconv.ParseFloat(f.Value, 64)
+	numeric := "(CASE WHEN " + col + " ~ '" + numericPattern + "' THEN (" + col + ")::numeric END)"
+	op := string(f.Op)
+	if f.Op == OpNeq {
+		op = "<>"
+	}
+	return numeric + " " + op + " " + b.arg(n), nil
+}
+
+// labelExpr returns a text SQL expression for a label, ” when missing.
+// Other labels are only available after a json stage, which reads them from
+// the metadata and then from the message when it is a JSON object.
+func labelExpr(label string, hasJSON bool) (string, error) {
+	if col, ok := streamLabels[label]; ok {
+		return col, nil
+	}
+
+	if !labelPattern.MatchString(label) {
+		return "", fmt.Errorf("invalid label name %q", label)
+	}
+
+	if !hasJSON {
+		return "", fmt.Errorf("label %s is not a stream label; add a | json stage to use metadata fields", label)
+	}
+
+	path := labelPath(label)
+	return "COALESCE(metadata #>> '" + path + "', log_message_json(message) #>> '" + path + "', '')", nil
+}
+
+// metadataExpr returns a text SQL expression for a metadata key, ” when
+// missing.
+func metadataExpr(label string) string {
+	return "COALESCE(metadata #>> '" + labelPath(label) + "', '')"
+}
+
+func labelPath(label string) string {
+	return "{" + strings.ReplaceAll(label, ".", ",") + "}"
+}
@@ -0,0 +1,152 @@
+package logql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testStart = time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC)
+	testEnd   = testStart.Add(time.Hour)
+)
+
+func mustPlan(t *testing.T, query string, opts Options) *Plan {
+	t.Helper()
+	expr, err := Parse(query)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", query, err)
+	}
+	plan, err := NewPlan(expr, opts)
+	if err != nil {
+		t.Fatalf("NewPlan(%q): %v", query, err)
+	}
+	return plan
+}
+
+func TestPlanLogQuery(t *testing.T) {
+	plan := mustPlan(t,
+		`{service="api", level!="DEBUG", customer="acme"} |= "timeout" !~ "health" | json | http.status >= 500`,
+		Options{Start: testStart, End: testEnd, Limit: 50})
+
+	if plan.Metric {
+		t.Errorf("log query planned as metric query")
+	}
+
+	for _, fragment := range []string{
+		"FROM logs",
+		"timestamp >= $1 AND timestamp <= $2",
+		"service_name = $3",
+		"COALESCE(log_level, '') <> $4",
+		"metadata @> $5::jsonb",
+		"strpos(message, $6) > 0",
+		"message !~ $7",
+		"COALESCE(metadata #>> '{http,status}', log_message_json(message) #>> '{http,status}', '')",
+		"::numeric END) >= $8",
+		"ORDER BY timestamp DESC, id DESC LIMIT $9",
+	} {
+		if !strings.Contains(plan.SQL, fragment) {
+			t.Errorf("SQL missing %q:\n%s", fragment, plan.SQL)
+		}
+	}
+
+	want := []any{testStart, testEnd, "api", "DEBUG", `{"customer":"acme"}`, "timeout", "health", 500.0, 50}
+	if !reflect.DeepEqual(plan.Params, want) {
+		t.Errorf("params = %#v, want %#v", plan.Params, want)
+	}
+}
+
+func TestPlanSelectorReadsMetadataOnly(t *testing.T) {
+	plan := mustPlan(t, `{user.id=~"4.*"}`, Options{Start: testStart, End: testEnd, Limit: 10})
+
+	if !strings.Contains(plan.SQL, "COALESCE(metadata #>> '{user,id}', '') ~ $3") {
+		t.Errorf("expected regex on metadata:\n%s", plan.SQL)
+	}
+	if strings.Contains(plan.SQL, "log_message_json") {
+		t.Errorf("selector without a json stage read the message:\n%s", plan.SQL)
+	}
+}
+
+func TestPlanMetadataEqualityMatchesNumbers(t *testing.T) {
+	plan := mustPlan(t, `{user.id="42"}`, Options{Start: testStart, End: testEnd, Limit: 10})
+
+	if !strings.Contains(plan.SQL, "(metadata @> $3::jsonb OR metadata @> $4::jsonb)") {
+		t.Errorf("expected containment on string and number:\n%s", plan.SQL)
+	}
+	if plan.Params[2] != `{"user":{"id":"42"}}` || plan.Params[3] != `{"user":{"id":42}}` {
+		t.Errorf("unexpected containment params: %v", plan.Params[2:4])
+	}
+}
+
+func TestPlanMetricQuery(t *testing.T) {
+	plan := mustPlan(t,
+		`sum by (service, customer) (rate({level="ERROR"} | json [5m]))`,
+		Options{Start: testStart, End: testEnd, Step: time.Minute})
+
+	if !plan.Metric {
+		t.Fatalf("metric query planned as log query")
+	}
+	if !reflect.DeepEqual(plan.Labels, []string{"service", "customer"}) {
+		t.Errorf("labels = %v", plan.Labels)
+	}
+
+	for _, fragment := range []string{
+		"generate_series($1::timestamp, $2::timestamp, $3 * INTERVAL '1 second')",
+		"SELECT steps.ts, service_name, COALESCE(metadata #>> '{customer}', log_message_json(message) #>> '{customer}', ''), COUNT(*)::float8 / $4",
+		"JOIN logs ON timestamp > steps.ts - $4 * INTERVAL '1 second' AND timestamp <= steps.ts",
+		"log_level = $5",
+		"GROUP BY 1, 2, 3",
+		"ORDER BY 2, 3, 1",
+	} {
+		if !strings.Contains(plan.SQL, fragment) {
+			t.Errorf("SQL missing %q:\n%s", fragment, plan.SQL)
+		}
+	}
+
+	want := []any{testStart, testEnd, 60.0, 300.0, "ERROR"}
+	if !reflect.DeepEqual(plan.Params, want) {
+		t.Errorf("params = %#v, want %#v", plan.Params, want)
+	}
+}
+
+func TestPlanMetricDefaultGrouping(t *testing.T) {
+	plan := mustPlan(t, `count_over_time({service="api"}[1m])`, Options{Start: testStart, End: testEnd, Step: time.Minute})
+	if !reflect.DeepEqual(plan.Labels, []string{"service", "level"}) {
+		t.Errorf("labels = %v", plan.Labels)
+	}
+	if strings.Contains(plan.SQL, "/ $4") {
+		t.Errorf("count_over_time should not divide by the range:\n%s", plan.SQL)
+	}
+
+	plan = mustPlan(t, `sum(count_over_time({service="api"}[1m]))`, Options{Start: testStart, End: testEnd, Step: time.Minute})
+	if len(plan.Labels) != 0 || !strings.Contains(plan.SQL, "GROUP BY 1 ORDER BY 1") {
+		t.Errorf("ungrouped sum should only group by time, got labels %v:\n%s", plan.Labels, plan.SQL)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	cases := []struct {
+		query string
+		opts  Options
+	}{
+		{query: `{service="api"} | status >= 500`, opts: Options{Start: testStart, End: testEnd, Limit: 10}},
+		{query: `{service="api"} | status >= 500 | json`, opts: Options{Start: testStart, End: testEnd, Limit: 10}},
+		{query: `{service="api"} | json | level > 3`, opts: Options{Start: testStart, End: testEnd, Limit: 10}},
+		{query: `sum by (customer) (rate({service="api"}[5m]))`, opts: Options{Start: testStart, End: testEnd, Step: time.Minute}},
+		{query: `{service="api"}`, opts: Options{Start: testEnd, End: testStart, Limit: 10}},
+		{query: `{service="api"}`, opts: Options{Start: testStart, End: testEnd}},
+		{query: `rate({service="api"}[5m])`, opts: Options{Start: testStart, End: testEnd}},
+		{query: `{bad..label="x"}`, opts: Options{Start: testStart, End: testEnd, Limit: 10}},
+	}
+
+	for _, c := range cases {
+		expr, err := Parse(c.query)
+		if err != nil {
+			continue
+		}
+		if plan, err := NewPlan(expr, c.opts); err == nil {
+			t.Errorf("NewPlan(%q) expected error, got:\n%s", c.query, plan.SQL)
+		}
+	}
+}
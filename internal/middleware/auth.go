@@ -2,25 +2,56 @@ package middleware
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/apikeys"
+	"github.com/NathanSanchezDev/go-insight/internal/jwtauth"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
 )
 
+// apiKeyTouchInterval limits how often the last-used time of an API key is
+// written.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyStore finds the API keys managed through the API.
+type APIKeyStore interface {
+	FindAPIKey(ctx context.Context, prefix string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error
+}
+
+// apiKeyStore is nil until SetAPIKeyStore is called; until then only the
+// API_KEY and JWT credentials are accepted.
+var apiKeyStore APIKeyStore
+
+// SetAPIKeyStore enables authentication with stored API keys.
+func SetAPIKeyStore(store APIKeyStore) {
+	apiKeyStore = store
+}
+
+type restrictedServiceKey struct{}
+
+// RestrictedService returns the service the request's API key is limited
+// to, or "" when it may use any service.
+func RestrictedService(ctx context.Context) string {
+	service, _ := ctx.Value(restrictedServiceKey{}).(string)
+	return service
+}
+
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		expectedAPIKey := os.Getenv("API_KEY")
-		jwtSecret := os.Getenv("JWT_SECRET")
+		jwt := loadJWTSettings()
 
-		if expectedAPIKey == "" && jwtSecret == "" {
-			log.Println("⚠️  WARNING: No API_KEY or JWT_SECRET configured, authentication disabled")
+		if expectedAPIKey == "" && !jwt.enabled() && sessionStore == nil {
+			log.Println("⚠️  WARNING: No API_KEY, JWT or login settings configured, authentication disabled")
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -30,16 +61,40 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		var role string
 		var authenticated bool
+		var key *models.APIKey
 
 		if expectedAPIKey != "" && apiKey == expectedAPIKey {
 			role = "admin"
 			authenticated = true
-		} else if jwtSecret != "" && token != "" {
-			claims, err := parseJWT(token, jwtSecret)
+		} else if _, ok := apikeys.Prefix(apiKey); ok && apiKeyStore != nil {
+			stored, err := authenticateAPIKey(r.Context(), apiKey, time.Now())
+			if err != nil {
+				log.Printf("🔒 API key validation error: %v", err)
+			} else {
+				key = &stored
+				role = stored.Role
+				authenticated = true
+			}
+		} else if jwt.enabled() && token != "" {
+			tokenRole, err := authenticateJWT(r.Context(), jwt, token, time.Now())
 			if err != nil {
 				log.Printf("🔒 JWT validation error: %v", err)
 			} else {
-				role = claims.Role
+				role = tokenRole
+				authenticated = true
+			}
+		} else if apiKey == "" && sessionStore != nil {
+			session, err := authenticateSession(r, time.Now())
+			if err != nil {
+				if !errors.Is(err, http.ErrNoCookie) {
+					log.Printf("🔒 Session validation error: %v", err)
+				}
+			} else if !sameOrigin(r) {
+				log.Printf("🔒 Access denied: cross-origin %s %s with session cookie", r.Method, r.URL.Path)
+				http.Error(w, `{"error": "Forbidden"}`, http.StatusForbidden)
+				return
+			} else {
+				role = session.Role
 				authenticated = true
 			}
 		}
@@ -50,7 +105,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		requiredRole := EndpointRoles[r.URL.Path]
+		requiredRole := requiredRole(r.URL.Path)
 		if requiredRole != "" && !hasRole(role, requiredRole) {
 			log.Printf("🔒 Access denied: role %s required for %s", requiredRole, r.URL.Path)
 			http.Error(w, `{"error": "Forbidden"}`, http.StatusForbidden)
@@ -58,11 +113,107 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		ctx := context.WithValue(r.Context(), "role", role)
+		if key != nil {
+			if !apikeys.Allows(key.Scopes, r.Method, r.URL.Path) {
+				log.Printf("🔒 Access denied: API key %s has no scope for %s %s", key.Prefix, r.Method, r.URL.Path)
+				http.Error(w, `{"error": "Forbidden"}`, http.StatusForbidden)
+				return
+			}
+			if key.Service != "" {
+				if !restrictService(r, key.Service) {
+					log.Printf("🔒 Access denied: API key %s is limited to service %s", key.Prefix, key.Service)
+					http.Error(w, `{"error": "Forbidden"}`, http.StatusForbidden)
+					return
+				}
+				ctx = context.WithValue(ctx, restrictedServiceKey{}, key.Service)
+			}
+		}
+
 		log.Printf("✅ Authenticated request: %s %s as %s", r.Method, r.RequestURI, role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// authenticateAPIKey returns the stored key matching apiKey if it is neither
+// revoked nor expired, recording that it was used.
+func authenticateAPIKey(ctx context.Context, apiKey string, now time.Time) (models.APIKey, error) {
+	prefix, _ := apikeys.Prefix(apiKey)
+	key, err := apiKeyStore.FindAPIKey(ctx, prefix)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("key %s: %w", prefix, err)
+	}
+
+	switch {
+	case !apikeys.Verify(apiKey, key.KeyHash):
+		return models.APIKey{}, fmt.Errorf("key %s: secret mismatch", prefix)
+	case key.RevokedAt != nil:
+		return models.APIKey{}, fmt.Errorf("key %s: revoked", prefix)
+	case key.ExpiresAt != nil && !now.Before(*key.ExpiresAt):
+		return models.APIKey{}, fmt.Errorf("key %s: expired", prefix)
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := apiKeyStore.TouchAPIKey(ctx, key.ID, now); err != nil {
+			log.Printf("❌ Error recording API key use: %v", err)
+		}
+	}
+
+	return key, nil
+}
+
+// serviceFilteredPaths are the read endpoints that accept a service filter.
+var serviceFilteredPaths = map[string]bool{
+	"/api/anomalies":               true,
+	"/api/dependencies":            true,
+	"/api/error-groups":            true,
+	"/api/logs":                    true,
+	"/api/logs/patterns":           true,
+	"/api/logs/tail":               true,
+	"/api/metrics":                 true,
+	"/api/metrics/aggregate":       true,
+	"/api/metrics/series":          true,
+	"/api/metrics/stream":          true,
+	"/api/spans/metrics/aggregate": true,
+	"/api/spans/metrics/series":    true,
+	"/api/traces":                  true,
+	"/api/traces/stream":           true,
+}
+
+// isIngestPath reports whether path receives telemetry.
+func isIngestPath(path string) bool {
+	switch path {
+	case "/api/logs", "/api/logs/bulk", "/api/metrics", "/api/traces", "/api/spans":
+		return true
+	}
+	for _, base := range []string{"/api/traces/", "/api/spans/"} {
+		if id, ok := strings.CutPrefix(path, base); ok {
+			id, ok = strings.CutSuffix(id, "/end")
+			return ok && id != "" && !strings.Contains(id, "/")
+		}
+	}
+	return false
+}
+
+// restrictService limits a request to service. Reads must go to an endpoint
+// with a service filter, which is set to service; ingestion handlers check
+// the service of the data they receive. Everything else is refused.
+func restrictService(r *http.Request, service string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return isIngestPath(r.URL.Path)
+	}
+	if !serviceFilteredPaths[r.URL.Path] {
+		return false
+	}
+
+	q := r.URL.Query()
+	if requested := q.Get("service"); requested != "" && requested != service {
+		return false
+	}
+	q.Set("service", service)
+	r.URL.RawQuery = q.Encode()
+	return true
+}
+
 func extractAPIKey(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader != "" {
@@ -109,54 +260,236 @@ func extractJWT(r *http.Request) string {
 	return ""
 }
 
-type jwtClaims struct {
-	Role string `json:"role"`
-	Exp  int64  `json:"exp"`
+// jwtLeeway allows for clock skew between go-insight and token issuers.
+const jwtLeeway = 30 * time.Second
+
+// jwtSettings configures the tokens AuthMiddleware accepts. HS256 tokens
+// need JWT_SECRET; RS256, ES256 and EdDSA tokens need the keys of a provider,
+// either from JWT_JWKS_URL or found through JWT_OIDC_DISCOVERY_URL.
+type jwtSettings struct {
+	secret       string
+	discoveryURL string
+	jwksURL      string
+	issuer       string
+	audience     string
+	roleClaim    string
+	roleMapping  string
 }
 
-func parseJWT(token, secret string) (*jwtClaims, error) {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid token")
+func loadJWTSettings() jwtSettings {
+	return jwtSettings{
+		secret:       os.Getenv("JWT_SECRET"),
+		discoveryURL: os.Getenv("JWT_OIDC_DISCOVERY_URL"),
+		jwksURL:      os.Getenv("JWT_JWKS_URL"),
+		issuer:       os.Getenv("JWT_ISSUER"),
+		audience:     os.Getenv("JWT_AUDIENCE"),
+		roleClaim:    os.Getenv("JWT_ROLE_CLAIM"),
+		roleMapping:  os.Getenv("JWT_ROLE_MAPPING"),
 	}
+}
 
-	base64Raw := base64.RawURLEncoding
-	sig, err := base64Raw.DecodeString(parts[2])
-	if err != nil {
-		return nil, fmt.Errorf("invalid signature")
+func (s jwtSettings) enabled() bool {
+	return s.secret != "" || s.discoveryURL != "" || s.jwksURL != ""
+}
+
+// audiences returns the accepted aud values.
+func (s jwtSettings) audiences() []string {
+	var audiences []string
+	for _, aud := range strings.Split(s.audience, ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			audiences = append(audiences, aud)
+		}
 	}
+	return audiences
+}
 
-	unsigned := parts[0] + "." + parts[1]
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(unsigned))
-	expected := mac.Sum(nil)
-	if !hmac.Equal(sig, expected) {
-		return nil, fmt.Errorf("signature mismatch")
+// validate rejects settings that would accept tokens meant for other
+// applications: a provider's keys sign tokens for every client it serves, so
+// its tokens must be checked against an audience.
+func (s jwtSettings) validate() error {
+	if (s.discoveryURL != "" || s.jwksURL != "") && len(s.audiences()) == 0 {
+		return errors.New("JWT_AUDIENCE is required with JWT_OIDC_DISCOVERY_URL or JWT_JWKS_URL")
 	}
+	return nil
+}
 
-	payloadBytes, err := base64Raw.DecodeString(parts[1])
-	if err != nil {
-		return nil, fmt.Errorf("invalid payload")
+// CheckJWTSettings validates the JWT settings of the environment, so that
+// misconfiguration stops the server at startup.
+func CheckJWTSettings() error {
+	return loadJWTSettings().validate()
+}
+
+// jwtAuth verifies tokens and maps their claims to roles.
+type jwtAuth struct {
+	verifier *jwtauth.Verifier
+	roles    jwtauth.RoleMapper
+}
+
+// jwtState caches the jwtAuth of the current settings, so keys fetched from
+// the provider are reused across requests. Discovery runs without holding the
+// lock, one at a time; requests arriving meanwhile wait for its result. Failed
+// discovery is retried on the next request.
+var jwtState struct {
+	sync.Mutex
+	settings jwtSettings
+	auth     *jwtAuth
+	err      error
+	pending  chan struct{}
+}
+
+func jwtAuthFor(ctx context.Context, settings jwtSettings) (*jwtAuth, error) {
+	jwtState.Lock()
+	for jwtState.pending != nil {
+		wait := jwtState.pending
+		jwtState.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		jwtState.Lock()
+		if jwtState.settings == settings && jwtState.err != nil {
+			err := jwtState.err
+			jwtState.Unlock()
+			return nil, err
+		}
+	}
+
+	if jwtState.auth != nil && jwtState.settings == settings {
+		auth := jwtState.auth
+		jwtState.Unlock()
+		return auth, nil
+	}
+
+	done := make(chan struct{})
+	jwtState.settings = settings
+	jwtState.auth, jwtState.err = nil, nil
+	jwtState.pending = done
+	jwtState.Unlock()
+
+	auth, err := newJWTAuth(ctx, settings)
+
+	jwtState.Lock()
+	jwtState.auth, jwtState.err = auth, err
+	jwtState.pending = nil
+	close(done)
+	jwtState.Unlock()
+	return auth, err
+}
+
+func newJWTAuth(ctx context.Context, settings jwtSettings) (*jwtAuth, error) {
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	verifier := &jwtauth.Verifier{
+		Secret: []byte(settings.secret),
+		Issuer: settings.issuer,
+		Leeway: jwtLeeway,
+	}
+
+	jwksURL := settings.jwksURL
+	if settings.discoveryURL != "" {
+		metadata, err := jwtauth.Discover(ctx, client, settings.discoveryURL)
+		if err != nil {
+			return nil, err
+		}
+		if verifier.Issuer == "" {
+			verifier.Issuer = metadata.Issuer
+		}
+		if jwksURL == "" {
+			jwksURL = metadata.JWKSURI
+		}
+	}
+	if jwksURL != "" {
+		verifier.Keys = jwtauth.NewKeySet(jwksURL, client)
 	}
 
-	var claims jwtClaims
-	if err := json.Unmarshal(payloadBytes, &claims); err != nil {
+	verifier.Audiences = settings.audiences()
+
+	mapping, err := jwtauth.ParseRoleMapping(settings.roleMapping)
+	if err != nil {
 		return nil, err
 	}
+	roles := jwtauth.RoleMapper{Claim: settings.roleClaim, Mapping: mapping}
+	if roles.Claim == "" {
+		roles.Claim = "role"
+	}
 
-	if claims.Exp > 0 && time.Now().Unix() > claims.Exp {
-		return nil, fmt.Errorf("token expired")
+	return &jwtAuth{verifier: verifier, roles: roles}, nil
+}
+
+// authenticateJWT returns the role granted by a valid token.
+func authenticateJWT(ctx context.Context, settings jwtSettings, token string, now time.Time) (string, error) {
+	auth, err := jwtAuthFor(ctx, settings)
+	if err != nil {
+		return "", err
 	}
+	claims, err := auth.verifier.Verify(ctx, token, now)
+	if err != nil {
+		return "", err
+	}
+	return HighestRole(auth.roles.Roles(claims)), nil
+}
 
-	return &claims, nil
+// HighestRole returns admin or user when roles contain them, and otherwise
+// the first role.
+func HighestRole(roles []string) string {
+	for _, role := range []string{"admin", "user"} {
+		if slices.Contains(roles, role) {
+			return role
+		}
+	}
+	if len(roles) > 0 {
+		return roles[0]
+	}
+	return ""
 }
 
 var EndpointRoles = map[string]string{
-	"/api/logs":      "user",
-	"/api/logs/bulk": "user",
-	"/api/metrics":   "user",
-	"/api/spans":     "user",
-	"/api/traces":    "user",
+	"/api/alert-rules":             "user",
+	"/api/api-keys":                "admin",
+	"/api/alerts":                  "user",
+	"/api/alerts/history":          "user",
+	"/api/anomalies":               "user",
+	"/api/dependencies":            "user",
+	"/api/error-groups":            "user",
+	"/api/inhibit-rules":           "user",
+	"/api/logs":                    "user",
+	"/api/logs/bulk":               "user",
+	"/api/logs/patterns":           "user",
+	"/api/logs/tail":               "user",
+	"/api/logs/query":              "user",
+	"/api/metrics":                 "user",
+	"/api/metrics/aggregate":       "user",
+	"/api/metrics/series":          "user",
+	"/api/metrics/stream":          "user",
+	"/api/notification-channels":   "admin",
+	"/api/notification-deliveries": "user",
+	"/api/silences":                "user",
+	"/api/slos":                    "user",
+	"/api/spans":                   "user",
+	"/api/spans/metrics/aggregate": "user",
+	"/api/spans/metrics/series":    "user",
+	"/api/traces":                  "user",
+	"/api/traces/stream":           "user",
+}
+
+// requiredRole returns the role EndpointRoles requires for path or, when
+// path has no entry, for its closest parent path with one. Routes such as
+// /api/alert-rules/{id} thereby need the role of /api/alert-rules.
+func requiredRole(path string) string {
+	for {
+		if role, ok := EndpointRoles[path]; ok {
+			return role
+		}
+		i := strings.LastIndex(path, "/")
+		if i <= 0 {
+			return ""
+		}
+		path = path[:i]
+	}
 }
 
 func hasRole(userRole, required string) bool {
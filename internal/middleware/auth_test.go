@@ -1,9 +1,21 @@
 package middleware
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/apikeys"
+	"github.com/NathanSanchezDev/go-insight/internal/models"
 )
 
 func TestExtractAPIKey(t *testing.T) {
@@ -49,3 +61,303 @@ func TestRequiresAuth(t *testing.T) {
 		t.Errorf("/dashboard should not require auth (static files)")
 	}
 }
+
+type fakeAPIKeyStore struct {
+	keys    map[string]models.APIKey
+	touched []int
+}
+
+func (s *fakeAPIKeyStore) FindAPIKey(ctx context.Context, prefix string) (models.APIKey, error) {
+	key, ok := s.keys[prefix]
+	if !ok {
+		return models.APIKey{}, sql.ErrNoRows
+	}
+	return key, nil
+}
+
+func (s *fakeAPIKeyStore) TouchAPIKey(ctx context.Context, id int, usedAt time.Time) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+// storeKey adds a key to store and returns its secret.
+func storeKey(t *testing.T, store *fakeAPIKeyStore, key models.APIKey) string {
+	t.Helper()
+	secret, prefix, err := apikeys.Generate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key.ID = len(store.keys) + 1
+	key.Prefix = prefix
+	key.KeyHash = apikeys.Hash(secret)
+	store.keys[prefix] = key
+	return secret
+}
+
+func TestAuthMiddlewareStoredKeys(t *testing.T) {
+	t.Setenv("API_KEY", "env-admin-key")
+	t.Setenv("JWT_SECRET", "")
+
+	store := &fakeAPIKeyStore{keys: map[string]models.APIKey{}}
+	SetAPIKeyStore(store)
+	defer SetAPIKeyStore(nil)
+
+	past := time.Now().Add(-time.Hour)
+	recent := time.Now().Add(-time.Second)
+	admin := storeKey(t, store, models.APIKey{Role: "admin"})
+	user := storeKey(t, store, models.APIKey{Role: "user", LastUsedAt: &recent})
+	ingest := storeKey(t, store, models.APIKey{Role: "user", Scopes: []string{"logs:ingest"}})
+	checkout := storeKey(t, store, models.APIKey{Role: "user", Service: "checkout"})
+	expired := storeKey(t, store, models.APIKey{Role: "admin", ExpiresAt: &past})
+	revoked := storeKey(t, store, models.APIKey{Role: "admin", RevokedAt: &past})
+
+	var gotQuery, gotService string
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		gotService = RestrictedService(r.Context())
+	}))
+
+	cases := []struct {
+		key    string
+		method string
+		target string
+		want   int
+	}{
+		{"env-admin-key", http.MethodGet, "/api/api-keys", http.StatusOK},
+		{admin, http.MethodDelete, "/api/api-keys/3", http.StatusOK},
+		{user, http.MethodDelete, "/api/api-keys/3", http.StatusForbidden},
+		{user, http.MethodGet, "/api/traces/abc/spans", http.StatusOK},
+		{ingest, http.MethodPost, "/api/logs/bulk", http.StatusOK},
+		{ingest, http.MethodGet, "/api/logs", http.StatusForbidden},
+		{ingest, http.MethodPost, "/api/metrics", http.StatusForbidden},
+		{checkout, http.MethodGet, "/api/logs?service=checkout", http.StatusOK},
+		{checkout, http.MethodGet, "/api/logs?service=billing", http.StatusForbidden},
+		{checkout, http.MethodGet, "/api/alerts", http.StatusForbidden},
+		{checkout, http.MethodPost, "/api/spans/abc/end", http.StatusOK},
+		{checkout, http.MethodPost, "/api/alert-rules", http.StatusForbidden},
+		{expired, http.MethodGet, "/api/logs", http.StatusUnauthorized},
+		{revoked, http.MethodGet, "/api/logs", http.StatusUnauthorized},
+		{admin + "x", http.MethodGet, "/api/logs", http.StatusUnauthorized},
+		{"gi_000000000000_unknown", http.MethodGet, "/api/logs", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.target, nil)
+		req.Header.Set("X-API-Key", c.key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s %s: expected %d, got %d", c.method, c.target, c.want, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/metrics/series?interval=1m", nil)
+	req.Header.Set("X-API-Key", checkout)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if gotQuery != "interval=1m&service=checkout" || gotService != "checkout" {
+		t.Errorf("expected the service filter to be pinned, got %q for %q", gotQuery, gotService)
+	}
+
+	// Only the key used within the last minute is not touched again.
+	for _, id := range store.touched {
+		if id == 2 {
+			t.Errorf("expected the recently used key not to be touched")
+		}
+	}
+	if len(store.touched) == 0 {
+		t.Error("expected used keys to be touched")
+	}
+}
+
+func TestRequiredRole(t *testing.T) {
+	cases := map[string]string{
+		"/api/api-keys":                     "admin",
+		"/api/api-keys/12":                  "admin",
+		"/api/notification-channels/4/test": "admin",
+		"/api/logs/bulk":                    "user",
+		"/api/alert-rules/3":                "user",
+		"/api/traces/abc/spans":             "user",
+		"/api/health":                       "",
+		"/unknown":                          "",
+	}
+	for path, want := range cases {
+		if got := requiredRole(path); got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+}
+
+// signEdDSA returns a token with claims signed by key.
+func signEdDSA(key ed25519.PrivateKey, claims map[string]any) string {
+	b64 := base64.RawURLEncoding
+	head, _ := json.Marshal(map[string]string{"alg": "EdDSA", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(head) + "." + b64.EncodeToString(payload)
+	return signed + "." + b64.EncodeToString(ed25519.Sign(key, []byte(signed)))
+}
+
+func TestAuthMiddlewareOIDCTokens(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": idp.URL, "jwks_uri": idp.URL + "/keys"})
+		case "/keys":
+			json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+				"kty": "OKP", "crv": "Ed25519", "kid": "k1", "x": base64.RawURLEncoding.EncodeToString(public),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer idp.Close()
+
+	t.Setenv("API_KEY", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_OIDC_DISCOVERY_URL", idp.URL+"/.well-known/openid-configuration")
+	t.Setenv("JWT_AUDIENCE", "go-insight")
+	t.Setenv("JWT_ROLE_CLAIM", "groups")
+	t.Setenv("JWT_ROLE_MAPPING", "platform-admins=admin,engineering=user")
+
+	var gotRole any
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRole = r.Context().Value("role")
+	}))
+
+	exp := time.Now().Add(time.Hour).Unix()
+	cases := []struct {
+		claims map[string]any
+		path   string
+		want   int
+		role   string
+	}{
+		{map[string]any{"iss": idp.URL, "aud": "go-insight", "exp": exp, "groups": []string{"engineering"}}, "/api/logs", http.StatusOK, "user"},
+		{map[string]any{"iss": idp.URL, "aud": "go-insight", "exp": exp, "groups": []string{"engineering"}}, "/api/api-keys", http.StatusForbidden, ""},
+		{map[string]any{"iss": idp.URL, "aud": "go-insight", "exp": exp, "groups": []string{"engineering", "platform-admins"}}, "/api/api-keys", http.StatusOK, "admin"},
+		{map[string]any{"iss": idp.URL, "aud": "go-insight", "exp": exp, "groups": []string{"sales"}}, "/api/logs", http.StatusForbidden, ""},
+		{map[string]any{"iss": "https://other.example.com", "aud": "go-insight", "exp": exp}, "/api/logs", http.StatusUnauthorized, ""},
+		{map[string]any{"iss": idp.URL, "aud": "other", "exp": exp}, "/api/logs", http.StatusUnauthorized, ""},
+	}
+
+	for i, c := range cases {
+		gotRole = nil
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		req.Header.Set("Authorization", "Bearer "+signEdDSA(private, c.claims))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("case %d: expected %d, got %d", i, c.want, rec.Code)
+		}
+		if c.role != "" && gotRole != c.role {
+			t.Errorf("case %d: expected role %s, got %v", i, c.role, gotRole)
+		}
+	}
+}
+
+func TestJWTSettingsRequireAudience(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_OIDC_DISCOVERY_URL", "")
+	t.Setenv("JWT_JWKS_URL", "https://idp.example.com/keys")
+	t.Setenv("JWT_AUDIENCE", " , ")
+	if err := CheckJWTSettings(); err == nil {
+		t.Error("expected provider keys without an audience to be rejected")
+	}
+	if _, err := newJWTAuth(context.Background(), loadJWTSettings()); err == nil {
+		t.Error("expected setup to fail without an audience")
+	}
+
+	t.Setenv("JWT_AUDIENCE", "go-insight")
+	if err := CheckJWTSettings(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Shared secrets are not shared with other applications.
+	t.Setenv("JWT_JWKS_URL", "")
+	t.Setenv("JWT_AUDIENCE", "")
+	t.Setenv("JWT_SECRET", "s3cret")
+	if err := CheckJWTSettings(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAuthMiddlewareRejectsOtherAudiences(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "OKP", "crv": "Ed25519", "kid": "k1", "x": base64.RawURLEncoding.EncodeToString(public),
+		}}})
+	}))
+	defer idp.Close()
+
+	t.Setenv("API_KEY", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_OIDC_DISCOVERY_URL", "")
+	t.Setenv("JWT_JWKS_URL", idp.URL)
+	t.Setenv("JWT_AUDIENCE", "go-insight")
+	t.Setenv("JWT_ROLE_CLAIM", "")
+	t.Setenv("JWT_ROLE_MAPPING", "")
+
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	exp := time.Now().Add(time.Hour).Unix()
+
+	for aud, want := range map[string]int{"go-insight": http.StatusOK, "billing-app": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/api/logs", nil)
+		req.Header.Set("Authorization", "Bearer "+signEdDSA(private, map[string]any{"aud": aud, "exp": exp, "role": "admin"}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("aud %s: expected %d, got %d", aud, want, rec.Code)
+		}
+	}
+}
+
+func TestJWTAuthForSharesDiscovery(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var discoveries int
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		discoveries++
+		mu.Unlock()
+		<-release
+		json.NewEncoder(w).Encode(map[string]string{"issuer": idp.URL, "jwks_uri": idp.URL + "/keys"})
+	}))
+	defer idp.Close()
+
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_JWKS_URL", "")
+	t.Setenv("JWT_OIDC_DISCOVERY_URL", idp.URL+"/discovery")
+	t.Setenv("JWT_AUDIENCE", "go-insight")
+	settings := loadJWTSettings()
+
+	var wg sync.WaitGroup
+	auths := make(chan *jwtAuth, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			auth, err := jwtAuthFor(context.Background(), settings)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			auths <- auth
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(auths)
+
+	first := <-auths
+	for auth := range auths {
+		if auth != first {
+			t.Error("expected every request to share one jwtAuth")
+		}
+	}
+	if discoveries != 1 {
+		t.Errorf("expected 1 discovery, got %d", discoveries)
+	}
+}
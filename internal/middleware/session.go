@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+// SessionCookie holds the token of a dashboard session.
+const SessionCookie = "go_insight_session"
+
+// SessionStore finds the sessions of users logged in to the dashboard.
+type SessionStore interface {
+	FindSession(ctx context.Context, token string) (models.Session, error)
+}
+
+// sessionStore is nil unless dashboard login is configured.
+var sessionStore SessionStore
+
+// SetSessionStore enables authentication with session cookies and requires
+// a session for the dashboard.
+func SetSessionStore(store SessionStore) {
+	sessionStore = store
+}
+
+// authenticateSession returns the unexpired session of the cookie on r.
+func authenticateSession(r *http.Request, now time.Time) (models.Session, error) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	session, err := sessionStore.FindSession(r.Context(), cookie.Value)
+	if err != nil {
+		return models.Session{}, fmt.Errorf("session: %w", err)
+	}
+	if !now.Before(session.ExpiresAt) {
+		return models.Session{}, errors.New("session expired")
+	}
+	return session, nil
+}
+
+// sameOrigin reports whether a request authenticated by cookie may change
+// data. Session cookies are SameSite=Lax, so browsers do not send them with
+// cross-site POSTs; requests whose Origin names another host are refused
+// as well.
+func sameOrigin(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// RequiresSession reports whether path is part of the dashboard rather than
+// the API or the login endpoints.
+func RequiresSession(path string) bool {
+	return !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/auth/")
+}
+
+// SessionMiddleware sends browsers without a session to the login page when
+// dashboard login is configured, and returns them to the page they asked for
+// afterwards.
+func SessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sessionStore == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := authenticateSession(r, time.Now()); err != nil {
+			if !errors.Is(err, http.ErrNoCookie) {
+				log.Printf("🔒 Session validation error: %v", err)
+			}
+			login := "/auth/login?return_to=" + url.QueryEscape(r.URL.RequestURI())
+			http.Redirect(w, r, login, http.StatusFound)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
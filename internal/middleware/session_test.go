@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/models"
+)
+
+type fakeSessionStore map[string]models.Session
+
+func (s fakeSessionStore) FindSession(ctx context.Context, token string) (models.Session, error) {
+	session, ok := s[token]
+	if !ok {
+		return models.Session{}, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func TestAuthMiddlewareSessions(t *testing.T) {
+	t.Setenv("API_KEY", "")
+	t.Setenv("JWT_SECRET", "")
+
+	SetSessionStore(fakeSessionStore{
+		"alice": {Subject: "alice", Role: "user", ExpiresAt: time.Now().Add(time.Hour)},
+		"admin": {Subject: "bob", Role: "admin", ExpiresAt: time.Now().Add(time.Hour)},
+		"old":   {Subject: "carol", Role: "admin", ExpiresAt: time.Now().Add(-time.Minute)},
+	})
+	defer SetSessionStore(nil)
+
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	cases := []struct {
+		token  string
+		method string
+		path   string
+		origin string
+		want   int
+	}{
+		{"alice", http.MethodGet, "/api/logs", "", http.StatusOK},
+		{"alice", http.MethodGet, "/api/api-keys", "", http.StatusForbidden},
+		{"admin", http.MethodGet, "/api/api-keys", "", http.StatusOK},
+		{"alice", http.MethodPost, "/api/silences", "http://example.com", http.StatusOK},
+		{"alice", http.MethodPost, "/api/silences", "https://evil.example", http.StatusForbidden},
+		{"old", http.MethodGet, "/api/logs", "", http.StatusUnauthorized},
+		{"unknown", http.MethodGet, "/api/logs", "", http.StatusUnauthorized},
+		{"", http.MethodGet, "/api/logs", "", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.token != "" {
+			req.AddCookie(&http.Cookie{Name: SessionCookie, Value: c.token})
+		}
+		if c.origin != "" {
+			req.Header.Set("Origin", c.origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("%s %s with %q: expected %d, got %d", c.method, c.path, c.token, c.want, rec.Code)
+		}
+	}
+}
+
+func TestSessionMiddleware(t *testing.T) {
+	handler := SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Without login configured the dashboard stays open.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/traces/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+
+	SetSessionStore(fakeSessionStore{"alice": {Role: "user", ExpiresAt: time.Now().Add(time.Hour)}})
+	defer SetSessionStore(nil)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/traces/?id=1", nil))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/auth/login?return_to=%2Ftraces%2F%3Fid%3D1" {
+		t.Errorf("expected a redirect to login, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/traces/", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookie, Value: "alice"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with a session, got %d", rec.Code)
+	}
+}
+
+func TestRequiresSession(t *testing.T) {
+	for path, want := range map[string]bool{"/": true, "/traces/": true, "/api/logs": false, "/auth/login": false} {
+		if got := RequiresSession(path); got != want {
+			t.Errorf("%s: expected %v, got %v", path, want, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Alert states. An alert is pending while its condition holds for less than
+// the rule's for duration, firing afterwards, and resolved once the
+// condition clears after it fired. A pending alert whose condition clears
+// becomes inactive and is removed.
+const (
+	AlertStateInactive = "inactive"
+	AlertStatePending  = "pending"
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+)
+
+// Alert query sources.
+const (
+	AlertSourceMetrics   = "metrics"
+	AlertSourceLogs      = "logs"
+	AlertSourceSpans     = "spans"
+	AlertSourceAnomalies = "anomalies"
+)
+
+// Duration is a time.Duration written to and read from JSON as a Go
+// duration string such as "5m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5m\"")
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %s", raw)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// AlertQuery selects the value a rule compares against its threshold: an
+// aggregation over the rule's source during the trailing window. Filters
+// that do not apply to the source must be empty. With GroupBy, one alert is
+// tracked per group.
+type AlertQuery struct {
+	Source      string   `json:"source"`
+	Aggregation string   `json:"aggregation"`
+	Window      Duration `json:"window"`
+
+	Service   string `json:"service,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Method    string `json:"method,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Signal    string `json:"signal,omitempty"`
+
+	GroupBy []string `json:"group_by,omitempty"`
+}
+
+// AlertRule is evaluated every Interval; its alerts fire once the condition
+// Query Comparison Threshold has held for For. Alerts that fire or resolve
+// are sent to the notification channels in ChannelIDs.
+type AlertRule struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Query       AlertQuery        `json:"query"`
+	Comparison  string            `json:"comparison"`
+	Threshold   float64           `json:"threshold"`
+	Interval    Duration          `json:"interval"`
+	For         Duration          `json:"for"`
+	Labels      map[string]string `json:"labels"`
+	ChannelIDs  []int             `json:"channel_ids"`
+	Enabled     bool              `json:"enabled"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at,omitempty"`
+}
+
+// Alert is the current state of one label set of a rule.
+type Alert struct {
+	ID              int               `json:"id"`
+	RuleID          int               `json:"rule_id"`
+	RuleName        string            `json:"rule_name"`
+	Fingerprint     string            `json:"fingerprint"`
+	Labels          map[string]string `json:"labels"`
+	State           string            `json:"state"`
+	Value           float64           `json:"value"`
+	ActiveSince     *time.Time        `json:"active_since,omitempty"`
+	FiredAt         *time.Time        `json:"fired_at,omitempty"`
+	ResolvedAt      *time.Time        `json:"resolved_at,omitempty"`
+	LastEvaluatedAt time.Time         `json:"last_evaluated_at"`
+
+	// SilencedBy and Inhibited tell why notifications for a firing alert
+	// are muted.
+	SilencedBy []int `json:"silenced_by,omitempty"`
+	Inhibited  bool  `json:"inhibited,omitempty"`
+}
+
+// AlertTransition records an alert changing state.
+type AlertTransition struct {
+	ID          int64             `json:"id"`
+	RuleID      int               `json:"rule_id"`
+	Fingerprint string            `json:"fingerprint"`
+	Labels      map[string]string `json:"labels"`
+	FromState   string            `json:"from_state"`
+	ToState     string            `json:"to_state"`
+	Value       float64           `json:"value"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// Label matcher operators.
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// LabelMatcher selects alerts by one label. Regular expressions must match
+// the whole label value, and a missing label matches as the empty string.
+type LabelMatcher struct {
+	Name  string `json:"name"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// Silence statuses, derived from the silence's time range.
+const (
+	SilencePending = "pending"
+	SilenceActive  = "active"
+	SilenceExpired = "expired"
+)
+
+// Silence mutes notifications for alerts matching all its matchers between
+// StartsAt and EndsAt. Silenced alerts are still evaluated and listed.
+type Silence struct {
+	ID        int            `json:"id"`
+	Matchers  []LabelMatcher `json:"matchers"`
+	StartsAt  time.Time      `json:"starts_at"`
+	EndsAt    time.Time      `json:"ends_at"`
+	CreatedBy string         `json:"created_by"`
+	Comment   string         `json:"comment"`
+	Status    string         `json:"status"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// InhibitRule mutes notifications for alerts matching TargetMatchers while
+// an alert matching SourceMatchers fires with the same values for the Equal
+// labels.
+type InhibitRule struct {
+	ID             int            `json:"id"`
+	Name           string         `json:"name"`
+	SourceMatchers []LabelMatcher `json:"source_matchers"`
+	TargetMatchers []LabelMatcher `json:"target_matchers"`
+	Equal          []string       `json:"equal"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
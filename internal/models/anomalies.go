@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Anomaly signals, computed per service over each bucket.
+const (
+	AnomalySignalRequestRate = "request_rate"
+	AnomalySignalErrorRate   = "error_rate"
+	AnomalySignalLatencyP95  = "latency_p95"
+	AnomalySignalLogVolume   = "log_volume"
+)
+
+// Anomaly is a bucket in which a signal of a service deviated from its
+// baseline, the median of the same hour of the week in previous weeks.
+// Score is the deviation in baseline spreads; Direction is "high" or "low".
+type Anomaly struct {
+	ID         int64     `json:"id"`
+	Service    string    `json:"service"`
+	Signal     string    `json:"signal"`
+	Bucket     time.Time `json:"bucket"`
+	Value      float64   `json:"value"`
+	Baseline   float64   `json:"baseline"`
+	Spread     float64   `json:"spread"`
+	Score      float64   `json:"score"`
+	Direction  string    `json:"direction"`
+	Samples    int       `json:"samples"`
+	DetectedAt time.Time `json:"detected_at"`
+}
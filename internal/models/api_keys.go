@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// APIKey authenticates requests with Role. Scopes narrow what the key may
+// do, such as ingest-only or read-only access to one signal; a key without
+// scopes may do everything its role allows. With Service, the key may only
+// send and read data of that service. Only a hash of the key is stored.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes"`
+	Service    string     `json:"service,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreatedAPIKey is a new key together with its secret, which is only shown
+// once.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// DependencyEdge aggregates the calls from one service to another, derived
+// from spans whose parent span belongs to a different service.
+type DependencyEdge struct {
+	Caller     string  `json:"caller"`
+	Callee     string  `json:"callee"`
+	CallCount  int64   `json:"call_count"`
+	ErrorCount int64   `json:"error_count"`
+	ErrorRate  float64 `json:"error_rate"`
+	AvgMs      float64 `json:"avg_ms"`
+	MaxMs      float64 `json:"max_ms"`
+}
+
+// ServiceNode is a service in the dependency graph with its traffic totals.
+type ServiceNode struct {
+	Name      string `json:"name"`
+	CallsIn   int64  `json:"calls_in"`
+	CallsOut  int64  `json:"calls_out"`
+	ErrorsIn  int64  `json:"errors_in"`
+	ErrorsOut int64  `json:"errors_out"`
+}
+
+// DependencyGraph is the service map for a time window.
+type DependencyGraph struct {
+	StartTime time.Time        `json:"start_time"`
+	EndTime   time.Time        `json:"end_time"`
+	Nodes     []ServiceNode    `json:"nodes"`
+	Edges     []DependencyEdge `json:"edges"`
+}
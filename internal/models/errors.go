@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Error group statuses. A resolved group that receives a new error is
+// reopened; an ignored group stays ignored.
+const (
+	ErrorGroupOpen     = "open"
+	ErrorGroupResolved = "resolved"
+	ErrorGroupIgnored  = "ignored"
+)
+
+// ErrorGroup aggregates ERROR and FATAL logs sharing a fingerprint.
+type ErrorGroup struct {
+	Fingerprint     string     `json:"fingerprint"`
+	Template        string     `json:"template"`
+	Stack           string     `json:"stack,omitempty"`
+	LogLevel        string     `json:"log_level"`
+	FirstSeen       time.Time  `json:"first_seen"`
+	LastSeen        time.Time  `json:"last_seen"`
+	Count           int64      `json:"count"`
+	Services        []string   `json:"services"`
+	SampleLogIDs    []int      `json:"sample_log_ids"`
+	Status          string     `json:"status"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+}
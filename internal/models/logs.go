@@ -16,3 +16,37 @@ type Log struct {
 	SpanID      sql.NullString   `json:"span_id,omitempty"`
 	Metadata    *json.RawMessage `json:"metadata,omitempty"`
 }
+
+type LogSeriesPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+type LogSeries struct {
+	Labels map[string]string `json:"labels"`
+	Points []LogSeriesPoint  `json:"points"`
+}
+
+type LogQueryResult struct {
+	// Type is "logs" for log queries and "series" for metric queries.
+	Type   string      `json:"type"`
+	Logs   []Log       `json:"logs,omitempty"`
+	Series []LogSeries `json:"series,omitempty"`
+}
+
+// LogPattern is a message template mined from a service's logs, with its
+// volume in a time window and in the window before it.
+type LogPattern struct {
+	ID            int       `json:"id"`
+	ServiceName   string    `json:"service_name"`
+	Template      string    `json:"template"`
+	Sample        string    `json:"sample"`
+	FirstSeen     time.Time `json:"first_seen"`
+	LastSeen      time.Time `json:"last_seen"`
+	Count         int64     `json:"count"`
+	PreviousCount int64     `json:"previous_count"`
+	// Change is the ratio of Count to PreviousCount, both smoothed by one so
+	// that patterns absent from the previous window still compare.
+	Change float64 `json:"change"`
+	New    bool    `json:"new"`
+}
@@ -20,3 +20,37 @@ type EndpointMetric struct {
 	Timestamp   time.Time    `json:"timestamp"`
 	RequestID   string       `json:"request_id"`
 }
+
+type MetricAggregate struct {
+	Group     map[string]string `json:"group"`
+	Count     int64             `json:"count"`
+	ErrorRate float64           `json:"error_rate"`
+	AvgMs     float64           `json:"avg_ms"`
+	P50Ms     float64           `json:"p50_ms"`
+	P90Ms     float64           `json:"p90_ms"`
+	P95Ms     float64           `json:"p95_ms"`
+	P99Ms     float64           `json:"p99_ms"`
+}
+
+type MetricSeriesPoint struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Count       int64     `json:"count"`
+	RequestRate float64   `json:"request_rate"`
+	ErrorRate   float64   `json:"error_rate"`
+	P50Ms       float64   `json:"p50_ms"`
+	P90Ms       float64   `json:"p90_ms"`
+	P95Ms       float64   `json:"p95_ms"`
+	P99Ms       float64   `json:"p99_ms"`
+}
+
+// MetricTick summarizes the metrics a service reported during one second,
+// as received by the server.
+type MetricTick struct {
+	ServiceName string    `json:"service_name"`
+	Timestamp   time.Time `json:"timestamp"`
+	Count       int64     `json:"count"`
+	ErrorCount  int64     `json:"error_count"`
+	ErrorRate   float64   `json:"error_rate"`
+	AvgMs       float64   `json:"avg_ms"`
+	MaxMs       float64   `json:"max_ms"`
+}
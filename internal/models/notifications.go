@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// NotificationChannel is a destination for alert notifications. Config holds
+// the settings of its Type; empty templates fall back to the defaults.
+//
+// Alerts sent to a channel are grouped by the GroupBy labels, one
+// notification per group. A new group is announced after GroupWait, changes
+// to a group after GroupInterval, and unchanged firing groups again after
+// RepeatInterval.
+type NotificationChannel struct {
+	ID             int             `json:"id"`
+	Name           string          `json:"name"`
+	Type           string          `json:"type"`
+	Config         json.RawMessage `json:"config"`
+	TitleTemplate  string          `json:"title_template,omitempty"`
+	BodyTemplate   string          `json:"body_template,omitempty"`
+	SendResolved   bool            `json:"send_resolved"`
+	GroupBy        []string        `json:"group_by"`
+	GroupWait      Duration        `json:"group_wait"`
+	GroupInterval  Duration        `json:"group_interval"`
+	RepeatInterval Duration        `json:"repeat_interval"`
+	Enabled        bool            `json:"enabled"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// NotificationDelivery records one notification sent, or failed to be sent,
+// to a channel. RuleID is set when all alerts of the notification belong to
+// one rule, and GroupKey is empty for test notifications.
+type NotificationDelivery struct {
+	ID           int64     `json:"id"`
+	ChannelID    int       `json:"channel_id"`
+	RuleID       *int      `json:"rule_id,omitempty"`
+	GroupKey     string    `json:"group_key,omitempty"`
+	Status       string    `json:"status"`
+	Fingerprints []string  `json:"fingerprints"`
+	Title        string    `json:"title"`
+	Success      bool      `json:"success"`
+	Attempts     int       `json:"attempts"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is a dashboard login. Role is mapped from the user's groups at the
+// provider when they log in. Only a hash of the session cookie is stored.
+type Session struct {
+	ID        int       `json:"id"`
+	TokenHash string    `json:"-"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
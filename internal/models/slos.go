@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// SLO is a service level objective over the request metrics of a service,
+// optionally narrowed to a path and method. A request is good when its
+// status code is below 500 and, with a LatencyThreshold, it took less than
+// the threshold. Objective is the fraction of good requests required over
+// the trailing Window, such as 0.999.
+type SLO struct {
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description,omitempty"`
+	Service          string    `json:"service"`
+	Path             string    `json:"path,omitempty"`
+	Method           string    `json:"method,omitempty"`
+	Objective        float64   `json:"objective"`
+	LatencyThreshold Duration  `json:"latency_threshold,omitempty"`
+	Window           Duration  `json:"window"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	LastEvaluatedAt *time.Time `json:"last_evaluated_at,omitempty"`
+}
+
+// ErrorBudget is the share of bad requests an SLO allows over its window.
+// Consumed and Remaining are fractions of the budget; Remaining is negative
+// once the SLO is violated.
+type ErrorBudget struct {
+	Allowed   float64 `json:"allowed"`
+	Consumed  float64 `json:"consumed"`
+	Remaining float64 `json:"remaining"`
+}
+
+// SLOBurnRate is the rate at which the error budget is spent over the long
+// and short window of a burn-rate alert.
+type SLOBurnRate struct {
+	BurnWindow    string   `json:"burn_window"`
+	Severity      string   `json:"severity"`
+	LongWindow    Duration `json:"long_window"`
+	ShortWindow   Duration `json:"short_window"`
+	Threshold     float64  `json:"threshold"`
+	LongBurnRate  float64  `json:"long_burn_rate"`
+	ShortBurnRate float64  `json:"short_burn_rate"`
+	Breached      bool     `json:"breached"`
+}
+
+// SLOAlert is the state of one burn-rate alert of an SLO. It fires while
+// both its windows burn faster than the threshold and resolves once either
+// drops below it.
+type SLOAlert struct {
+	SLOID           int        `json:"slo_id"`
+	BurnWindow      string     `json:"burn_window"`
+	Severity        string     `json:"severity"`
+	State           string     `json:"state"`
+	Threshold       float64    `json:"threshold"`
+	LongBurnRate    float64    `json:"long_burn_rate"`
+	ShortBurnRate   float64    `json:"short_burn_rate"`
+	ActiveSince     *time.Time `json:"active_since,omitempty"`
+	FiredAt         *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	LastEvaluatedAt time.Time  `json:"last_evaluated_at"`
+}
+
+// SLOStatus is an SLO's compliance over its window at EvaluatedAt.
+type SLOStatus struct {
+	SLOID       int           `json:"slo_id"`
+	TotalEvents int64         `json:"total_events"`
+	GoodEvents  int64         `json:"good_events"`
+	SLI         float64       `json:"sli"`
+	Objective   float64       `json:"objective"`
+	ErrorBudget ErrorBudget   `json:"error_budget"`
+	BurnRates   []SLOBurnRate `json:"burn_rates"`
+	Alerts      []SLOAlert    `json:"alerts"`
+	EvaluatedAt time.Time     `json:"evaluated_at"`
+}
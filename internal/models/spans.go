@@ -1,14 +1,32 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// Span status values, following OpenTelemetry.
+const (
+	SpanStatusUnset = "UNSET"
+	SpanStatusOK    = "OK"
+	SpanStatusError = "ERROR"
+)
 
 type Span struct {
-	ID        string    `json:"id"`
-	TraceID   string    `json:"trace_id"`
-	ParentID  string    `json:"parent_id"`
-	Service   string    `json:"service"`
-	Operation string    `json:"operation"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
-	Duration  float64   `json:"duration_ms"`
+	ID         string           `json:"id"`
+	TraceID    string           `json:"trace_id"`
+	ParentID   string           `json:"parent_id"`
+	Service    string           `json:"service"`
+	Operation  string           `json:"operation"`
+	StartTime  time.Time        `json:"start_time"`
+	EndTime    time.Time        `json:"end_time"`
+	Duration   float64          `json:"duration_ms"`
+	Status     string           `json:"status,omitempty"`
+	Attributes *json.RawMessage `json:"attributes,omitempty"`
 }
+
+// SpanDurationBounds are the upper bounds in milliseconds of the span
+// duration histogram kept by span metrics. A final overflow bucket holds
+// spans slower than the last bound. Changing the bounds invalidates
+// histograms already stored.
+var SpanDurationBounds = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
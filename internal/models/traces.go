@@ -12,3 +12,91 @@ type Trace struct {
 	EndTime     sql.NullTime    `json:"end_time"`
 	Duration    sql.NullFloat64 `json:"duration_ms"`
 }
+
+// TraceTree is a trace with its spans assembled into a hierarchy.
+type TraceTree struct {
+	Trace     Trace       `json:"trace"`
+	Services  []string    `json:"services"`
+	SpanCount int         `json:"span_count"`
+	Roots     []*SpanNode `json:"roots"`
+
+	// UnattributedLogs holds logs of the trace without a known span when
+	// logs are embedded in the tree.
+	UnattributedLogs []Log `json:"unattributed_logs,omitempty"`
+}
+
+// SpanNode is a span within a TraceTree. SelfTime is the part of the span's
+// duration not covered by any of its children. MissingParent is set on spans
+// whose parent was never received; they are attached as roots.
+type SpanNode struct {
+	Span
+	Depth         int         `json:"depth"`
+	SelfTime      float64     `json:"self_time_ms"`
+	MissingParent bool        `json:"missing_parent,omitempty"`
+	Logs          []Log       `json:"logs,omitempty"`
+	Children      []*SpanNode `json:"children"`
+}
+
+// TraceAnalysis describes where the time in a trace went.
+type TraceAnalysis struct {
+	TraceID      string                `json:"trace_id"`
+	Duration     float64               `json:"duration_ms"`
+	CriticalPath []CriticalPathSpan    `json:"critical_path"`
+	Segments     []CriticalPathSegment `json:"segments"`
+}
+
+// CriticalPathSpan is a span on the critical path with the time it
+// contributes to the trace's end-to-end latency.
+type CriticalPathSpan struct {
+	SpanID       string  `json:"span_id"`
+	Service      string  `json:"service"`
+	Operation    string  `json:"operation"`
+	Contribution float64 `json:"contribution_ms"`
+	Percent      float64 `json:"percent"`
+}
+
+// CriticalPathSegment is a contiguous stretch of the critical path during
+// which SpanID was the span doing the work.
+type CriticalPathSegment struct {
+	SpanID    string    `json:"span_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Duration  float64   `json:"duration_ms"`
+}
+
+// TraceLogs holds the logs recorded for a trace, grouped by span.
+type TraceLogs struct {
+	TraceID      string     `json:"trace_id"`
+	Count        int        `json:"count"`
+	Spans        []SpanLogs `json:"spans"`
+	Unattributed []Log      `json:"unattributed"`
+}
+
+// SpanLogs holds the logs recorded within one span, oldest first.
+type SpanLogs struct {
+	SpanID string `json:"span_id"`
+	Logs   []Log  `json:"logs"`
+}
+
+// Trace event types published to live trace streams.
+const (
+	TraceEventTraceStart = "trace_start"
+	TraceEventTraceEnd   = "trace_end"
+	TraceEventSpanStart  = "span_start"
+	TraceEventSpanEnd    = "span_end"
+)
+
+// TraceEvent reports a trace or span starting or ending. SpanID, ParentID,
+// Operation and Status are only set for span events; Duration only for end
+// events.
+type TraceEvent struct {
+	Type      string    `json:"type"`
+	TraceID   string    `json:"trace_id"`
+	SpanID    string    `json:"span_id,omitempty"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Service   string    `json:"service"`
+	Operation string    `json:"operation,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Duration  float64   `json:"duration_ms,omitempty"`
+}
@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpMessage is a mail received by the SMTP stand-in.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPStandIn serves just enough SMTP for net/smtp to deliver one
+// message per connection, without STARTTLS or AUTH.
+func startSMTPStandIn(t *testing.T) (string, int, <-chan smtpMessage) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan smtpMessage, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- smtpMessage) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var msg smtpMessage
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			msg.data = data.String()
+			messages <- msg
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailSenderDeliversToSMTPServer(t *testing.T) {
+	host, port, messages := startSMTPStandIn(t)
+
+	config, _ := json.Marshal(map[string]any{
+		"host": host,
+		"port": port,
+		"from": "alerts@example.com",
+		"to":   []string{"ops@example.com", "oncall@example.com"},
+	})
+	sender, err := NewSender(TypeEmail, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := renderTestMessage(t)
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := <-messages
+	if got.from != "alerts@example.com" || len(got.to) != 2 || got.to[1] != "oncall@example.com" {
+		t.Errorf("unexpected envelope %+v", got)
+	}
+	for _, want := range []string{
+		"Subject: " + msg.Title,
+		"To: ops@example.com, oncall@example.com",
+		"FIRING HighLatency value=812.5",
+		"\r\n  path=/users\r\n",
+	} {
+		if !strings.Contains(got.data, want) {
+			t.Errorf("message missing %q:\n%s", want, got.data)
+		}
+	}
+}
+
+func TestEmailSenderReportsConnectionFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	sender, err := NewSender(TypeEmail, json.RawMessage(`{"host": "127.0.0.1", "port": `+strconv.Itoa(port)+
+		`, "from": "alerts@example.com", "to": ["ops@example.com"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Send(context.Background(), Message{}); err == nil {
+		t.Error("expected connection error")
+	}
+}
+
+func TestEmailSenderGivesUpOnStalledServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		// Accept connections but never greet.
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	sender, err := NewSender(TypeEmail, json.RawMessage(`{"host": "127.0.0.1", "port": `+strconv.Itoa(port)+
+		`, "from": "alerts@example.com", "to": ["ops@example.com"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := sender.Send(ctx, Message{}); err == nil {
+		t.Error("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send took %s after the deadline", elapsed)
+	}
+}
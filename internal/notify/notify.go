@@ -0,0 +1,348 @@
+// Package notify renders alert notifications and delivers them to
+// notification channels: signed generic webhooks, Slack and Microsoft Teams
+// incoming webhooks, and SMTP email.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Channel types.
+const (
+	TypeWebhook = "webhook"
+	TypeSlack   = "slack"
+	TypeTeams   = "teams"
+	TypeEmail   = "email"
+)
+
+// Notification statuses. A notification is firing while any of its alerts
+// fires.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Alert is one alert included in a notification.
+type Alert struct {
+	Status      string            `json:"status"`
+	Fingerprint string            `json:"fingerprint"`
+	Labels      map[string]string `json:"labels"`
+	Value       float64           `json:"value"`
+	StartsAt    time.Time         `json:"starts_at"`
+	EndsAt      *time.Time        `json:"ends_at,omitempty"`
+}
+
+// Notification is a set of alerts sent together.
+type Notification struct {
+	Status       string            `json:"status"`
+	GroupLabels  map[string]string `json:"group_labels"`
+	CommonLabels map[string]string `json:"common_labels"`
+	Alerts       []Alert           `json:"alerts"`
+}
+
+// NewNotification builds a notification for alerts, deriving the status and
+// the labels all alerts share.
+func NewNotification(groupLabels map[string]string, alerts []Alert) Notification {
+	n := Notification{
+		Status:       StatusResolved,
+		GroupLabels:  groupLabels,
+		CommonLabels: map[string]string{},
+		Alerts:       alerts,
+	}
+	if n.GroupLabels == nil {
+		n.GroupLabels = map[string]string{}
+	}
+
+	for i, alert := range alerts {
+		if alert.Status == StatusFiring {
+			n.Status = StatusFiring
+		}
+		if i == 0 {
+			for key, value := range alert.Labels {
+				n.CommonLabels[key] = value
+			}
+			continue
+		}
+		for key, value := range n.CommonLabels {
+			if alert.Labels[key] != value {
+				delete(n.CommonLabels, key)
+			}
+		}
+	}
+
+	return n
+}
+
+// Firing returns the firing alerts.
+func (n Notification) Firing() []Alert {
+	return n.withStatus(StatusFiring)
+}
+
+// Resolved returns the resolved alerts.
+func (n Notification) Resolved() []Alert {
+	return n.withStatus(StatusResolved)
+}
+
+func (n Notification) withStatus(status string) []Alert {
+	var alerts []Alert
+	for _, alert := range n.Alerts {
+		if alert.Status == status {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// Message is a notification with its rendered title and body.
+type Message struct {
+	Notification
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Default templates, used when a channel does not define its own.
+const (
+	DefaultTitleTemplate = `[{{ upper .Status }}{{ if eq .Status "firing" }}:{{ len .Firing }}{{ end }}] ` +
+		`{{ or .CommonLabels.alertname .GroupLabels.alertname "alerts" }}` +
+		`{{ with .CommonLabels.service }} ({{ . }}){{ end }}`
+
+	DefaultBodyTemplate = `{{ range .Alerts -}}
+{{ upper .Status }} {{ .Labels.alertname }} value={{ .Value }} since {{ .StartsAt.Format "2006-01-02T15:04:05Z07:00" }}
+{{- range $key, $value := .Labels }}{{ if ne $key "alertname" }}
+  {{ $key }}={{ $value }}{{ end }}{{ end }}
+{{ end }}`
+)
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// Templates renders titles and bodies with text/template. The notification
+// is the template's data.
+type Templates struct {
+	title *template.Template
+	body  *template.Template
+}
+
+// ParseTemplates parses the title and body templates, falling back to the
+// defaults for empty ones.
+func ParseTemplates(title, body string) (*Templates, error) {
+	if title == "" {
+		title = DefaultTitleTemplate
+	}
+	if body == "" {
+		body = DefaultBodyTemplate
+	}
+
+	t := &Templates{}
+	var err error
+	if t.title, err = template.New("title").Funcs(templateFuncs).Option("missingkey=zero").Parse(title); err != nil {
+		return nil, fmt.Errorf("invalid title template: %w", err)
+	}
+	if t.body, err = template.New("body").Funcs(templateFuncs).Option("missingkey=zero").Parse(body); err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return t, nil
+}
+
+// Render produces the message for a notification.
+func (t *Templates) Render(n Notification) (Message, error) {
+	var title, body bytes.Buffer
+	if err := t.title.Execute(&title, n); err != nil {
+		return Message{}, fmt.Errorf("rendering title: %w", err)
+	}
+	if err := t.body.Execute(&body, n); err != nil {
+		return Message{}, fmt.Errorf("rendering body: %w", err)
+	}
+
+	return Message{
+		Notification: n,
+		Title:        strings.TrimSpace(title.String()),
+		Body:         strings.TrimSpace(body.String()),
+	}, nil
+}
+
+// Sender delivers messages to one channel.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender validates a channel's JSON config and returns its sender.
+func NewSender(channelType string, config json.RawMessage) (Sender, error) {
+	switch channelType {
+	case TypeWebhook:
+		return newWebhookSender(config)
+	case TypeSlack, TypeTeams:
+		return newChatSender(channelType, config)
+	case TypeEmail:
+		return newEmailSender(config)
+	default:
+		return nil, fmt.Errorf("invalid channel type: %q", channelType)
+	}
+}
+
+func decodeConfig(config json.RawMessage, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(config))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid channel config: %w", err)
+	}
+	return nil
+}
+
+// secretConfigKeys are config fields hidden by Redact. Slack and Teams
+// incoming webhook URLs embed their credentials, so they are secret too.
+var secretConfigKeys = map[string]bool{
+	"secret":   true,
+	"password": true,
+}
+
+var secretChatConfigKeys = map[string]bool{
+	"url": true,
+}
+
+// headersConfigKey holds extra webhook headers, such as Authorization, whose
+// values are hidden by Redact while their names stay visible.
+const headersConfigKey = "headers"
+
+const redactedSecret = `"********"`
+
+func isSecretConfigKey(channelType, key string) bool {
+	if secretConfigKeys[key] {
+		return true
+	}
+	return (channelType == TypeSlack || channelType == TypeTeams) && secretChatConfigKeys[key]
+}
+
+// Redact masks secrets in a channel config for display.
+func Redact(channelType string, config json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return config
+	}
+	for key, value := range fields {
+		if isSecretConfigKey(channelType, key) {
+			fields[key] = json.RawMessage(redactedSecret)
+			continue
+		}
+		if key == headersConfigKey {
+			var headers map[string]json.RawMessage
+			if json.Unmarshal(value, &headers) != nil {
+				fields[key] = json.RawMessage(redactedSecret)
+				continue
+			}
+			for name := range headers {
+				headers[name] = json.RawMessage(redactedSecret)
+			}
+			fields[key], _ = json.Marshal(headers)
+		}
+	}
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return config
+	}
+	return redacted
+}
+
+// Unredact restores secrets that are still masked in config from previous,
+// so that a redacted config read from the API can be sent back unchanged.
+func Unredact(channelType string, config, previous json.RawMessage) json.RawMessage {
+	var fields, previousFields map[string]json.RawMessage
+	if json.Unmarshal(config, &fields) != nil || json.Unmarshal(previous, &previousFields) != nil {
+		return config
+	}
+	for key, value := range fields {
+		secret := isSecretConfigKey(channelType, key) || key == headersConfigKey
+		if secret && string(value) == redactedSecret {
+			if original, ok := previousFields[key]; ok {
+				fields[key] = original
+			}
+			continue
+		}
+		if key == headersConfigKey {
+			var headers, previousHeaders map[string]json.RawMessage
+			if json.Unmarshal(value, &headers) != nil {
+				continue
+			}
+			json.Unmarshal(previousFields[key], &previousHeaders)
+			for name, headerValue := range headers {
+				if string(headerValue) != redactedSecret {
+					continue
+				}
+				if original, ok := previousHeaders[name]; ok {
+					headers[name] = original
+				}
+			}
+			fields[key], _ = json.Marshal(headers)
+		}
+	}
+	restored, err := json.Marshal(fields)
+	if err != nil {
+		return config
+	}
+	return restored
+}
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that Deliver does not retry it.
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// RetryPolicy controls how often and how patiently Deliver retries.
+type RetryPolicy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy waits 1s, 2s, 4s and 8s between five attempts.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:       5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// Deliver sends msg, retrying failures with exponential backoff until it
+// succeeds, the attempts are used up, the error is permanent or ctx is done.
+// It returns the number of attempts made and the last error.
+func Deliver(ctx context.Context, sender Sender, msg Message, policy RetryPolicy) (int, error) {
+	backoff := policy.InitialBackoff
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = sender.Send(ctx, msg); err == nil {
+			return attempt, nil
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= policy.Attempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2025, 5, 26, 10, 20, 0, 0, time.UTC)
+
+func testNotification() Notification {
+	return NewNotification(map[string]string{"alertname": "HighLatency"}, []Alert{
+		{
+			Status:   StatusFiring,
+			Labels:   map[string]string{"alertname": "HighLatency", "service": "api", "path": "/users"},
+			Value:    812.5,
+			StartsAt: testStart,
+		},
+		{
+			Status:   StatusResolved,
+			Labels:   map[string]string{"alertname": "HighLatency", "service": "api", "path": "/orders"},
+			Value:    120,
+			StartsAt: testStart,
+		},
+	})
+}
+
+func TestNewNotification(t *testing.T) {
+	n := testNotification()
+
+	if n.Status != StatusFiring {
+		t.Errorf("expected firing status, got %s", n.Status)
+	}
+	if len(n.CommonLabels) != 2 || n.CommonLabels["service"] != "api" || n.CommonLabels["path"] != "" {
+		t.Errorf("unexpected common labels %v", n.CommonLabels)
+	}
+	if len(n.Firing()) != 1 || len(n.Resolved()) != 1 {
+		t.Errorf("expected one firing and one resolved alert")
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	templates, err := ParseTemplates("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, err := templates.Render(testNotification())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if msg.Title != "[FIRING:1] HighLatency (api)" {
+		t.Errorf("unexpected title %q", msg.Title)
+	}
+	for _, want := range []string{
+		"FIRING HighLatency value=812.5 since 2025-05-26T10:20:00Z",
+		"  path=/users",
+		"RESOLVED HighLatency value=120",
+	} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("body missing %q:\n%s", want, msg.Body)
+		}
+	}
+}
+
+func TestCustomTemplates(t *testing.T) {
+	templates, err := ParseTemplates(`{{ .Status }}: {{ len .Alerts }} alerts`, `{{ range .Firing }}{{ .Labels.path }}{{ end }}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, err := templates.Render(testNotification())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.Title != "firing: 2 alerts" || msg.Body != "/users" {
+		t.Errorf("unexpected message %q / %q", msg.Title, msg.Body)
+	}
+
+	if _, err := ParseTemplates(`{{ .Status`, ""); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestNewSenderValidatesConfig(t *testing.T) {
+	cases := []struct {
+		channelType string
+		config      string
+	}{
+		{"pager", `{}`},
+		{TypeWebhook, `{}`},
+		{TypeWebhook, `{"url": "ftp://example.com"}`},
+		{TypeSlack, `{"url": "https://hooks.slack.com/x", "channel": "#alerts"}`},
+		{TypeEmail, `{"host": "smtp.example.com", "from": "alerts@example.com"}`},
+		{TypeEmail, `{"host": "smtp.example.com", "from": "nope", "to": ["ops@example.com"]}`},
+	}
+
+	for _, tc := range cases {
+		if _, err := NewSender(tc.channelType, json.RawMessage(tc.config)); err == nil {
+			t.Errorf("%s %s: expected error", tc.channelType, tc.config)
+		}
+	}
+
+	if _, err := NewSender(TypeTeams, json.RawMessage(`{"url": "https://example.webhook.office.com/x"}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	redacted := Redact(TypeWebhook, json.RawMessage(`{"url": "https://example.com", "secret": "s3cret"}`))
+
+	var fields map[string]string
+	if err := json.Unmarshal(redacted, &fields); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if fields["secret"] != "********" || fields["url"] != "https://example.com" {
+		t.Errorf("unexpected redacted config %s", redacted)
+	}
+
+	restored := Unredact(TypeWebhook, redacted, json.RawMessage(`{"url": "https://old.example.com", "secret": "s3cret"}`))
+	if err := json.Unmarshal(restored, &fields); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if fields["secret"] != "s3cret" || fields["url"] != "https://example.com" {
+		t.Errorf("unexpected restored config %s", restored)
+	}
+}
+
+func TestRedactChatURL(t *testing.T) {
+	original := json.RawMessage(`{"url": "https://hooks.slack.com/services/T0/B0/token"}`)
+	redacted := Redact(TypeSlack, original)
+	if strings.Contains(string(redacted), "token") {
+		t.Fatalf("url not redacted: %s", redacted)
+	}
+
+	restored := Unredact(TypeSlack, redacted, original)
+	var fields map[string]string
+	if err := json.Unmarshal(restored, &fields); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if fields["url"] != "https://hooks.slack.com/services/T0/B0/token" {
+		t.Errorf("unexpected restored config %s", restored)
+	}
+}
+
+func TestRedactWebhookHeaders(t *testing.T) {
+	original := json.RawMessage(`{"url": "https://example.com",
+		"headers": {"Authorization": "Bearer abc", "X-Team": "ops"}}`)
+	redacted := Redact(TypeWebhook, original)
+
+	var config webhookConfig
+	if err := json.Unmarshal(redacted, &config); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if config.Headers["Authorization"] != "********" || config.Headers["X-Team"] != "********" {
+		t.Errorf("headers not redacted: %s", redacted)
+	}
+
+	// A client changing one header keeps the other masked one.
+	config.Headers["X-Team"] = "platform"
+	edited, _ := json.Marshal(config)
+	if err := json.Unmarshal(Unredact(TypeWebhook, edited, original), &config); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if config.Headers["Authorization"] != "Bearer abc" || config.Headers["X-Team"] != "platform" {
+		t.Errorf("unexpected restored headers %v", config.Headers)
+	}
+}
+
+type flakySender struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (s *flakySender) Send(ctx context.Context, msg Message) error {
+	s.calls++
+	if s.calls <= s.failures {
+		return s.err
+	}
+	return nil
+}
+
+func TestDeliverRetries(t *testing.T) {
+	policy := RetryPolicy{Attempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	sender := &flakySender{failures: 2, err: errors.New("unavailable")}
+	attempts, err := Deliver(context.Background(), sender, Message{}, policy)
+	if err != nil || attempts != 3 {
+		t.Errorf("expected success on attempt 3, got %d, %v", attempts, err)
+	}
+
+	sender = &flakySender{failures: 10, err: errors.New("unavailable")}
+	attempts, err = Deliver(context.Background(), sender, Message{}, policy)
+	if err == nil || attempts != 4 {
+		t.Errorf("expected failure after 4 attempts, got %d, %v", attempts, err)
+	}
+
+	sender = &flakySender{failures: 10, err: Permanent(errors.New("bad request"))}
+	attempts, err = Deliver(context.Background(), sender, Message{}, policy)
+	if err == nil || attempts != 1 {
+		t.Errorf("expected permanent failure on first attempt, got %d, %v", attempts, err)
+	}
+}
@@ -0,0 +1,234 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Headers set on generic webhook requests. The signature is the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the channel secret.
+const (
+	SignatureHeader = "X-Go-Insight-Signature"
+	TimestampHeader = "X-Go-Insight-Timestamp"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// AllowPrivateNetworks lets webhooks reach loopback, private and link-local
+// addresses. It is off by default so that channel URLs cannot be used to
+// probe the internal network or cloud metadata endpoints.
+var AllowPrivateNetworks = false
+
+var httpClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		// Destinations are checked after DNS resolution, on every connection,
+		// so a hostname cannot be re-pointed at an internal address later.
+		DialContext: (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				return checkDestination(net.ParseIP(host))
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+	},
+}
+
+// checkDestination rejects addresses webhooks may not reach.
+func checkDestination(ip net.IP) error {
+	if AllowPrivateNetworks {
+		return nil
+	}
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return Permanent(fmt.Errorf("destination %s is not allowed", ip))
+	}
+	return nil
+}
+
+type webhookConfig struct {
+	URL     string            `json:"url"`
+	Secret  string            `json:"secret,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type webhookSender struct {
+	config webhookConfig
+}
+
+func newWebhookSender(raw json.RawMessage) (*webhookSender, error) {
+	var config webhookConfig
+	if err := decodeConfig(raw, &config); err != nil {
+		return nil, err
+	}
+	if err := validateURL(config.URL); err != nil {
+		return nil, err
+	}
+	return &webhookSender{config: config}, nil
+}
+
+// Send posts the message as JSON, signed when the channel has a secret.
+func (s *webhookSender) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return Permanent(err)
+	}
+
+	headers := map[string]string{}
+	for key, value := range s.config.Headers {
+		headers[key] = value
+	}
+	if s.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[TimestampHeader] = timestamp
+		headers[SignatureHeader] = "sha256=" + Sign(s.config.Secret, timestamp, body)
+	}
+
+	return postJSON(ctx, s.config.URL, body, headers)
+}
+
+// Sign computes the webhook signature of body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type chatConfig struct {
+	URL string `json:"url"`
+}
+
+// chatSender posts to Slack or Microsoft Teams incoming webhooks.
+type chatSender struct {
+	channelType string
+	config      chatConfig
+}
+
+func newChatSender(channelType string, raw json.RawMessage) (*chatSender, error) {
+	var config chatConfig
+	if err := decodeConfig(raw, &config); err != nil {
+		return nil, err
+	}
+	if err := validateURL(config.URL); err != nil {
+		return nil, err
+	}
+	return &chatSender{channelType: channelType, config: config}, nil
+}
+
+func (s *chatSender) Send(ctx context.Context, msg Message) error {
+	var payload any
+	if s.channelType == TypeTeams {
+		payload = teamsPayload(msg)
+	} else {
+		payload = slackPayload(msg)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Permanent(err)
+	}
+	return postJSON(ctx, s.config.URL, body, nil)
+}
+
+// slackPayload is a Slack incoming webhook message with a colored
+// attachment.
+func slackPayload(msg Message) map[string]any {
+	return map[string]any{
+		"text": msg.Title,
+		"attachments": []map[string]any{{
+			"color":     statusColor(msg.Status),
+			"text":      msg.Body,
+			"mrkdwn_in": []string{"text"},
+		}},
+	}
+}
+
+// teamsPayload is an Office 365 connector card accepted by Teams incoming
+// webhooks.
+func teamsPayload(msg Message) map[string]any {
+	return map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.Title,
+		"title":      msg.Title,
+		"text":       msg.Body,
+		"themeColor": statusColor(msg.Status)[1:],
+	}
+}
+
+func statusColor(status string) string {
+	if status == StatusFiring {
+		return "#D63232"
+	}
+	return "#2EB67D"
+}
+
+// postJSON posts body and treats non-2xx responses as failures. Client
+// errors other than 408 and 429 are permanent.
+func postJSON(ctx context.Context, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-insight")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(snippet))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+func validateURL(raw string) error {
+	if raw == "" {
+		return errors.New("url is required")
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid url: %s", raw)
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil {
+		return checkDestination(ip)
+	}
+	if !AllowPrivateNetworks && strings.EqualFold(parsed.Hostname(), "localhost") {
+		return errors.New("destination localhost is not allowed")
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type capturedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookStandIn records requests and replies with the given statuses in
+// turn, repeating the last one.
+func newWebhookStandIn(t *testing.T, statuses ...int) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+	var requests []capturedRequest
+
+	// The stand-in listens on loopback, which webhooks may not reach by default.
+	AllowPrivateNetworks = true
+	t.Cleanup(func() { AllowPrivateNetworks = false })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, capturedRequest{header: r.Header.Clone(), body: body})
+
+		status := statuses[len(statuses)-1]
+		if len(requests) <= len(statuses) {
+			status = statuses[len(requests)-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func renderTestMessage(t *testing.T) Message {
+	t.Helper()
+	templates, err := ParseTemplates("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := templates.Render(testNotification())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestWebhookSenderSignsPayload(t *testing.T) {
+	server, requests := newWebhookStandIn(t, http.StatusOK)
+
+	config, _ := json.Marshal(map[string]any{
+		"url":     server.URL,
+		"secret":  "s3cret",
+		"headers": map[string]string{"X-Team": "ops"},
+	})
+	sender, err := NewSender(TypeWebhook, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Send(context.Background(), renderTestMessage(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := (*requests)[0]
+	timestamp := req.header.Get(TimestampHeader)
+	if want := "sha256=" + Sign("s3cret", timestamp, req.body); req.header.Get(SignatureHeader) != want {
+		t.Errorf("signature %q does not match %q", req.header.Get(SignatureHeader), want)
+	}
+	if req.header.Get("X-Team") != "ops" {
+		t.Errorf("expected custom header, got %v", req.header)
+	}
+
+	var payload Message
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.Status != StatusFiring || len(payload.Alerts) != 2 || payload.Title == "" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestWebhookSenderErrors(t *testing.T) {
+	server, _ := newWebhookStandIn(t, http.StatusBadRequest)
+	sender, _ := NewSender(TypeWebhook, json.RawMessage(`{"url": "`+server.URL+`"}`))
+
+	err := sender.Send(context.Background(), Message{})
+	var permanent permanentError
+	if !errors.As(err, &permanent) {
+		t.Errorf("expected 400 to be permanent, got %v", err)
+	}
+
+	server, _ = newWebhookStandIn(t, http.StatusServiceUnavailable)
+	sender, _ = NewSender(TypeWebhook, json.RawMessage(`{"url": "`+server.URL+`"}`))
+
+	err = sender.Send(context.Background(), Message{})
+	if err == nil || errors.As(err, &permanent) {
+		t.Errorf("expected 503 to be retryable, got %v", err)
+	}
+}
+
+func TestDeliverRetriesWebhook(t *testing.T) {
+	server, requests := newWebhookStandIn(t, http.StatusBadGateway, http.StatusTooManyRequests, http.StatusNoContent)
+	sender, _ := NewSender(TypeWebhook, json.RawMessage(`{"url": "`+server.URL+`"}`))
+
+	policy := RetryPolicy{Attempts: 5, InitialBackoff: 0}
+	attempts, err := Deliver(context.Background(), sender, Message{}, policy)
+	if err != nil || attempts != 3 || len(*requests) != 3 {
+		t.Errorf("expected success on attempt 3, got %d attempts, %d requests, %v", attempts, len(*requests), err)
+	}
+}
+
+func TestChatPayloads(t *testing.T) {
+	msg := renderTestMessage(t)
+
+	for _, channelType := range []string{TypeSlack, TypeTeams} {
+		server, requests := newWebhookStandIn(t, http.StatusOK)
+		sender, err := NewSender(channelType, json.RawMessage(`{"url": "`+server.URL+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sender.Send(context.Background(), msg); err != nil {
+			t.Fatalf("%s: unexpected error: %v", channelType, err)
+		}
+
+		var payload map[string]any
+		if err := json.Unmarshal((*requests)[0].body, &payload); err != nil {
+			t.Fatalf("%s: invalid payload: %v", channelType, err)
+		}
+
+		switch channelType {
+		case TypeSlack:
+			attachments, _ := payload["attachments"].([]any)
+			if payload["text"] != msg.Title || len(attachments) != 1 {
+				t.Errorf("unexpected Slack payload %v", payload)
+			}
+		case TypeTeams:
+			if payload["@type"] != "MessageCard" || payload["title"] != msg.Title || payload["text"] != msg.Body ||
+				payload["themeColor"] != "D63232" {
+				t.Errorf("unexpected Teams payload %v", payload)
+			}
+		}
+	}
+}
+
+func TestWebhookRejectsPrivateDestinations(t *testing.T) {
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"https://10.0.0.5/hook",
+		"http://[::1]/hook",
+	} {
+		config, _ := json.Marshal(map[string]string{"url": target})
+		if _, err := NewSender(TypeWebhook, config); err == nil {
+			t.Errorf("%s: expected error", target)
+		}
+	}
+
+	// Resolved addresses are checked again when the connection is made.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request reached the private server")
+	}))
+	defer server.Close()
+
+	sender := &chatSender{channelType: TypeSlack, config: chatConfig{URL: server.URL}}
+	attempts, err := Deliver(context.Background(), sender, renderTestMessage(t), RetryPolicy{Attempts: 3})
+	if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+		t.Fatalf("expected destination error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected no retries, got %d attempts", attempts)
+	}
+}
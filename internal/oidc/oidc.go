@@ -0,0 +1,185 @@
+// Package oidc logs users in with the OpenID Connect authorization code flow
+// and PKCE: it builds the redirect to the provider, exchanges the returned
+// code for tokens and verifies the ID token.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NathanSanchezDev/go-insight/internal/jwtauth"
+)
+
+// idTokenLeeway allows for clock skew between go-insight and the provider.
+const idTokenLeeway = time.Minute
+
+// Config identifies go-insight as a client of a provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Provider is a discovered OpenID Connect provider.
+type Provider struct {
+	config   Config
+	metadata jwtauth.ProviderMetadata
+	verifier *jwtauth.Verifier
+	client   *http.Client
+}
+
+// NewProvider discovers the provider at cfg.Issuer. A nil client uses
+// http.DefaultClient.
+func NewProvider(ctx context.Context, cfg Config, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	discoveryURL := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	metadata, err := jwtauth.Discover(ctx, client, discoveryURL)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("provider issuer %q does not match %q", metadata.Issuer, cfg.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" {
+		return nil, errors.New("discovery document has no authorization or token endpoint")
+	}
+
+	return &Provider{
+		config:   cfg,
+		metadata: metadata,
+		verifier: &jwtauth.Verifier{
+			Keys:      jwtauth.NewKeySet(metadata.JWKSURI, client),
+			Issuer:    metadata.Issuer,
+			Audiences: []string{cfg.ClientID},
+			Leeway:    idTokenLeeway,
+		},
+		client: client,
+	}, nil
+}
+
+// RandomString returns 256 random bits encoded for URLs, for states, nonces,
+// PKCE verifiers and session tokens.
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge returns the S256 PKCE challenge of verifier.
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the provider URL that asks the user to log in.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	return withQuery(p.metadata.AuthorizationEndpoint, params)
+}
+
+// LogoutURL returns the provider URL that ends the user's session there,
+// or "" when the provider has none.
+func (p *Provider) LogoutURL(postLogoutRedirectURL string) string {
+	if p.metadata.EndSessionEndpoint == "" {
+		return ""
+	}
+	params := url.Values{"client_id": {p.config.ClientID}}
+	if postLogoutRedirectURL != "" {
+		params.Set("post_logout_redirect_uri", postLogoutRedirectURL)
+	}
+	return withQuery(p.metadata.EndSessionEndpoint, params)
+}
+
+func withQuery(endpoint string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+	return endpoint + separator + params.Encode()
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems an authorization code and returns the ID token.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.config.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s: %s %s", resp.Status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return token.IDToken, nil
+}
+
+// VerifyIDToken returns the claims of an ID token issued by the provider
+// for go-insight in answer to the login with nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, idToken, nonce string, now time.Time) (jwtauth.Claims, error) {
+	claims, err := p.verifier.Verify(ctx, idToken, now)
+	if err != nil {
+		return nil, err
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("nonce mismatch")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	return claims, nil
+}
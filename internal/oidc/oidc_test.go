@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProvider is a local provider that issues ID tokens for the code
+// "good-code" when the PKCE verifier matches challenge.
+type testProvider struct {
+	*httptest.Server
+	key       ed25519.PrivateKey
+	challenge string
+	nonce     string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	p := &testProvider{key: private}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"jwks_uri":               p.URL + "/jwks",
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"end_session_endpoint":   p.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "OKP", "crv": "Ed25519", "kid": "k1", "x": base64.RawURLEncoding.EncodeToString(public),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.PostFormValue("code") != "good-code" || Challenge(r.PostFormValue("code_verifier")) != p.challenge ||
+			id != "go-insight" || secret != "s3cret" || r.PostFormValue("grant_type") != "authorization_code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken(map[string]any{
+			"iss": p.URL, "aud": "go-insight", "sub": "alice", "nonce": p.nonce,
+			"exp": time.Now().Add(time.Hour).Unix(), "groups": []string{"engineering"},
+		})})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *testProvider) idToken(claims map[string]any) string {
+	b64 := base64.RawURLEncoding
+	head, _ := json.Marshal(map[string]string{"alg": "EdDSA", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(head) + "." + b64.EncodeToString(payload)
+	return signed + "." + b64.EncodeToString(ed25519.Sign(p.key, []byte(signed)))
+}
+
+func (p *testProvider) config() Config {
+	return Config{
+		Issuer:       p.URL,
+		ClientID:     "go-insight",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://insight.example.com/auth/callback",
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	idp := newTestProvider(t)
+	provider, err := NewProvider(context.Background(), idp.config(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifier, _ := RandomString()
+	authURL, err := url.Parse(provider.AuthCodeURL("state-1", "nonce-1", verifier))
+	if err != nil {
+		t.Fatalf("invalid URL: %v", err)
+	}
+	q := authURL.Query()
+	if authURL.Path != "/authorize" || q.Get("client_id") != "go-insight" || q.Get("state") != "state-1" ||
+		q.Get("nonce") != "nonce-1" || q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid email" ||
+		q.Get("redirect_uri") != "https://insight.example.com/auth/callback" || q.Get("response_type") != "code" {
+		t.Errorf("unexpected authorization URL %s", authURL)
+	}
+
+	// The provider keeps the challenge and nonce of the login.
+	idp.challenge = q.Get("code_challenge")
+	idp.nonce = q.Get("nonce")
+
+	if _, err := provider.Exchange(context.Background(), "good-code", "other-verifier"); err == nil {
+		t.Error("expected the exchange to fail with the wrong verifier")
+	}
+
+	idToken, err := provider.Exchange(context.Background(), "good-code", verifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, err := provider.VerifyIDToken(context.Background(), idToken, "nonce-1", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claims["sub"] != "alice" {
+		t.Errorf("unexpected claims %v", claims)
+	}
+
+	if _, err := provider.VerifyIDToken(context.Background(), idToken, "nonce-2", time.Now()); err == nil {
+		t.Error("expected a nonce mismatch")
+	}
+}
+
+func TestVerifyIDTokenAudience(t *testing.T) {
+	idp := newTestProvider(t)
+	provider, err := NewProvider(context.Background(), idp.config(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token := idp.idToken(map[string]any{"iss": idp.URL, "aud": "other-app", "sub": "alice", "nonce": "n"})
+	if _, err := provider.VerifyIDToken(context.Background(), token, "n", time.Now()); err == nil {
+		t.Error("expected a token for another client to be rejected")
+	}
+}
+
+func TestNewProviderIssuerMismatch(t *testing.T) {
+	idp := newTestProvider(t)
+	cfg := idp.config()
+	cfg.Issuer = idp.URL + "/"
+
+	if _, err := NewProvider(context.Background(), cfg, nil); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected an issuer mismatch, got %v", err)
+	}
+}
+
+func TestLogoutURL(t *testing.T) {
+	idp := newTestProvider(t)
+	provider, _ := NewProvider(context.Background(), idp.config(), nil)
+
+	got := provider.LogoutURL("https://insight.example.com/")
+	want := idp.URL + "/logout?client_id=go-insight&post_logout_redirect_uri=https%3A%2F%2Finsight.example.com%2F"
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	// The example of RFC 7636, appendix B.
+	if got := Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("unexpected challenge %s", got)
+	}
+}
@@ -0,0 +1,335 @@
+// Package patterns clusters log messages into templates with an online
+// variant of the Drain algorithm (He et al., "Drain: An Online Log Parsing
+// Approach with Fixed Depth Tree", ICWS 2017).
+//
+// Messages are tokenized on whitespace and routed through a fixed depth
+// tree keyed first by token count and then by their leading tokens. The
+// leaf holds candidate clusters; a message joins the most similar one when
+// enough of its tokens match, turning the differing positions into
+// wildcards, and otherwise starts a new cluster.
+package patterns
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Wildcard marks a variable position in a template.
+const Wildcard = "<*>"
+
+// Config tunes the miner.
+type Config struct {
+	// Depth is the depth of the routing tree, including the root and token
+	// count levels. Depth-2 leading tokens are used for routing.
+	Depth int
+	// Similarity is the fraction of tokens that must match for a message to
+	// join an existing cluster.
+	Similarity float64
+	// MaxChildren bounds the fan-out of routing nodes; further tokens are
+	// routed through a wildcard child.
+	MaxChildren int
+	// MaxClusters bounds the clusters kept per service. New clusters beyond
+	// the limit are not created and their messages are dropped.
+	MaxClusters int
+	// MaxServices bounds the services with a routing tree. Messages of
+	// further services are dropped.
+	MaxServices int
+}
+
+// DefaultConfig returns the parameters suggested by the Drain paper.
+func DefaultConfig() Config {
+	return Config{
+		Depth:       4,
+		Similarity:  0.4,
+		MaxChildren: 100,
+		MaxClusters: 1000,
+		MaxServices: 1000,
+	}
+}
+
+// Count is the number of messages matched to a template during one minute.
+// Previous is the template the cluster had when its counts were last taken,
+// set only when the cluster has since been generalized, so that stored
+// counts under the old template can be merged into the new one.
+type Count struct {
+	Service  string
+	Template string
+	Previous string
+	Bucket   time.Time
+	Count    int64
+	Sample   string
+
+	cluster *cluster
+}
+
+type cluster struct {
+	tokens []string
+	// reported is the template under which counts were last taken.
+	reported string
+}
+
+func (c *cluster) template() string {
+	return strings.Join(c.tokens, " ")
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*cluster
+}
+
+func newNode() *node {
+	return &node{children: map[string]*node{}}
+}
+
+// countKey identifies a cluster's counts by the cluster itself rather than
+// its template, which changes as the cluster is generalized.
+type countKey struct {
+	cluster *cluster
+	bucket  time.Time
+}
+
+// Miner clusters messages per service and accumulates per-minute template
+// counts until they are taken. It is safe for concurrent use.
+type Miner struct {
+	cfg Config
+
+	mu       sync.Mutex
+	trees    map[string]*node
+	clusters map[string]int
+	counts   map[countKey]*Count
+}
+
+// NewMiner creates a miner with the given configuration.
+func NewMiner(cfg Config) *Miner {
+	return &Miner{
+		cfg:      cfg,
+		trees:    map[string]*node{},
+		clusters: map[string]int{},
+		counts:   map[countKey]*Count{},
+	}
+}
+
+// Add clusters a message logged by service at the given time and returns
+// the template it was matched to, or "" when the message has no tokens or
+// MaxClusters or MaxServices is reached.
+func (m *Miner) Add(service, message string, at time.Time) string {
+	tokens := tokenize(message)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	leaf := m.route(service, tokens)
+	if leaf == nil {
+		return ""
+	}
+
+	match := m.bestMatch(leaf.clusters, tokens)
+	if match == nil {
+		if m.clusters[service] >= m.cfg.MaxClusters {
+			return ""
+		}
+		match = &cluster{tokens: tokens}
+		leaf.clusters = append(leaf.clusters, match)
+		m.clusters[service]++
+	} else {
+		for i, token := range tokens {
+			if match.tokens[i] != token {
+				match.tokens[i] = Wildcard
+			}
+		}
+	}
+
+	key := countKey{cluster: match, bucket: at.Truncate(time.Minute)}
+	count, ok := m.counts[key]
+	if !ok {
+		count = &Count{Service: service, Bucket: key.bucket, Sample: message, cluster: match}
+		m.counts[key] = count
+	}
+	count.Count++
+
+	return match.template()
+}
+
+// Seed registers a known template for service, such as one loaded from
+// storage after a restart, so that new messages join it instead of starting
+// a fresh cluster.
+func (m *Miner) Seed(service, template string) {
+	tokens := strings.Fields(template)
+	if len(tokens) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	leaf := m.route(service, tokens)
+	if leaf == nil {
+		return
+	}
+	for _, existing := range leaf.clusters {
+		if existing.template() == template {
+			return
+		}
+	}
+	leaf.clusters = append(leaf.clusters, &cluster{tokens: tokens, reported: template})
+	m.clusters[service]++
+}
+
+// TakeCounts returns the counts accumulated since the previous call under
+// their clusters' current templates and resets them.
+func (m *Miner) TakeCounts() []Count {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make([]Count, 0, len(m.counts))
+	for _, count := range m.counts {
+		taken := *count
+		taken.Template = count.cluster.template()
+		if count.cluster.reported != "" && count.cluster.reported != taken.Template {
+			taken.Previous = count.cluster.reported
+		}
+		counts = append(counts, taken)
+	}
+	for _, count := range m.counts {
+		count.cluster.reported = count.cluster.template()
+	}
+	m.counts = map[countKey]*Count{}
+	return counts
+}
+
+// Restore puts counts that could not be persisted back, merging them with
+// any accumulated since they were taken. Counts not taken from this miner are
+// ignored.
+func (m *Miner) Restore(counts []Count) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range counts {
+		if c.cluster == nil {
+			continue
+		}
+		// The cluster's counts were not stored under the template it was
+		// taken with, so the previous one still needs merging.
+		if c.Previous != "" {
+			c.cluster.reported = c.Previous
+		}
+		key := countKey{cluster: c.cluster, bucket: c.Bucket}
+		if existing, ok := m.counts[key]; ok {
+			existing.Count += c.Count
+			continue
+		}
+		restored := c
+		restored.Template, restored.Previous = "", ""
+		m.counts[key] = &restored
+	}
+}
+
+// route walks the tree for service down to the leaf for tokens, creating
+// nodes as needed. It returns nil when service has no tree and MaxServices
+// is reached. Must be called with mu held.
+func (m *Miner) route(service string, tokens []string) *node {
+	root, ok := m.trees[service]
+	if !ok {
+		if m.cfg.MaxServices > 0 && len(m.trees) >= m.cfg.MaxServices {
+			return nil
+		}
+		root = newNode()
+		m.trees[service] = root
+	}
+
+	current := child(root, strconv.Itoa(len(tokens)), 0)
+
+	for i := 0; i < m.cfg.Depth-2 && i < len(tokens); i++ {
+		key := tokens[i]
+		if isVariable(key) {
+			key = Wildcard
+		}
+		current = child(current, key, m.cfg.MaxChildren)
+	}
+
+	return current
+}
+
+// child returns the child of n for key, creating it while n has fewer than
+// maxChildren children (0 meaning unlimited) and falling back to the
+// wildcard child otherwise.
+func child(n *node, key string, maxChildren int) *node {
+	if next, ok := n.children[key]; ok {
+		return next
+	}
+
+	if maxChildren > 0 && len(n.children) >= maxChildren-1 && key != Wildcard {
+		key = Wildcard
+		if next, ok := n.children[key]; ok {
+			return next
+		}
+	}
+
+	next := newNode()
+	n.children[key] = next
+	return next
+}
+
+// bestMatch returns the cluster most similar to tokens, preferring the more
+// general cluster (more wildcards) on ties, or nil when none reaches the
+// threshold. Template wildcards only count as matches against tokens that
+// were masked as variables.
+func (m *Miner) bestMatch(clusters []*cluster, tokens []string) *cluster {
+	var best *cluster
+	bestSim, bestWildcards := -1.0, 0
+
+	for _, c := range clusters {
+		if len(c.tokens) != len(tokens) {
+			continue
+		}
+
+		matched, wildcards := 0, 0
+		for i, token := range c.tokens {
+			if token == Wildcard {
+				wildcards++
+				// Positions masked in the message itself agree with the
+				// template; otherwise a message made mostly of variables
+				// could never rejoin its own cluster.
+				if tokens[i] == Wildcard {
+					matched++
+				}
+				continue
+			}
+			if token == tokens[i] {
+				matched++
+			}
+		}
+
+		sim := float64(matched) / float64(len(tokens))
+		if sim > bestSim || (sim == bestSim && wildcards > bestWildcards) {
+			best, bestSim, bestWildcards = c, sim, wildcards
+		}
+	}
+
+	if best == nil || bestSim < m.cfg.Similarity {
+		return nil
+	}
+	return best
+}
+
+// tokenize splits a message on whitespace, replacing tokens that contain
+// digits with the wildcard since they are almost always variables.
+func tokenize(message string) []string {
+	tokens := strings.Fields(message)
+	for i, token := range tokens {
+		if isVariable(token) {
+			tokens[i] = Wildcard
+		}
+	}
+	return tokens
+}
+
+func isVariable(token string) bool {
+	return strings.IndexFunc(token, unicode.IsDigit) >= 0
+}
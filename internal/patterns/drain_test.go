@@ -0,0 +1,154 @@
+package patterns
+
+import (
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2025, 5, 26, 10, 30, 15, 0, time.UTC)
+
+func TestMinerClustersVariableTokens(t *testing.T) {
+	m := NewMiner(DefaultConfig())
+
+	first := m.Add("api", "login succeeded for alice from 10.0.0.1", testTime)
+	if first != "login succeeded for alice from <*>" {
+		t.Fatalf("unexpected first template %q", first)
+	}
+
+	second := m.Add("api", "login succeeded for bob from 10.0.0.2", testTime)
+	if second != "login succeeded for <*> from <*>" {
+		t.Errorf("unexpected merged template %q", second)
+	}
+
+	other := m.Add("api", "cache miss for key session", testTime)
+	if other != "cache miss for key session" {
+		t.Errorf("expected a separate cluster, got %q", other)
+	}
+}
+
+func TestMinerSeparatesServicesAndLengths(t *testing.T) {
+	m := NewMiner(DefaultConfig())
+
+	m.Add("api", "request failed", testTime)
+	if got := m.Add("worker", "request retried", testTime); got != "request retried" {
+		t.Errorf("services should not share clusters, got %q", got)
+	}
+	if got := m.Add("api", "request failed twice", testTime); got != "request failed twice" {
+		t.Errorf("messages of different lengths should not merge, got %q", got)
+	}
+}
+
+func TestMinerRejoinsMostlyVariableMessages(t *testing.T) {
+	m := NewMiner(DefaultConfig())
+
+	m.Add("api", "42 17 done", testTime)
+	m.Add("api", "7 99 done", testTime)
+
+	counts := m.TakeCounts()
+	if len(counts) != 1 || counts[0].Count != 2 || counts[0].Template != "<*> <*> done" {
+		t.Errorf("unexpected counts %+v", counts)
+	}
+}
+
+func TestMinerTakeCountsPerMinute(t *testing.T) {
+	m := NewMiner(DefaultConfig())
+
+	m.Add("api", "job 1 finished", testTime)
+	m.Add("api", "job 2 finished", testTime.Add(10*time.Second))
+	m.Add("api", "job 3 finished", testTime.Add(time.Minute))
+
+	counts := m.TakeCounts()
+	if len(counts) != 2 {
+		t.Fatalf("expected 2 buckets, got %+v", counts)
+	}
+
+	total := int64(0)
+	for _, c := range counts {
+		if c.Template != "job <*> finished" || c.Bucket.Second() != 0 {
+			t.Errorf("unexpected count %+v", c)
+		}
+		total += c.Count
+	}
+	if total != 3 {
+		t.Errorf("expected 3 messages counted, got %d", total)
+	}
+
+	if again := m.TakeCounts(); len(again) != 0 {
+		t.Errorf("expected counts to reset, got %+v", again)
+	}
+
+	m.Restore(counts)
+	m.Add("api", "job 4 finished", testTime)
+	restored := m.TakeCounts()
+	if len(restored) != 2 {
+		t.Errorf("expected restored counts to merge, got %+v", restored)
+	}
+}
+
+func TestMinerSeed(t *testing.T) {
+	m := NewMiner(DefaultConfig())
+	m.Seed("api", "payment <*> declined by <*>")
+
+	if got := m.Add("api", "payment 42 declined by issuer", testTime); got != "payment <*> declined by <*>" {
+		t.Errorf("expected seeded template, got %q", got)
+	}
+}
+
+func TestMinerMaxClusters(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxClusters = 1
+	m := NewMiner(cfg)
+
+	m.Add("api", "alpha beta gamma", testTime)
+	if got := m.Add("api", "one two three four", testTime); got != "" {
+		t.Errorf("expected message to be dropped, got %q", got)
+	}
+}
+
+func TestMinerKeepsCountsOfGeneralizedClusters(t *testing.T) {
+	m := NewMiner(DefaultConfig())
+
+	m.Add("api", "task queue alpha done", testTime)
+	m.Add("api", "task queue beta done", testTime)
+
+	counts := m.TakeCounts()
+	if len(counts) != 1 || counts[0].Count != 2 || counts[0].Template != "task queue <*> done" {
+		t.Fatalf("expected one count under the generalized template, got %+v", counts)
+	}
+	if counts[0].Previous != "" {
+		t.Errorf("new cluster should have no previous template, got %q", counts[0].Previous)
+	}
+
+	m.Add("api", "task queue gamma failed", testTime)
+	counts = m.TakeCounts()
+	if len(counts) != 1 || counts[0].Template != "task queue <*> <*>" || counts[0].Previous != "task queue <*> done" {
+		t.Fatalf("expected the count to name its previous template, got %+v", counts)
+	}
+
+	// A failed flush keeps the previous template for the next one.
+	m.Restore(counts)
+	m.Add("api", "task queue delta done", testTime)
+	counts = m.TakeCounts()
+	if len(counts) != 1 || counts[0].Count != 2 || counts[0].Previous != "task queue <*> done" {
+		t.Errorf("unexpected counts after restore %+v", counts)
+	}
+
+	m.Add("api", "task queue eta done", testTime)
+	if counts = m.TakeCounts(); len(counts) != 1 || counts[0].Previous != "" {
+		t.Errorf("expected no previous template once stored, got %+v", counts)
+	}
+}
+
+func TestMinerMaxServices(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxServices = 1
+	m := NewMiner(cfg)
+
+	m.Add("api", "request failed", testTime)
+	if got := m.Add("worker", "request failed", testTime); got != "" {
+		t.Errorf("expected message of a new service to be dropped, got %q", got)
+	}
+	if got := m.Add("api", "request failed", testTime); got != "request failed" {
+		t.Errorf("known services should keep clustering, got %q", got)
+	}
+}
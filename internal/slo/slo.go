@@ -0,0 +1,159 @@
+// Package slo computes service level indicators, error budgets and
+// multi-window multi-burn-rate alerts from good and total event counts.
+//
+// The burn rate is how fast the error budget is spent: a burn rate of 1
+// uses up exactly the budget over the SLO window. A burn-rate alert fires
+// when both its long and its short window burn faster than its threshold;
+// the long window makes the alert significant, the short window makes it
+// reset quickly once the problem is fixed.
+package slo
+
+import (
+	"fmt"
+	"time"
+)
+
+// Alert severities.
+const (
+	SeverityPage   = "page"
+	SeverityTicket = "ticket"
+)
+
+// Counts are the events observed during a window.
+type Counts struct {
+	Total int64
+	Good  int64
+}
+
+// Bad returns the number of events that were not good.
+func (c Counts) Bad() int64 {
+	return c.Total - c.Good
+}
+
+// SLI returns the fraction of good events, 1 when there were none.
+func (c Counts) SLI() float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Good) / float64(c.Total)
+}
+
+// BurnRate returns the error rate of counts relative to the error rate the
+// objective allows.
+func BurnRate(c Counts, objective float64) float64 {
+	if c.Total == 0 || objective >= 1 {
+		return 0
+	}
+	return (1 - c.SLI()) / (1 - objective)
+}
+
+// Budget is the error budget of an SLO window.
+type Budget struct {
+	// Allowed is the number of bad events the objective permits.
+	Allowed float64
+	// Consumed is the fraction of the budget used; above 1 the SLO is
+	// violated.
+	Consumed float64
+	// Remaining is 1 - Consumed, negative once the budget is exhausted.
+	Remaining float64
+}
+
+// ErrorBudget computes the budget for the counts of a full SLO window.
+func ErrorBudget(c Counts, objective float64) Budget {
+	b := Budget{Allowed: (1 - objective) * float64(c.Total)}
+	if b.Allowed > 0 {
+		b.Consumed = float64(c.Bad()) / b.Allowed
+	} else if c.Bad() > 0 {
+		b.Consumed = 1
+	}
+	b.Remaining = 1 - b.Consumed
+	return b
+}
+
+// BurnWindow is one multi-window burn-rate alert: it fires once the long and
+// short windows burn BudgetFraction of the SLO window's budget within Long.
+type BurnWindow struct {
+	Severity       string
+	Long           time.Duration
+	Short          time.Duration
+	BudgetFraction float64
+}
+
+// Name identifies the window as "<long>/<short>", such as "1h/5m".
+func (w BurnWindow) Name() string {
+	return formatDuration(w.Long) + "/" + formatDuration(w.Short)
+}
+
+// Threshold returns the burn rate that spends the window's budget fraction
+// within its long window.
+func (w BurnWindow) Threshold(sloWindow time.Duration) float64 {
+	return w.BudgetFraction * float64(sloWindow) / float64(w.Long)
+}
+
+// DefaultBurnWindows are the windows recommended by the Google SRE workbook.
+// For a 30-day SLO their thresholds are 14.4, 6, 3 and 1.
+var DefaultBurnWindows = []BurnWindow{
+	{Severity: SeverityPage, Long: time.Hour, Short: 5 * time.Minute, BudgetFraction: 0.02},
+	{Severity: SeverityPage, Long: 6 * time.Hour, Short: 30 * time.Minute, BudgetFraction: 0.05},
+	{Severity: SeverityTicket, Long: 24 * time.Hour, Short: 2 * time.Hour, BudgetFraction: 0.10},
+	{Severity: SeverityTicket, Long: 72 * time.Hour, Short: 6 * time.Hour, BudgetFraction: 0.10},
+}
+
+// Windows returns the distinct lengths counts are needed for to evaluate an
+// SLO window and its burn windows.
+func Windows(sloWindow time.Duration, burnWindows []BurnWindow) []time.Duration {
+	seen := map[time.Duration]bool{sloWindow: true}
+	windows := []time.Duration{sloWindow}
+	for _, w := range burnWindows {
+		for _, d := range []time.Duration{w.Long, w.Short} {
+			if !seen[d] {
+				seen[d] = true
+				windows = append(windows, d)
+			}
+		}
+	}
+	return windows
+}
+
+// BurnResult is the evaluation of one burn window.
+type BurnResult struct {
+	Window        BurnWindow
+	Threshold     float64
+	LongBurnRate  float64
+	ShortBurnRate float64
+}
+
+// Breached reports whether both windows burn faster than the threshold.
+func (r BurnResult) Breached() bool {
+	return r.LongBurnRate > r.Threshold && r.ShortBurnRate > r.Threshold
+}
+
+// Evaluate computes the burn rates of each burn window from counts keyed by
+// window length.
+func Evaluate(counts map[time.Duration]Counts, objective float64, sloWindow time.Duration, burnWindows []BurnWindow) []BurnResult {
+	results := make([]BurnResult, 0, len(burnWindows))
+	for _, w := range burnWindows {
+		results = append(results, BurnResult{
+			Window:        w,
+			Threshold:     w.Threshold(sloWindow),
+			LongBurnRate:  BurnRate(counts[w.Long], objective),
+			ShortBurnRate: BurnRate(counts[w.Short], objective),
+		})
+	}
+	return results
+}
+
+// formatDuration writes whole days as "3d" and other durations compactly,
+// such as "6h" or "30m".
+func formatDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,98 @@
+package slo
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCounts(t *testing.T) {
+	c := Counts{Total: 1000, Good: 990}
+	if c.Bad() != 10 || !approx(c.SLI(), 0.99) {
+		t.Errorf("unexpected counts %d, %f", c.Bad(), c.SLI())
+	}
+	if (Counts{}).SLI() != 1 {
+		t.Error("expected an SLI of 1 without events")
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	cases := []struct {
+		counts    Counts
+		objective float64
+		want      float64
+	}{
+		{Counts{Total: 1000, Good: 999}, 0.999, 1},
+		{Counts{Total: 1000, Good: 990}, 0.999, 10},
+		{Counts{Total: 1000, Good: 1000}, 0.999, 0},
+		{Counts{}, 0.999, 0},
+	}
+	for _, tc := range cases {
+		if got := BurnRate(tc.counts, tc.objective); !approx(got, tc.want) {
+			t.Errorf("%+v at %g: expected %g, got %g", tc.counts, tc.objective, tc.want, got)
+		}
+	}
+}
+
+func TestErrorBudget(t *testing.T) {
+	b := ErrorBudget(Counts{Total: 100000, Good: 99925}, 0.999)
+	if !approx(b.Allowed, 100) || !approx(b.Consumed, 0.75) || !approx(b.Remaining, 0.25) {
+		t.Errorf("unexpected budget %+v", b)
+	}
+
+	b = ErrorBudget(Counts{Total: 1000, Good: 990}, 0.999)
+	if !approx(b.Consumed, 10) || !approx(b.Remaining, -9) {
+		t.Errorf("expected an exhausted budget, got %+v", b)
+	}
+
+	if b := ErrorBudget(Counts{}, 0.999); b.Consumed != 0 || b.Remaining != 1 {
+		t.Errorf("expected a full budget without events, got %+v", b)
+	}
+}
+
+func TestBurnWindowThresholds(t *testing.T) {
+	want := []float64{14.4, 6, 3, 1}
+	for i, w := range DefaultBurnWindows {
+		if got := w.Threshold(30 * day); !approx(got, want[i]) {
+			t.Errorf("%s: expected threshold %g, got %g", w.Name(), want[i], got)
+		}
+	}
+
+	if got := DefaultBurnWindows[0].Threshold(28 * day); !approx(got, 13.44) {
+		t.Errorf("expected a 28-day threshold of 13.44, got %g", got)
+	}
+	if name := DefaultBurnWindows[3].Name(); name != "3d/6h" {
+		t.Errorf("unexpected name %s", name)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	windows := Windows(28*day, DefaultBurnWindows)
+	// 6h is both a long and a short window.
+	if len(windows) != 8 || windows[0] != 28*day {
+		t.Errorf("unexpected windows %v", windows)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	counts := map[time.Duration]Counts{
+		time.Hour:        {Total: 10000, Good: 9800},
+		5 * time.Minute:  {Total: 1000, Good: 980},
+		6 * time.Hour:    {Total: 60000, Good: 59700},
+		30 * time.Minute: {Total: 5000, Good: 5000},
+	}
+
+	results := Evaluate(counts, 0.999, 30*day, DefaultBurnWindows[:2])
+	if !approx(results[0].LongBurnRate, 20) || !approx(results[0].ShortBurnRate, 20) || !results[0].Breached() {
+		t.Errorf("expected the 1h/5m window to breach, got %+v", results[0])
+	}
+	if !approx(results[1].LongBurnRate, 5) || results[1].Breached() {
+		t.Errorf("expected the 6h/30m window not to breach, got %+v", results[1])
+	}
+}
@@ -0,0 +1,104 @@
+// Package stream fans out telemetry to live subscribers, such as clients
+// tailing logs, without letting a slow subscriber hold up ingestion.
+package stream
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrSlowConsumer is reported by a subscription that was dropped because its
+// buffer filled up.
+var ErrSlowConsumer = errors.New("subscriber too slow, events dropped")
+
+// Hub delivers published events to every subscription whose filter accepts
+// them. It is safe for concurrent use.
+type Hub[T any] struct {
+	mu   sync.RWMutex
+	subs map[*Subscription[T]]struct{}
+}
+
+// NewHub creates an empty hub.
+func NewHub[T any]() *Hub[T] {
+	return &Hub[T]{subs: map[*Subscription[T]]struct{}{}}
+}
+
+// Subscription receives the events accepted by its filter on C. C is closed
+// when the subscription is closed or dropped; Err tells the two apart.
+type Subscription[T any] struct {
+	C <-chan T
+
+	hub    *Hub[T]
+	ch     chan T
+	filter func(T) bool
+	once   sync.Once
+	err    error
+}
+
+// Subscribe registers a subscription buffering up to buffer events. A nil
+// filter accepts every event. Callers must Close the subscription when done.
+func (h *Hub[T]) Subscribe(buffer int, filter func(T) bool) *Subscription[T] {
+	ch := make(chan T, buffer)
+	sub := &Subscription[T]{C: ch, hub: h, ch: ch, filter: filter}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Publish hands the event to every matching subscription without blocking.
+// Subscriptions whose buffer is full are dropped with ErrSlowConsumer.
+func (h *Hub[T]) Publish(event T) {
+	var slow []*Subscription[T]
+
+	h.mu.RLock()
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range slow {
+		sub.close(ErrSlowConsumer)
+	}
+}
+
+// Len returns the number of active subscriptions.
+func (h *Hub[T]) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+// Close unsubscribes. It is safe to call more than once and after the
+// subscription was dropped.
+func (s *Subscription[T]) Close() {
+	s.close(nil)
+}
+
+// Err returns ErrSlowConsumer once the subscription was dropped, and nil
+// otherwise. It is meaningful after C has been closed.
+func (s *Subscription[T]) Err() error {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	return s.err
+}
+
+func (s *Subscription[T]) close(err error) {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+
+		delete(s.hub.subs, s)
+		s.err = err
+		close(s.ch)
+	})
+}
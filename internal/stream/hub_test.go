@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHubDeliversMatchingEvents(t *testing.T) {
+	hub := NewHub[int]()
+	even := hub.Subscribe(4, func(n int) bool { return n%2 == 0 })
+	all := hub.Subscribe(4, nil)
+	defer even.Close()
+	defer all.Close()
+
+	for i := 1; i <= 4; i++ {
+		hub.Publish(i)
+	}
+
+	if got := drain(even.C, 2); got[0] != 2 || got[1] != 4 {
+		t.Errorf("filtered subscription got %v", got)
+	}
+	if got := drain(all.C, 4); got[0] != 1 || got[3] != 4 {
+		t.Errorf("unfiltered subscription got %v", got)
+	}
+}
+
+func TestHubDropsSlowConsumer(t *testing.T) {
+	hub := NewHub[int]()
+	slow := hub.Subscribe(1, nil)
+	fast := hub.Subscribe(4, nil)
+	defer fast.Close()
+
+	hub.Publish(1)
+	hub.Publish(2)
+
+	if got := drain(slow.C, 1); got[0] != 1 {
+		t.Errorf("expected buffered event before drop, got %v", got)
+	}
+	if _, ok := <-slow.C; ok {
+		t.Fatal("expected slow subscription to be closed")
+	}
+	if !errors.Is(slow.Err(), ErrSlowConsumer) {
+		t.Errorf("expected ErrSlowConsumer, got %v", slow.Err())
+	}
+	if hub.Len() != 1 {
+		t.Errorf("expected 1 remaining subscription, got %d", hub.Len())
+	}
+	if got := drain(fast.C, 2); got[1] != 2 {
+		t.Errorf("fast subscription got %v", got)
+	}
+}
+
+func TestSubscriptionCloseIsIdempotent(t *testing.T) {
+	hub := NewHub[int]()
+	sub := hub.Subscribe(1, nil)
+
+	sub.Close()
+	sub.Close()
+	hub.Publish(1)
+
+	if _, ok := <-sub.C; ok {
+		t.Error("expected closed channel")
+	}
+	if sub.Err() != nil {
+		t.Errorf("expected nil error after Close, got %v", sub.Err())
+	}
+	if hub.Len() != 0 {
+		t.Errorf("expected no subscriptions, got %d", hub.Len())
+	}
+}
+
+func drain(ch <-chan int, n int) []int {
+	got := make([]int, n)
+	for i := range got {
+		got[i] = <-ch
+	}
+	return got
+}
@@ -79,14 +79,27 @@ export interface TracesQuery {
   offset?: number;
 }
 
+// The user logged in to the dashboard
+export interface Session {
+  subject: string;
+  email?: string;
+  name?: string;
+  role: string;
+  created_at: string;
+  expires_at: string;
+}
+
 class ApiClient {
   private baseUrl: string;
-  private apiKey: string | null = null;
 
+  // Requests are authenticated by the session cookie set at login.
   constructor(baseUrl: string = API_BASE_URL) {
     this.baseUrl = baseUrl;
-    // For now, we'll handle auth later
-    this.apiKey = 'your-secure-api-key-here'; // TODO: Make this configurable
+  }
+
+  private redirectToLogin(): void {
+    const returnTo = window.location.pathname + window.location.search;
+    window.location.href = `/auth/login?return_to=${encodeURIComponent(returnTo)}`;
   }
 
   private async request<T>(endpoint: string, options: RequestInit = {}): Promise<T> {
@@ -97,15 +110,16 @@ class ApiClient {
       ...options.headers as Record<string, string>,
     };
 
-    if (this.apiKey) {
-      headers['X-API-Key'] = this.apiKey;
-    }
-
     const response = await fetch(url, {
       ...options,
       headers,
+      credentials: 'same-origin',
     });
 
+    if (response.status === 401) {
+      this.redirectToLogin();
+    }
+
     if (!response.ok) {
       throw new Error(`API request failed: ${response.status} ${response.statusText}`);
     }
@@ -126,6 +140,30 @@ class ApiClient {
     return queryString ? `?${queryString}` : '';
   }
 
+  // Current session, or null when login is not configured
+  async getSession(): Promise<Session | null> {
+    const response = await fetch('/auth/session', { credentials: 'same-origin' });
+    if (response.status === 404) {
+      return null;
+    }
+    if (response.status === 401) {
+      this.redirectToLogin();
+    }
+    if (!response.ok) {
+      throw new Error(`Session request failed: ${response.status} ${response.statusText}`);
+    }
+    return response.json();
+  }
+
+  // Logout is a form POST so the browser follows the redirect to the provider
+  logout(): void {
+    const form = document.createElement('form');
+    form.method = 'POST';
+    form.action = '/auth/logout';
+    document.body.appendChild(form);
+    form.submit();
+  }
+
   // Health check (no auth required)
   async getHealth(): Promise<string> {
     const response = await fetch(`${this.baseUrl}/health`);